@@ -3,17 +3,75 @@ package main
 import (
 	"encoding/json"
 	"os"
+	"time"
 )
 
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var str string
+	err := json.Unmarshal(data, &str)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(str)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// DriveConfig is one tape drive of the library, for libraries with several drives
+type DriveConfig struct {
+	Device         string `json:"device"`
+	Mount          string `json:"mount"`
+	ElementAddress *int   `json:"element-address"`
+}
+
+// PartitionConfig restricts tapemgr to part of a library shared with other software
+type PartitionConfig struct {
+	// Slots are storage element addresses or inclusive ranges like "4096-4119"
+	Slots []string `json:"slots"`
+	// Barcodes are regular expressions, data tapes must match one of them
+	Barcodes []string `json:"barcodes"`
+}
+
 type Config struct {
-	LoaderDevice string   `json:"loader-device"`
-	DriveDevice  string   `json:"drive-device"`
-	TapeMount    string   `json:"tape-mount"`
-	TapeFileKey  string   `json:"tape-file-key"`
-	TapePathKey  string   `json:"tape-path-key"`
-	TapesPath    string   `json:"tapes-path"`
-	DryRun       bool     `json:"dry-run"`
-	Targets      []string `json:"targets"`
+	LoaderDevice   string   `json:"loader-device"`
+	DriveDevice    string   `json:"drive-device"`
+	TapeMount      string   `json:"tape-mount"`
+	TapeFileKey    string   `json:"tape-file-key"`
+	TapePathKey    string   `json:"tape-path-key"`
+	TapesPath      string   `json:"tapes-path"`
+	DryRun         bool     `json:"dry-run"`
+	Targets        []string `json:"targets"`
+	VolumeTag      string   `json:"volume-tag"`
+	PoolID         string   `json:"pool-id"`
+	AutoClean      bool     `json:"auto-clean"`
+	CleaningCycles int      `json:"cleaning-cycles"`
+	ReadyTimeout   Duration `json:"ready-timeout"`
+	MountTimeout   Duration `json:"mount-timeout"`
+
+	DriveElementAddress *int `json:"drive-element-address"`
+
+	// DriveEncryptionKeyID enables drive-native encryption instead of age, with a key derived from tape-file-key
+	DriveEncryptionKeyID string `json:"drive-encryption-key-id"`
+
+	// StandaloneTapes are the barcodes of the tapes kept next to a drive without a loader
+	StandaloneTapes []string `json:"standalone-tapes"`
+
+	// ScratchBarcodes are regular expressions of blank tapes that may be formatted when a new tape is needed,
+	// other unknown tapes must be registered with scratch-add first
+	ScratchBarcodes []string `json:"scratch-barcodes"`
+
+	// Partition limits the slots and tapes tapemgr touches, the whole library is used if unset
+	Partition *PartitionConfig `json:"partition"`
+
+	// Drives replaces DriveDevice, TapeMount and DriveElementAddress to use several drives
+	Drives []DriveConfig `json:"drives"`
 }
 
 func loadConfig(path string) (Config, error) {
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/FoxDenHome/tapemgr/scsi/discover"
+)
+
+func printDiscovery(result *discover.Result, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	describe := func(device *discover.Device) (string, string) {
+		product := "-"
+		if device.Inquiry != nil {
+			product = fmt.Sprintf("%s %s %s", device.Inquiry.Vendor, device.Inquiry.Product, device.Inquiry.Revision)
+		}
+		serial := device.Serial
+		if device.Error != "" {
+			serial = "error: " + device.Error
+		}
+		return product, serial
+	}
+
+	if len(result.Changers) == 0 {
+		_, _ = fmt.Fprintln(writer, "No changers found")
+	} else {
+		_, _ = fmt.Fprintln(writer, "CHANGER\tGENERIC\tPRODUCT\tSERIAL")
+		for _, changer := range result.Changers {
+			product, serial := describe(changer)
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", changer.Path, changer.GenericPath, product, serial)
+		}
+	}
+
+	if len(result.Drives) == 0 {
+		_, _ = fmt.Fprintln(writer, "\nNo tape drives found")
+	} else {
+		_, _ = fmt.Fprintln(writer, "\nDRIVE\tGENERIC\tPRODUCT\tSERIAL\tCHANGER\tELEMENT")
+		for _, drive := range result.Drives {
+			product, serial := describe(&drive.Device)
+			changer, address := "-", "-"
+			if drive.Changer != "" {
+				changer = drive.Changer
+				address = fmt.Sprintf("%d", *drive.ElementAddress)
+			}
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", drive.Path, drive.GenericPath, product, serial, changer, address)
+		}
+	}
+
+	return writer.Flush()
+}
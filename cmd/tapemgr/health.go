@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/storage/manager"
+)
+
+func printDriveHealth(healths []*manager.DriveHealth, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(healths)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, health := range healths {
+		if i > 0 {
+			_, _ = fmt.Fprintln(writer)
+		}
+		_, _ = fmt.Fprintf(writer, "Drive %d\n", health.ElementAddress)
+		printDriveHealthCounters(writer, health)
+	}
+	return writer.Flush()
+}
+
+func printDriveHealthCounters(writer *tabwriter.Writer, health *manager.DriveHealth) {
+
+	if len(health.TapeAlerts) == 0 {
+		_, _ = fmt.Fprintln(writer, "No TapeAlert flags set")
+	} else {
+		_, _ = fmt.Fprintln(writer, "FLAG\tSEVERITY\tTAPEALERT")
+		for _, alert := range health.TapeAlerts {
+			_, _ = fmt.Fprintf(writer, "%d\t%s\t%s\n", alert.Flag, alert.Severity, alert.Name)
+		}
+	}
+
+	if health.WriteErrors != nil || health.ReadErrors != nil {
+		counters := func(name string, value func(c *scsi.ErrorCounters) uint64) {
+			write, read := "-", "-"
+			if health.WriteErrors != nil {
+				write = fmt.Sprintf("%d", value(health.WriteErrors))
+			}
+			if health.ReadErrors != nil {
+				read = fmt.Sprintf("%d", value(health.ReadErrors))
+			}
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\n", name, write, read)
+		}
+
+		_, _ = fmt.Fprintln(writer, "\nERROR COUNTER\tWRITE\tREAD")
+		counters("Corrected without delay", func(c *scsi.ErrorCounters) uint64 { return c.CorrectedWithoutDelay })
+		counters("Corrected with delay", func(c *scsi.ErrorCounters) uint64 { return c.CorrectedWithDelay })
+		counters("Retries", func(c *scsi.ErrorCounters) uint64 { return c.Retries })
+		counters("Corrected", func(c *scsi.ErrorCounters) uint64 { return c.Corrected })
+		counters("Correction invocations", func(c *scsi.ErrorCounters) uint64 { return c.CorrectionInvocations })
+		counters("Bytes processed", func(c *scsi.ErrorCounters) uint64 { return c.BytesProcessed })
+		counters("Uncorrected", func(c *scsi.ErrorCounters) uint64 { return c.Uncorrected })
+	}
+
+	if stats := health.VolumeStatistics; stats != nil {
+		_, _ = fmt.Fprintln(writer, "\nVOLUME STATISTIC\tVALUE")
+		_, _ = fmt.Fprintf(writer, "Mounts\t%d\n", stats.Mounts)
+		_, _ = fmt.Fprintf(writer, "Datasets written\t%d\n", stats.DatasetsWritten)
+		_, _ = fmt.Fprintf(writer, "Datasets read\t%d\n", stats.DatasetsRead)
+		_, _ = fmt.Fprintf(writer, "Write errors (recovered/unrecovered)\t%d/%d\n", stats.RecoveredWriteErrors, stats.UnrecoveredWriteErrors)
+		_, _ = fmt.Fprintf(writer, "Read errors (recovered/unrecovered)\t%d/%d\n", stats.RecoveredReadErrors, stats.UnrecoveredReadErrors)
+		_, _ = fmt.Fprintf(writer, "Last mount written/read\t%d MB/%d MB\n", stats.LastMountMegabytesWritten, stats.LastMountMegabytesRead)
+		_, _ = fmt.Fprintf(writer, "Lifetime written/read\t%d MB/%d MB\n", stats.LifetimeMegabytesWritten, stats.LifetimeMegabytesRead)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/storage/manager"
+)
+
+func printDeviceInfo(info *manager.DeviceInfo, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if info.Changer == nil {
+		_, _ = fmt.Fprintln(writer, "Changer\tnone (standalone drive)")
+	} else {
+		_, _ = fmt.Fprintln(writer, "Changer")
+		printDeviceInfoFields(writer, info.Changer)
+	}
+	for _, drive := range info.Drives {
+		_, _ = fmt.Fprintf(writer, "\nDrive %d\n", drive.ElementAddress)
+		printDeviceInfoFields(writer, drive.DeviceInfo)
+	}
+	return writer.Flush()
+}
+
+func printDeviceInfoFields(writer *tabwriter.Writer, info *scsi.DeviceInfo) {
+	if inquiry := info.Inquiry; inquiry != nil {
+		_, _ = fmt.Fprintf(writer, "Vendor\t%s\n", inquiry.Vendor)
+		_, _ = fmt.Fprintf(writer, "Product\t%s\n", inquiry.Product)
+		_, _ = fmt.Fprintf(writer, "Firmware\t%s\n", inquiry.Revision)
+		_, _ = fmt.Fprintf(writer, "Device type\t%#02x\n", inquiry.DeviceType)
+	}
+	_, _ = fmt.Fprintf(writer, "Serial number\t%s\n", info.Serial)
+
+	pages := make([]string, 0, len(info.VPDPages))
+	for _, page := range info.VPDPages {
+		pages = append(pages, page.String())
+	}
+	_, _ = fmt.Fprintf(writer, "VPD pages\t%s\n", strings.Join(pages, ", "))
+
+	for _, identifier := range info.Identifiers {
+		_, _ = fmt.Fprintf(writer, "Identifier\t%s\n", identifier)
+	}
+	if capabilities := info.SequentialAccess; capabilities != nil {
+		_, _ = fmt.Fprintf(writer, "WORM support\t%t\n", capabilities.WORM)
+	}
+}
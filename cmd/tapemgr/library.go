@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/FoxDenHome/tapemgr/storage/manager"
+	"github.com/FoxDenHome/tapemgr/util"
+)
+
+func printLibraryStatus(statuses []*manager.ElementStatus, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(statuses)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "TYPE\tADDRESS\tSTATE\tBARCODE\tSOURCE\tTAPE\tEXCEPTION")
+	for _, status := range statuses {
+		state := "empty"
+		if status.Full {
+			state = "full"
+		}
+		if !status.Accessible {
+			state += ", inaccessible"
+		}
+
+		source := ""
+		if status.Source != nil {
+			source = fmt.Sprintf("%d", *status.Source)
+		}
+
+		tape := ""
+		if status.Full {
+			if status.Cleaning {
+				tape = fmt.Sprintf("cleaning, %d uses left", status.UsesLeft)
+			} else if status.Known {
+				tape = fmt.Sprintf("%s used, %s free", util.FormatSize(status.Size-status.Free), util.FormatSize(status.Free))
+			} else {
+				tape = "new"
+			}
+		}
+
+		_, _ = fmt.Fprintf(writer, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", status.Type, status.Address, state, status.Barcode, source, tape, status.Exception)
+	}
+	return writer.Flush()
+}
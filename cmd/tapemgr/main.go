@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
 	"encoding/base64"
+	"errors"
 	"flag"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/discover"
 	"github.com/FoxDenHome/tapemgr/scsi/drive"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
 	"github.com/FoxDenHome/tapemgr/scsi/loader"
+	"github.com/FoxDenHome/tapemgr/scsi/sim"
 	"github.com/FoxDenHome/tapemgr/storage/encryption"
 	"github.com/FoxDenHome/tapemgr/storage/inventory"
 	"github.com/FoxDenHome/tapemgr/storage/manager"
@@ -27,17 +37,52 @@ func main() {
 		log.Fatalf("Failed to load config %s: %v", configFile, err)
 	}
 
-	loaderDeviceStr := flag.String("loader-device", config.LoaderDevice, "Path to the SCSI tape loader device")
-	driveDeviceStr := flag.String("drive-device", config.DriveDevice, "Path to the SCSI tape drive device")
+	loaderDeviceStr := flag.String("loader-device", config.LoaderDevice, "Path or serial number of the SCSI tape loader device (or sim:///path for a simulated library, empty for a standalone drive)")
+	driveDeviceStr := flag.String("drive-device", config.DriveDevice, "Path or serial number of the SCSI tape drive device (or serial of the simulated drive)")
 	tapeMount := flag.String("tape-mount", config.TapeMount, "Path to the tape mount point")
 	tapesPath := flag.String("tapes-path", config.TapesPath, "Path to the tapes directory")
-	cmdMode := flag.String("mode", "help", "Mode to run in (scan, statistics, backup, restore-tape, restore-file, mount, format)")
+	cmdMode := flag.String("mode", "help", "Mode to run in (scan, statistics, backup, restore-tape, restore-file, mount, format, scratch-add, scratch-remove, scratch-list, export, import, move, unload, exchange, library, drive-health, tape-info, info, clean, discover)")
+	jsonOutput := flag.Bool("json", false, "Print machine readable JSON (library, drive-health, tape-info, info and discover modes)")
+	volumeTag := flag.String("volume-tag", config.VolumeTag, "Which volume tag identifies tapes (primary, alternate)")
+	poolID := flag.String("pool-id", config.PoolID, "Pool or installation ID written into tape labels, tapes labelled with another pool are not written to")
+	autoClean := flag.Bool("auto-clean", config.AutoClean, "Clean the drive after unloading a tape when it asks for cleaning")
+	cleaningCycles := flag.Int("cleaning-cycles", config.CleaningCycles, "How often a cleaning cartridge can be used (0 for default)")
+	driveEncryptionKeyID := flag.String("drive-encryption-key-id", config.DriveEncryptionKeyID, "Have the drives encrypt new tapes with the key of this ID (derived from the tape file key) instead of encrypting files with age")
+	force := flag.Bool("force", false, "Format tapes that already carry an LTFS volume (format mode)")
+	unlock := flag.Bool("unlock", false, "Allow medium removal on the library and drive again (after a crashed run left them locked) and exit")
 	dryRun := flag.Bool("dry-run", config.DryRun, "Dry run mode (do not perform any write operations)")
+	readyTimeout := flag.Duration("ready-timeout", time.Duration(config.ReadyTimeout), "How long to wait for the drive to become ready after loading a tape (0 for default)")
+	mountTimeout := flag.Duration("mount-timeout", time.Duration(config.MountTimeout), "How long to wait for LTFS to mount a tape (0 for default)")
+	defaultDriveAddress := manager.DRIVE_ADDRESS_AUTO
+	if config.DriveElementAddress != nil {
+		defaultDriveAddress = *config.DriveElementAddress
+	}
+	driveAddress := flag.Int("drive-element-address", defaultDriveAddress, "Element address of the tape drive in the loader (-1 to match by drive identifiers)")
 	flag.Parse()
 	manager.DryRun = *dryRun
 
+	driveConfigs := config.Drives
+	if len(driveConfigs) == 0 {
+		driveConfigs = []DriveConfig{{Device: *driveDeviceStr, Mount: *tapeMount, ElementAddress: driveAddress}}
+	} else if *driveDeviceStr != "" || *tapeMount != "" || *driveAddress != manager.DRIVE_ADDRESS_AUTO {
+		log.Fatalf("The drives config replaces drive-device, tape-mount and drive-element-address, do not set both")
+	}
+
 	log.Printf("tapemgr (version %s / git %s) starting up", util.GetVersion(), util.GetGitRev())
 
+	// Runs before the devices are opened, as it is how to find out what to configure
+	if strings.ToLower(*cmdMode) == "discover" {
+		result, err := discover.New().Discover()
+		if err != nil {
+			log.Fatalf("Failed to discover devices: %v", err)
+		}
+		err = printDiscovery(result, *jsonOutput)
+		if err != nil {
+			log.Fatalf("Failed to print discovered devices: %v", err)
+		}
+		return
+	}
+
 	fileCryptor, err := encryption.NewFileCryptor(config.TapeFileKey)
 	if err != nil {
 		log.Fatalf("Failed to create file cryptor: %v", err)
@@ -53,14 +98,90 @@ func main() {
 		log.Fatalf("Failed to create path cryptor: %v", err)
 	}
 
-	loaderDevice, err := loader.NewTapeLoader(*loaderDeviceStr)
-	if err != nil {
-		log.Fatalf("Failed to create tape loader: %v", err)
+	var loaderDevice loader.Changer
+	var driveDevices []manager.DriveConfig
+	if sim.IsSimulated(*loaderDeviceStr) {
+		library, err := sim.Open(*loaderDeviceStr)
+		if err != nil {
+			log.Fatalf("Failed to open simulated library: %v", err)
+		}
+		for _, driveConfig := range driveConfigs {
+			simDrive, err := library.Drive(driveConfig.Device)
+			if err != nil {
+				log.Fatalf("Failed to find simulated tape drive: %v", err)
+			}
+			driveDevices = append(driveDevices, manager.DriveConfig{
+				Drive:          simDrive,
+				ElementAddress: driveElementAddress(driveConfig),
+			})
+		}
+		log.Printf("Using simulated library at %s", *loaderDeviceStr)
+		loaderDevice = library
+	} else {
+		discoverer := discover.New()
+		if *loaderDeviceStr != "" {
+			loaderPath, err := discoverer.ChangerPath(*loaderDeviceStr)
+			if err != nil {
+				log.Fatalf("Failed to find tape loader: %v", err)
+			}
+			if loaderPath != *loaderDeviceStr {
+				log.Printf("Using tape loader %s", loaderPath)
+			}
+
+			tapeLoader, err := loader.NewTapeLoader(loaderPath)
+			if err != nil {
+				log.Fatalf("Failed to create tape loader: %v", err)
+			}
+			tapeLoader.VolumeTagSource, err = element.ParseVolumeTagSource(*volumeTag)
+			if err != nil {
+				log.Fatalf("Invalid volume tag setting: %v", err)
+			}
+			loaderDevice = tapeLoader
+		}
+
+		for _, driveConfig := range driveConfigs {
+			drivePath, err := discoverer.DrivePath(driveConfig.Device)
+			if err != nil {
+				log.Fatalf("Failed to find tape drive: %v", err)
+			}
+			if drivePath != driveConfig.Device {
+				log.Printf("Using tape drive %s", drivePath)
+			}
+
+			tapeDrive, err := drive.NewTapeDrive(drivePath, driveConfig.Mount)
+			if err != nil {
+				log.Fatalf("Failed to create tape drive: %v", err)
+			}
+			if *readyTimeout > 0 {
+				tapeDrive.ReadyTimeout = *readyTimeout
+			}
+			if *mountTimeout > 0 {
+				tapeDrive.MountTimeout = *mountTimeout
+			}
+
+			driveDevices = append(driveDevices, manager.DriveConfig{
+				Drive:          tapeDrive,
+				ElementAddress: driveElementAddress(driveConfig),
+			})
+		}
+
+		if loaderDevice == nil {
+			log.Printf("No tape loader configured, tapes are changed by hand")
+			loaderDevice = manager.NewManualChanger(driveDevices[0].Drive, config.StandaloneTapes)
+		}
 	}
 
-	driveDevice, err := drive.NewTapeDrive(*driveDeviceStr, *tapeMount)
-	if err != nil {
-		log.Fatalf("Failed to create tape drive: %v", err)
+	if config.Partition != nil {
+		if _, ok := loaderDevice.(*manager.ManualChanger); ok {
+			log.Fatalf("A partition cannot be configured for a standalone drive")
+		}
+
+		partition, err := manager.ParsePartition(config.Partition.Slots, config.Partition.Barcodes)
+		if err != nil {
+			log.Fatalf("Invalid partition: %v", err)
+		}
+		log.Printf("Restricting tapemgr to library partition: %s", partition)
+		loaderDevice = manager.NewPartitionChanger(loaderDevice, partition)
 	}
 
 	log.Printf("Loading tape inventory...")
@@ -72,25 +193,67 @@ func main() {
 
 	log.Printf("Loaded %d tapes from inventory", inv.TapeCount())
 
-	fileManager, err = manager.New(fileCryptor, nameCryptor, inv, loaderDevice, driveDevice)
+	fileManager, err = manager.New(fileCryptor, nameCryptor, inv, loaderDevice, driveDevices...)
 	if err != nil {
 		log.Fatalf("Failed to create manager: %v", err)
 	}
 
+	err = manager.ValidatePoolID(*poolID)
+	if err != nil {
+		log.Fatalf("Invalid pool ID: %v", err)
+	}
+	fileManager.PoolID = *poolID
+	fileManager.AutoClean = *autoClean
+	if *cleaningCycles > 0 {
+		fileManager.CleaningCycles = *cleaningCycles
+	}
+	fileManager.ScratchBarcodes, err = manager.ParseScratchBarcodes(config.ScratchBarcodes)
+	if err != nil {
+		log.Fatalf("Invalid scratch barcodes: %v", err)
+	}
+	if *driveEncryptionKeyID != "" {
+		fileManager.DriveEncryption, err = encryption.NewDriveKeys(config.TapeFileKey, *driveEncryptionKeyID)
+		if err != nil {
+			log.Fatalf("Failed to derive drive encryption keys: %v", err)
+		}
+	}
+
 	log.Printf("tapemgr startup done, parsing command")
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Printf("Received %v, stopping current operation (send again to force quit)", sig)
+		signal.Stop(signals)
+		cancel()
+	}()
+
+	if *unlock {
+		err := fileManager.Unlock()
+		if err != nil {
+			log.Fatalf("Failed to unlock library: %v", err)
+		}
+		log.Printf("Library and drive unlocked")
+		return
+	}
+
 	switch strings.ToLower(*cmdMode) {
 	case "scan":
+		lockLibrary(true)
 		defer putLibraryToIdle()
 
 		barcode := flag.Arg(0)
 		if barcode == "" {
-			log.Fatalf("No barcode provided for scan")
+			fatalf("No barcode provided for scan")
 		}
 
-		err := fileManager.ScanTape(barcode)
+		err := fileManager.ScanTape(ctx, barcode)
 		if err != nil {
-			log.Fatalf("Failed to scan tape %s: %v", barcode, err)
+			fatalf("Failed to scan tape %s: %v", barcode, err)
 		}
 
 	case "statistics":
@@ -99,53 +262,252 @@ func main() {
 			size := tape.GetSize()
 
 			fileCount := len(tape.GetFiles())
+			// Tapes whose format was interrupted have no filesystem size yet
+			var usedPercent int64
+			if size > 0 {
+				usedPercent = (100 * (size - free)) / size
+			}
+
+			notes := ""
+			if tape.GetWriteProtected() {
+				notes = ", read-only (write-protected)"
+			}
+			if keyID := tape.GetEncryptionKeyId(); keyID != "" {
+				notes += ", drive-encrypted with key " + keyID
+			}
 
 			log.Printf(
-				"Tape: %s, Size: %s (%s free, %d%% used by %d %s)",
+				"Tape: %s (%s), Size: %s (%s free, %d%% used by %d %s)%s",
 				tape.GetBarcode(),
+				tape.Media(),
 				util.FormatSize(size),
 				util.FormatSize(free),
-				(100*(size-free))/size,
+				usedPercent,
 				fileCount,
 				util.PluralizeS("file", fileCount),
+				notes,
 			)
+
+			for _, alert := range tape.GetAlerts() {
+				flag := scsi.TapeAlertFlag(alert.GetFlag())
+				log.Printf("  TapeAlert at %s: %s", alert.GetTime().AsTime().Format(time.RFC3339), flag)
+			}
+			for _, write := range tape.GetDriveWrites() {
+				log.Printf(
+					"  Written by %s %s serial %s firmware %s from %s to %s",
+					write.GetVendor(),
+					write.GetProduct(),
+					write.GetSerialNumber(),
+					write.GetFirmware(),
+					write.GetFirstWrite().AsTime().Format(time.RFC3339),
+					write.GetLastWrite().AsTime().Format(time.RFC3339),
+				)
+			}
+		}
+
+	case "library", "status":
+		statuses, err := fileManager.LibraryStatus()
+		if err != nil {
+			fatalf("Failed to read library status: %v", err)
+		}
+
+		err = printLibraryStatus(statuses, *jsonOutput)
+		if err != nil {
+			fatalf("Failed to print library status: %v", err)
+		}
+
+	case "drive-health":
+		health, err := fileManager.DriveHealth()
+		if err != nil {
+			fatalf("Failed to read drive health: %v", err)
+		}
+
+		err = printDriveHealth(health, *jsonOutput)
+		if err != nil {
+			fatalf("Failed to print drive health: %v", err)
+		}
+
+	case "info":
+		info, err := fileManager.DeviceInfo()
+		if err != nil {
+			fatalf("Failed to read device info: %v", err)
+		}
+
+		err = printDeviceInfo(info, *jsonOutput)
+		if err != nil {
+			fatalf("Failed to print device info: %v", err)
+		}
+
+	case "tape-info":
+		lockLibrary(true)
+		defer putLibraryToIdle()
+
+		barcode := flag.Arg(0)
+		if barcode == "" {
+			fatalf("No barcode provided for tape-info")
+		}
+
+		info, err := fileManager.TapeInfo(ctx, barcode)
+		if err != nil {
+			fatalf("Failed to read tape info of %s: %v", barcode, err)
+		}
+
+		err = printTapeInfo(info, *jsonOutput)
+		if err != nil {
+			fatalf("Failed to print tape info: %v", err)
+		}
+
+	case "clean":
+		lockLibrary(true)
+		defer unlockLibrary()
+
+		err := fileManager.CleanDrives(ctx)
+		if err != nil {
+			fatalf("Failed to clean drives: %v", err)
 		}
 
 	case "backup":
+		lockLibrary(true)
 		defer putLibraryToIdle()
 
-		err = fileManager.Backup(config.Targets...)
+		err = fileManager.Backup(ctx, config.Targets...)
 		if err != nil {
-			log.Fatalf("Failed to backup: %v", err)
+			fatalf("Failed to backup: %v", err)
 		}
 
 	case "mount":
+		lockLibrary(true)
 		defer putLibraryToIdle()
 
 		barcode := flag.Arg(0)
 		if barcode == "" {
-			log.Fatalf("No barcode provided for scan")
+			fatalf("No barcode provided for scan")
 		}
 
-		err := fileManager.MountTapeWait(barcode)
+		err := fileManager.MountTapeWait(ctx, barcode)
 		if err != nil {
-			log.Fatalf("Failed to mount tape %s: %v", barcode, err)
+			fatalf("Failed to mount tape %s: %v", barcode, err)
 		}
 
 	case "format":
+		lockLibrary(true)
 		defer putLibraryToIdle()
 
 		barcode := flag.Arg(0)
 		if barcode == "" {
-			log.Fatalf("No barcode provided for format")
+			fatalf("No barcode provided for format")
+		}
+
+		err := fileManager.FormatTape(ctx, barcode, *force)
+		if errors.Is(err, manager.ErrTapeHasVolume) {
+			fatalf("Not formatting tape %s: %v, pass -force to overwrite it", barcode, err)
+		}
+		if err != nil {
+			fatalf("Failed to format tape %s: %v", barcode, err)
+		}
+
+	case "scratch-add":
+		barcodes := flag.Args()
+		if len(barcodes) == 0 {
+			fatalf("No barcodes provided for scratch-add")
+		}
+
+		err := fileManager.AddScratchTapes(barcodes...)
+		if err != nil {
+			fatalf("Failed to add scratch tapes: %v", err)
+		}
+
+	case "scratch-remove":
+		barcodes := flag.Args()
+		if len(barcodes) == 0 {
+			fatalf("No barcodes provided for scratch-remove")
+		}
+
+		err := fileManager.RemoveScratchTapes(barcodes...)
+		if err != nil {
+			fatalf("Failed to remove scratch tapes: %v", err)
+		}
+
+	case "scratch-list":
+		for _, pattern := range fileManager.ScratchBarcodes {
+			log.Printf("Scratch barcode pattern: %s", pattern)
+		}
+		for _, barcode := range fileManager.ScratchTapes() {
+			log.Printf("Scratch tape: %s", barcode)
+		}
+
+	case "export":
+		lockLibrary(false)
+		defer unlockLibrary()
+
+		barcodes := flag.Args()
+		if len(barcodes) == 0 {
+			fatalf("No barcodes provided for export")
+		}
+
+		err := fileManager.ExportTapes(barcodes...)
+		if err != nil {
+			fatalf("Failed to export tapes: %v", err)
+		}
+
+	case "move":
+		lockLibrary(false)
+		defer unlockLibrary()
+
+		barcode := flag.Arg(0)
+		if barcode == "" || flag.NArg() != 2 {
+			fatalf("Usage: -mode move <barcode> <slot>")
+		}
+
+		err := fileManager.MoveTape(barcode, parseElementAddress(flag.Arg(1)))
+		if err != nil {
+			fatalf("Failed to move tape %s: %v", barcode, err)
+		}
+
+	case "unload":
+		lockLibrary(false)
+		defer unlockLibrary()
+
+		var err error
+		switch flag.NArg() {
+		case 0:
+			err = fileManager.UnloadDrives()
+		case 1:
+			err = fileManager.UnloadDrive(parseElementAddress(flag.Arg(0)), nil)
+		case 2:
+			slot := parseElementAddress(flag.Arg(1))
+			err = fileManager.UnloadDrive(parseElementAddress(flag.Arg(0)), &slot)
+		default:
+			fatalf("Usage: -mode unload [drive [slot]]")
+		}
+		if err != nil {
+			fatalf("Failed to unload: %v", err)
+		}
+
+	case "exchange":
+		lockLibrary(false)
+		defer unlockLibrary()
+
+		if flag.NArg() != 2 {
+			fatalf("Usage: -mode exchange <barcode> <barcode>")
 		}
 
-		err := fileManager.FormatTape(barcode)
+		err := fileManager.ExchangeTapes(flag.Arg(0), flag.Arg(1))
+		if err != nil {
+			fatalf("Failed to exchange tapes: %v", err)
+		}
+
+	case "import":
+		lockLibrary(false)
+		defer unlockLibrary()
+
+		err := fileManager.ImportTapes()
 		if err != nil {
-			log.Fatalf("Failed to format tape %s: %v", barcode, err)
+			fatalf("Failed to import tapes: %v", err)
 		}
 
 	case "restore-tape":
+		lockLibrary(true)
 		defer putLibraryToIdle()
 
 		target := flag.Arg(0)
@@ -155,14 +517,15 @@ func main() {
 			tapesMap[tape] = true
 		}
 
-		err := fileManager.Restore(func(path string, info inventory.File) bool {
+		err := fileManager.Restore(ctx, func(path string, info inventory.File) bool {
 			return tapesMap[info.GetTape().GetBarcode()]
 		}, target)
 		if err != nil {
-			log.Fatalf("Failed to restore tapes: %v", err)
+			fatalf("Failed to restore tapes: %v", err)
 		}
 
 	case "restore-file":
+		lockLibrary(true)
 		defer putLibraryToIdle()
 
 		target := flag.Arg(0)
@@ -172,7 +535,7 @@ func main() {
 			files[i] = strings.Trim(file, "/")
 		}
 
-		err := fileManager.Restore(func(path string, info inventory.File) bool {
+		err := fileManager.Restore(ctx, func(path string, info inventory.File) bool {
 			for _, file := range files {
 				if file == path {
 					return true
@@ -184,7 +547,7 @@ func main() {
 			return false
 		}, target)
 		if err != nil {
-			log.Fatalf("Failed to restore files: %v", err)
+			fatalf("Failed to restore files: %v", err)
 		}
 
 	case "help":
@@ -196,12 +559,71 @@ func main() {
 		return
 	}
 
+	if ctx.Err() != nil {
+		fatalf("Interrupted by signal")
+	}
+
 	log.Printf("tapemgr command done, shutting down")
 }
 
+func driveElementAddress(driveConfig DriveConfig) int {
+	if driveConfig.ElementAddress == nil {
+		return manager.DRIVE_ADDRESS_AUTO
+	}
+	return *driveConfig.ElementAddress
+}
+
+func parseElementAddress(str string) uint16 {
+	address, err := strconv.ParseUint(str, 0, 16)
+	if err != nil {
+		fatalf("Invalid element address %q: %v", str, err)
+	}
+	return uint16(address)
+}
+
+var libraryLocked bool
+
+// unloadOnExit is set by commands that load tapes, fatalf puts those back into storage
+var unloadOnExit bool
+
+// lockLibrary keeps tapes from being removed by hand while the command moves or writes them
+func lockLibrary(loadsTapes bool) {
+	err := fileManager.Lock()
+	if err != nil {
+		fatalf("Failed to lock library: %v", err)
+	}
+	libraryLocked = !manager.DryRun
+	unloadOnExit = loadsTapes
+}
+
+func unlockLibrary() {
+	if !libraryLocked {
+		return
+	}
+	err := fileManager.Unlock()
+	if err != nil {
+		log.Printf("Error unlocking library, run with -unlock to retry: %v", err)
+		return
+	}
+	libraryLocked = false
+}
+
 func putLibraryToIdle() {
 	err := fileManager.UnmountAndUnload()
 	if err != nil {
 		log.Printf("Error unmounting and unloading tape: %v", err)
 	}
+	unlockLibrary()
+}
+
+// fatalf replaces log.Fatalf once the library is locked, as log.Fatalf skips deferred cleanup
+// Only commands that load tapes unload the drives, a failed status query or move leaves them alone
+func fatalf(format string, v ...any) {
+	log.Printf(format, v...)
+	if unloadOnExit {
+		putLibraryToIdle()
+	} else {
+		unlockLibrary()
+	}
+	os.Exit(1)
 }
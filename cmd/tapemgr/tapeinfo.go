@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/FoxDenHome/tapemgr/storage/inventory"
+	"github.com/FoxDenHome/tapemgr/storage/manager"
+	"github.com/FoxDenHome/tapemgr/util"
+)
+
+func formatMiB(mib uint64) string {
+	return util.FormatSize(int64(mib) * inventory.MIB)
+}
+
+func printTapeInfo(info *manager.TapeInfo, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+
+	mam := info.Medium
+	manufactureDate := mam.ManufactureDate
+	if parsed, err := time.Parse("20060102", manufactureDate); err == nil {
+		manufactureDate = parsed.Format(time.DateOnly)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(writer, "Barcode\t%s\n", info.Barcode)
+	_, _ = fmt.Fprintf(writer, "Label\t%s\n", info.Label)
+	if mam.VolumeLabel != "" {
+		_, _ = fmt.Fprintf(writer, "Volume name\t%s\n", mam.VolumeLabel)
+	}
+	_, _ = fmt.Fprintf(writer, "Manufacturer\t%s\n", mam.Manufacturer)
+	_, _ = fmt.Fprintf(writer, "Serial number\t%s\n", mam.SerialNumber)
+	_, _ = fmt.Fprintf(writer, "Manufacture date\t%s\n", manufactureDate)
+	_, _ = fmt.Fprintf(writer, "Density code\t%#02x\n", mam.DensityCode)
+	_, _ = fmt.Fprintf(writer, "Load count\t%d\n", mam.LoadCount)
+	_, _ = fmt.Fprintf(writer, "Total written\t%s\n", formatMiB(mam.TotalWrittenMiB))
+	_, _ = fmt.Fprintf(writer, "Total read\t%s\n", formatMiB(mam.TotalReadMiB))
+	for _, partition := range mam.Partitions {
+		_, _ = fmt.Fprintf(writer, "Partition %d\t%s of %s remaining\n", partition.Partition, formatMiB(partition.RemainingMiB), formatMiB(partition.MaximumMiB))
+	}
+	if info.Known {
+		_, _ = fmt.Fprintf(writer, "Filesystem\t%s of %s free\n", util.FormatSize(info.Free), util.FormatSize(info.Size))
+	} else {
+		_, _ = fmt.Fprintln(writer, "Filesystem\tnot in inventory")
+	}
+	if application := mam.Application(); application != "" {
+		_, _ = fmt.Fprintf(writer, "Formatted by\t%s\n", application)
+	}
+	if len(mam.LastLoads) > 0 {
+		_, _ = fmt.Fprintf(writer, "Last loaded by\t%s\n", strings.Join(mam.LastLoads, ", "))
+	}
+	return writer.Flush()
+}
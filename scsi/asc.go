@@ -0,0 +1,268 @@
+package scsi
+
+import "fmt"
+
+// Subset of the T10 ASC/ASCQ assignments relevant to tape drives and media changers
+var ascDescriptions = map[uint16]string{
+	0x0000: "No additional sense information",
+	0x0001: "Filemark detected",
+	0x0002: "End-of-partition/medium detected",
+	0x0003: "Setmark detected",
+	0x0004: "Beginning-of-partition/medium detected",
+	0x0005: "End-of-data detected",
+	0x0016: "Operation in progress",
+	0x0017: "Cleaning requested",
+	0x0018: "Erase operation in progress",
+	0x0019: "Locate operation in progress",
+	0x001A: "Rewind operation in progress",
+	0x001B: "Set capacity operation in progress",
+	0x001C: "Verify operation in progress",
+
+	0x0300: "Peripheral device write fault",
+	0x0302: "Excessive write errors",
+
+	0x0400: "Logical unit not ready, cause not reportable",
+	0x0401: "Logical unit is in process of becoming ready",
+	0x0402: "Logical unit not ready, initializing command required",
+	0x0403: "Logical unit not ready, manual intervention required",
+	0x0404: "Logical unit not ready, format in progress",
+	0x0407: "Logical unit not ready, operation in progress",
+	0x0409: "Logical unit not ready, self-test in progress",
+	0x0412: "Logical unit not ready, offline",
+	0x0483: "Logical unit not ready, door open",
+
+	0x0800: "Logical unit communication failure",
+	0x0801: "Logical unit communication time-out",
+	0x0802: "Logical unit communication parity error",
+
+	0x0A00: "Error log overflow",
+
+	0x0C00: "Write error",
+
+	0x1100: "Unrecovered read error",
+	0x1101: "Read retries exhausted",
+	0x1102: "Error too long to correct",
+	0x1108: "Incomplete block read",
+	0x1109: "No gap found",
+	0x110C: "Unrecovered read error, auto reallocate failed",
+	0x1112: "Auxiliary memory read error",
+
+	0x1400: "Recorded entity not found",
+	0x1401: "Record not found",
+	0x1402: "Filemark or setmark not found",
+	0x1403: "End-of-data not found",
+	0x1404: "Block sequence error",
+
+	0x1500: "Random positioning error",
+	0x1501: "Mechanical positioning error",
+	0x1502: "Positioning error detected by read of medium",
+
+	0x1700: "Recovered data with no error correction applied",
+	0x1701: "Recovered data with retries",
+
+	0x1800: "Recovered data with error correction applied",
+
+	0x1A00: "Parameter list length error",
+
+	0x2000: "Invalid command operation code",
+
+	0x2100: "Logical block address out of range",
+	0x2101: "Invalid element address",
+
+	0x2400: "Invalid field in CDB",
+	0x2500: "Logical unit not supported",
+	0x2600: "Invalid field in parameter list",
+	0x2601: "Parameter not supported",
+	0x2602: "Parameter value invalid",
+	0x2611: "Incomplete key-associated data set",
+	0x2612: "Vendor specific key reference not found",
+
+	0x2700: "Write protected",
+	0x2701: "Hardware write protected",
+	0x2702: "Logical unit software write protected",
+	0x2703: "Associated write protect",
+	0x2704: "Persistent write protect",
+	0x2705: "Permanent write protect",
+
+	0x2800: "Not ready to ready change, medium may have changed",
+	0x2801: "Import or export element accessed",
+	0x2802: "Format-layer may have changed",
+	0x2803: "Import/export element accessed, medium changed",
+
+	0x2900: "Power on, reset, or bus device reset occurred",
+	0x2901: "Power on occurred",
+	0x2902: "SCSI bus reset occurred",
+	0x2903: "Bus device reset function occurred",
+	0x2904: "Device internal reset",
+	0x2905: "Transceiver mode changed to single-ended",
+	0x2906: "Transceiver mode changed to LVD",
+	0x2907: "I_T nexus loss occurred",
+
+	0x2A00: "Parameters changed",
+	0x2A01: "Mode parameters changed",
+	0x2A02: "Log parameters changed",
+	0x2A03: "Reservations preempted",
+	0x2A04: "Reservations released",
+	0x2A05: "Registrations preempted",
+	0x2A11: "Data encryption parameters changed by another I_T nexus",
+	0x2A12: "Data encryption parameters changed by vendor specific event",
+	0x2A14: "SA creation capabilities data has changed",
+
+	0x2C00: "Command sequence error",
+
+	0x2F00: "Commands cleared by another initiator",
+
+	0x3000: "Incompatible medium installed",
+	0x3001: "Cannot read medium, unknown format",
+	0x3002: "Cannot read medium, incompatible format",
+	0x3003: "Cleaning cartridge installed",
+	0x3004: "Cannot write medium, unknown format",
+	0x3005: "Cannot write medium, incompatible format",
+	0x3006: "Cannot format medium, incompatible medium",
+	0x3007: "Cleaning failure",
+	0x300A: "Cleaning request rejected",
+	0x300C: "WORM medium, overwrite attempted",
+	0x300D: "WORM medium, integrity check",
+
+	0x3100: "Medium format corrupted",
+	0x3101: "Format command failed",
+
+	0x3300: "Tape length error",
+
+	0x3700: "Rounded parameter",
+
+	0x3800: "Event status notification",
+	0x3807: "Thin provisioning soft threshold reached",
+
+	0x3900: "Saving parameters not supported",
+
+	0x3A00: "Medium not present",
+	0x3A01: "Medium not present, tray closed",
+	0x3A02: "Medium not present, tray open",
+	0x3A03: "Medium not present, loadable",
+	0x3A04: "Medium not present, medium auxiliary memory accessible",
+
+	0x3B00: "Sequential positioning error",
+	0x3B01: "Tape position error at beginning-of-medium",
+	0x3B02: "Tape position error at end-of-medium",
+	0x3B08: "Reposition error",
+	0x3B0C: "Position past beginning of medium",
+	0x3B0D: "Medium destination element full",
+	0x3B0E: "Medium source element empty",
+	0x3B11: "Medium magazine not accessible",
+	0x3B12: "Medium magazine removed",
+	0x3B13: "Medium magazine inserted",
+	0x3B14: "Medium magazine locked",
+	0x3B15: "Medium magazine unlocked",
+
+	0x3D00: "Invalid bits in identify message",
+
+	0x3E00: "Logical unit has not self-configured yet",
+	0x3E01: "Logical unit failure",
+	0x3E02: "Timeout on logical unit",
+
+	0x3F00: "Target operating conditions have changed",
+	0x3F01: "Microcode has been changed",
+	0x3F02: "Changed operating definition",
+	0x3F03: "Inquiry data has changed",
+	0x3F0E: "Reported LUNs data has changed",
+
+	0x4000: "RAM failure",
+
+	0x4400: "Internal target failure",
+
+	0x4500: "Select or reselect failure",
+
+	0x4700: "SCSI parity error",
+
+	0x4800: "Initiator detected error message received",
+
+	0x4900: "Invalid message error",
+
+	0x4A00: "Command phase error",
+
+	0x4B00: "Data phase error",
+
+	0x4C00: "Logical unit failed self-configuration",
+
+	0x4E00: "Overlapped commands attempted",
+
+	0x5000: "Write append error",
+	0x5001: "Write append position error",
+	0x5002: "Position error related to timing",
+
+	0x5100: "Erase failure",
+
+	0x5200: "Cartridge fault",
+
+	0x5300: "Media load or eject failed",
+	0x5301: "Unload tape failure",
+	0x5302: "Medium removal prevented",
+	0x5303: "Medium removal prevented by data transfer element",
+	0x5304: "Medium thread or unthread failure",
+
+	0x5500: "System resource failure",
+	0x5506: "Auxiliary memory out of space",
+
+	0x5A00: "Operator request or state change input",
+	0x5A01: "Operator medium removal request",
+	0x5A02: "Operator selected write protect",
+	0x5A03: "Operator selected write permit",
+
+	0x5B00: "Log exception",
+	0x5B01: "Threshold condition met",
+	0x5B02: "Log counter at maximum",
+	0x5B03: "Log list codes exhausted",
+
+	0x5D00: "Failure prediction threshold exceeded",
+	0x5DFF: "Failure prediction threshold exceeded (false)",
+
+	0x6F00: "Copy protection key exchange failure, authentication failure",
+
+	0x7400: "Security error",
+	0x7401: "Unable to decrypt data",
+	0x7402: "Unencrypted data encountered while decrypting",
+	0x7403: "Incorrect data encryption key",
+	0x7404: "Cryptographic integrity validation failed",
+	0x7405: "Error decrypting data",
+	0x7406: "Unknown signature verification key",
+	0x7407: "Encryption parameters not useable",
+	0x7408: "Digital signature validation failure",
+	0x7409: "Encryption mode mismatch on read",
+	0x740A: "Encrypted block not raw read enabled",
+	0x740B: "Incorrect encryption parameters",
+	0x740C: "Unable to decrypt parameter list",
+	0x740D: "Encryption algorithm disabled",
+	0x7410: "SA creation parameter value invalid",
+	0x7411: "SA creation parameter value rejected",
+	0x7412: "Invalid SA usage",
+	0x7421: "Data encryption configuration prevented",
+	0x7430: "SA creation parameter not supported",
+	0x7440: "Authentication failed",
+	0x7461: "External data encryption key manager access error",
+	0x7462: "External data encryption key manager error",
+	0x7463: "External data encryption key not found",
+	0x7464: "External data encryption request not authorized",
+	0x746E: "External data encryption control timeout",
+	0x746F: "External data encryption control error",
+	0x7471: "Logical unit access not authorized",
+	0x7479: "Security conflict in translated device",
+}
+
+func DescribeASC(asc uint8, ascq uint8) string {
+	desc, ok := ascDescriptions[uint16(asc)<<8|uint16(ascq)]
+	if ok {
+		return desc
+	}
+
+	switch {
+	case asc >= 0x80:
+		return fmt.Sprintf("Vendor specific ASC %#02x, ASCQ %#02x", asc, ascq)
+	case ascq >= 0x80:
+		if desc, ok = ascDescriptions[uint16(asc)<<8]; ok {
+			return fmt.Sprintf("%s (vendor specific ASCQ %#02x)", desc, ascq)
+		}
+	}
+
+	return fmt.Sprintf("Unknown ASC %#02x, ASCQ %#02x", asc, ascq)
+}
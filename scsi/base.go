@@ -6,41 +6,78 @@ import (
 	"github.com/FoxDenHome/goscsi"
 )
 
+// Transport sends raw CDBs to a device, it is implemented by the SG_IO
+// transport and by the fake in scsi/scsitest
+type Transport interface {
+	Close() error
+	Request(cdb, fromdev, todev []byte) error
+	RequestWithTimeout(cdb, fromdev, todev []byte, timeout time.Duration) error
+}
+
+// ResidualReporter is optionally implemented by a Transport to report how many
+// bytes of the last data-in buffer were not filled by the device
+type ResidualReporter interface {
+	Residual() int
+}
+
+type Opener func(path string) (*SCSIDevice, error)
+
 type SCSIDevice struct {
-	dev goscsi.Dev
+	dev       goscsi.Dev
+	transport Transport
 }
 
 func Open(path string) (*SCSIDevice, error) {
-	dev, err := goscsi.Open(path)
+	dev, err := openSG(path)
 	if err != nil {
 		return nil, err
 	}
-	return &SCSIDevice{dev: dev}, nil
+	return NewDevice(dev), nil
+}
+
+func NewDevice(transport Transport) *SCSIDevice {
+	return &SCSIDevice{
+		dev:       goscsi.Dev{EmbeddedDev: transport},
+		transport: transport,
+	}
 }
 
 func (d *SCSIDevice) Close() error {
 	return d.dev.Close()
 }
 
+// send issues a command that transfers data to the device
+func (d *SCSIDevice) send(req []byte, data []byte) error {
+	return d.sendWithTimeout(req, data, DEFAULT_TIMEOUT)
+}
+
+func (d *SCSIDevice) sendWithTimeout(req []byte, data []byte, timeout time.Duration) error {
+	return d.dev.RequestWithTimeout(req, nil, data, timeout)
+}
+
 func (d *SCSIDevice) request(req []byte, respLen int) ([]byte, error) {
-	resp := make([]byte, respLen)
-	err := d.dev.Request(req, resp, nil)
-	if err != nil {
-		return nil, err
-	}
-	return resp, nil
+	return d.requestWithTimeout(req, respLen, DEFAULT_TIMEOUT)
 }
 
 func (d *SCSIDevice) requestWithTimeout(req []byte, respLen int, timeout time.Duration) ([]byte, error) {
-	resp := make([]byte, respLen)
+	var resp []byte
+	if respLen > 0 {
+		resp = make([]byte, respLen)
+	}
 	err := d.dev.RequestWithTimeout(req, resp, nil, timeout)
 	if err != nil {
 		return nil, err
 	}
+
+	if reporter, ok := d.transport.(ResidualReporter); ok {
+		residual := reporter.Residual()
+		if residual > 0 && residual <= len(resp) {
+			resp = resp[:len(resp)-residual]
+		}
+	}
 	return resp, nil
 }
 
 func (d *SCSIDevice) SerialNumber() (string, error) {
-	str, err := d.dev.SerialNumber()
-	return str.String(), err
+	return d.UnitSerialNumber()
 }
@@ -0,0 +1,40 @@
+package scsi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FoxDenHome/tapemgr/util"
+)
+
+// WaitForCleaning polls TEST UNIT READY while a cleaning cartridge is in the drive,
+// until the drive has unloaded it again or reports the cleaning failed
+func (d *SCSIDevice) WaitForCleaning(ctx context.Context, timeout time.Duration) error {
+	var lastErr error
+	err := util.Poll(ctx, timeout, func() (bool, error) {
+		_, lastErr = d.testUnitReady()
+		switch {
+		case lastErr == nil:
+			// A ready drive has a data tape loaded, not a cleaning cartridge
+			return false, errors.New("drive became ready with a cartridge that is not a cleaning cartridge")
+		case errors.Is(lastErr, ErrMediumNotPresent):
+			return true, nil
+		case errors.Is(lastErr, ErrCleaningFailure):
+			return false, lastErr
+		case errors.Is(lastErr, ErrCleaningCartridge), errors.Is(lastErr, ErrNotReady):
+			return false, nil
+		default:
+			return false, ignoreTransient(lastErr)
+		}
+	})
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) && lastErr != nil {
+		return fmt.Errorf("cleaning not finished after %v, last status: %w", timeout, lastErr)
+	}
+	return fmt.Errorf("waiting for cleaning to finish: %w", err)
+}
@@ -0,0 +1,251 @@
+// Package discover finds media changers and tape drives through sysfs and
+// works out which drive sits in which changer.
+package discover
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/loader"
+)
+
+const (
+	SYSFS_ROOT = "/sys"
+	DEV_ROOT   = "/dev"
+
+	CHANGER_CLASS = "class/scsi_changer"
+	TAPE_CLASS    = "class/scsi_tape"
+)
+
+// Only the non-rewinding device of each drive, not the st*/nst*[alm] mode variants
+var tapeDeviceName = regexp.MustCompile(`^nst[0-9]+$`)
+
+type Device struct {
+	Path        string            `json:"path"`
+	GenericPath string            `json:"generic-path,omitempty"`
+	Inquiry     *scsi.InquiryData `json:"inquiry,omitempty"`
+	Serial      string            `json:"serial,omitempty"`
+	Error       string            `json:"error,omitempty"`
+
+	ident *scsi.DeviceIdentification
+}
+
+type Drive struct {
+	Device
+	Changer        string  `json:"changer,omitempty"`
+	ElementAddress *uint16 `json:"element-address,omitempty"`
+}
+
+type Result struct {
+	Changers []*Device `json:"changers"`
+	Drives   []*Drive  `json:"drives"`
+}
+
+type Discoverer struct {
+	SysfsRoot string
+	DevRoot   string
+
+	open scsi.Opener
+}
+
+func New() *Discoverer {
+	return NewWithOpener(SYSFS_ROOT, DEV_ROOT, scsi.Open)
+}
+
+// NewWithOpener allows substituting sysfs and the SCSI transport, for example with scsitest
+func NewWithOpener(sysfsRoot string, devRoot string, opener scsi.Opener) *Discoverer {
+	return &Discoverer{
+		SysfsRoot: sysfsRoot,
+		DevRoot:   devRoot,
+		open:      opener,
+	}
+}
+
+// IsPath tells device paths apart from serial numbers in the configuration
+func IsPath(device string) bool {
+	return strings.HasPrefix(device, "/")
+}
+
+func (d *Discoverer) Changers() ([]*Device, error) {
+	names, err := d.classDevices(CHANGER_CLASS, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	changers := make([]*Device, 0, len(names))
+	for _, name := range names {
+		changers = append(changers, d.probe(CHANGER_CLASS, name))
+	}
+	return changers, nil
+}
+
+func (d *Discoverer) Drives() ([]*Drive, error) {
+	names, err := d.classDevices(TAPE_CLASS, tapeDeviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	drives := make([]*Drive, 0, len(names))
+	for _, name := range names {
+		drives = append(drives, &Drive{Device: *d.probe(TAPE_CLASS, name)})
+	}
+	return drives, nil
+}
+
+// Discover lists all changers and drives and matches the drives to the changers' data transfer elements
+func (d *Discoverer) Discover() (*Result, error) {
+	changers, err := d.Changers()
+	if err != nil {
+		return nil, err
+	}
+	drives, err := d.Drives()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, changer := range changers {
+		if changer.Error != "" {
+			continue
+		}
+
+		tapeLoader, err := loader.NewTapeLoaderWithOpener(changer.Path, d.open)
+		if err != nil {
+			return nil, err
+		}
+		elements, err := tapeLoader.DriveElements()
+		if err != nil {
+			changer.Error = fmt.Sprintf("reading drive elements: %v", err)
+			continue
+		}
+
+		for _, drive := range drives {
+			if drive.ident == nil || drive.Changer != "" {
+				continue
+			}
+			for _, elem := range elements {
+				if drive.ident.Matches(elem.Designator()) {
+					drive.Changer = changer.Path
+					drive.ElementAddress = &elem.Address
+					break
+				}
+			}
+		}
+	}
+
+	return &Result{
+		Changers: changers,
+		Drives:   drives,
+	}, nil
+}
+
+// ChangerPath returns the device path of a changer given by path or serial number
+func (d *Discoverer) ChangerPath(device string) (string, error) {
+	if IsPath(device) {
+		return device, nil
+	}
+
+	changers, err := d.Changers()
+	if err != nil {
+		return "", err
+	}
+	return findSerial("changer", device, changers)
+}
+
+// DrivePath returns the device path of a tape drive given by path or serial number
+func (d *Discoverer) DrivePath(device string) (string, error) {
+	if IsPath(device) {
+		return device, nil
+	}
+
+	drives, err := d.Drives()
+	if err != nil {
+		return "", err
+	}
+	devices := make([]*Device, 0, len(drives))
+	for _, drive := range drives {
+		devices = append(devices, &drive.Device)
+	}
+	return findSerial("tape drive", device, devices)
+}
+
+func findSerial(kind string, serial string, devices []*Device) (string, error) {
+	var found []string
+	for _, device := range devices {
+		if device.Serial != "" && strings.EqualFold(device.Serial, serial) {
+			found = append(found, device.Path)
+		}
+	}
+
+	switch len(found) {
+	case 1:
+		return found[0], nil
+	case 0:
+		return "", fmt.Errorf("no %s with serial %s found, %d checked", kind, serial, len(devices))
+	default:
+		return "", fmt.Errorf("%d %ss with serial %s found: %s", len(found), kind, serial, strings.Join(found, ", "))
+	}
+}
+
+// classDevices lists the device names of a sysfs class, a missing class means its driver is not loaded
+func (d *Discoverer) classDevices(class string, filter *regexp.Regexp) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(d.SysfsRoot, class))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if filter == nil || filter.MatchString(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// probe reads INQUIRY and the identifiers through the SCSI generic device, if there is one
+func (d *Discoverer) probe(class string, name string) *Device {
+	device := &Device{
+		Path: filepath.Join(d.DevRoot, name),
+	}
+
+	linkDest, err := os.Readlink(filepath.Join(d.SysfsRoot, class, name, "device", "generic"))
+	if err == nil {
+		device.GenericPath = filepath.Join(d.DevRoot, filepath.Base(linkDest))
+	}
+
+	probePath := device.GenericPath
+	if probePath == "" {
+		probePath = device.Path
+	}
+
+	dev, err := d.open(probePath)
+	if err != nil {
+		device.Error = err.Error()
+		return device
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	device.Inquiry, err = dev.Inquiry()
+	if err != nil {
+		device.Error = fmt.Sprintf("inquiry: %v", err)
+		return device
+	}
+
+	device.ident, err = dev.Identification()
+	if err != nil {
+		device.Error = fmt.Sprintf("identification: %v", err)
+		return device
+	}
+	device.Serial = device.ident.Serial
+	return device
+}
@@ -0,0 +1,251 @@
+package discover_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/discover"
+	"github.com/FoxDenHome/tapemgr/scsi/scsitest"
+)
+
+const (
+	OPCODE_MODE_SENSE          = 0x1A
+	OPCODE_READ_ELEMENT_STATUS = 0xB8
+)
+
+// NAA designator of the first drive of scsitest.MIXED_IDENTIFIER_DATA_TRANSFER
+var naaDesignator = []byte{0x01, 0x03, 0x00, 0x08, 0x50, 0x05, 0x07, 0x63, 0x12, 0x4b, 0x0a, 0x11}
+
+func vpdPage(page scsi.VPDPage, data []byte) []byte {
+	return append([]byte{0x00, uint8(page), uint8(len(data) >> 8), uint8(len(data))}, data...)
+}
+
+// newDevice answers INQUIRY like a device of the given type, with page 0x83 only if designators are given
+func newDevice(deviceType uint8, product string, serial string, designators ...[]byte) *scsitest.Device {
+	dev := scsitest.New()
+	dev.Handle(scsi.INQUIRY, func(cdb []byte, todev []byte) ([]byte, error) {
+		if cdb[1]&0x01 == 0 {
+			return []byte(fmt.Sprintf("%c\x80\x06\x02\x1f\x00\x00\x00%-8s%-16s%-4s", deviceType, "IBM", product, "0001")), nil
+		}
+		switch scsi.VPDPage(cdb[2]) {
+		case scsi.VPD_PAGE_UNIT_SERIAL_NUMBER:
+			return vpdPage(scsi.VPD_PAGE_UNIT_SERIAL_NUMBER, []byte(serial)), nil
+		case scsi.VPD_PAGE_DEVICE_IDENTIFICATION:
+			if len(designators) > 0 {
+				var page []byte
+				for _, designator := range designators {
+					page = append(page, designator...)
+				}
+				return vpdPage(scsi.VPD_PAGE_DEVICE_IDENTIFICATION, page), nil
+			}
+		}
+		return nil, scsitest.CheckCondition(scsi.INQUIRY, scsi.SENSE_KEY_ILLEGAL_REQUEST, 0x24, 0x00)
+	})
+	return dev
+}
+
+// newChanger reports the given drive elements, without an element address assignment page
+func newChanger(serial string, driveElements []byte) *scsitest.Device {
+	dev := newDevice(scsi.DEVICE_TYPE_MEDIUM_CHANGER, "3573-TL", serial)
+	dev.Handle(OPCODE_MODE_SENSE, func(cdb []byte, todev []byte) ([]byte, error) {
+		return nil, scsitest.CheckCondition(OPCODE_MODE_SENSE, scsi.SENSE_KEY_ILLEGAL_REQUEST, 0x24, 0x00)
+	})
+	dev.Handle(OPCODE_READ_ELEMENT_STATUS, func(cdb []byte, todev []byte) ([]byte, error) {
+		return driveElements, nil
+	})
+	return dev
+}
+
+type system struct {
+	sysfs   string
+	devices map[string]*scsitest.Device
+}
+
+func newSystem(t *testing.T) *system {
+	return &system{
+		sysfs:   t.TempDir(),
+		devices: make(map[string]*scsitest.Device),
+	}
+}
+
+// add creates the sysfs entry of a device and its SCSI generic device, dev answers on both device nodes
+func (s *system) add(t *testing.T, class string, name string, generic string, dev *scsitest.Device) {
+	t.Helper()
+	dir := filepath.Join(s.sysfs, class, name, "device")
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if generic != "" {
+		err = os.Symlink("../../../devices/pci0000:00/0000:00:01.0/host0/target0:0:0/0:0:0:0/scsi_generic/"+generic, filepath.Join(dir, "generic"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.devices["/dev/"+generic] = dev
+	}
+	s.devices["/dev/"+name] = dev
+}
+
+func (s *system) discoverer() *discover.Discoverer {
+	return discover.NewWithOpener(s.sysfs, "/dev", func(path string) (*scsi.SCSIDevice, error) {
+		dev := s.devices[path]
+		if dev == nil {
+			return nil, fmt.Errorf("open %s: %w", path, os.ErrNotExist)
+		}
+		return scsi.NewDevice(dev), nil
+	})
+}
+
+// newLibrary sets up two changers, four drives in them and a standalone drive
+func newLibrary(t *testing.T) *system {
+	s := newSystem(t)
+	// Drives 0x100 and 0x101 with the T10 vendor identifications of serials 1013000100 and 1013000101
+	s.add(t, discover.CHANGER_CLASS, "sch0", "sg2", newChanger("CHG0000001", scsitest.IBM_TS4300_DATA_TRANSFER))
+	// Drive 0x100 by NAA, 0x101 by the vendor specific serial HU19087F4K
+	s.add(t, discover.CHANGER_CLASS, "sch1", "sg5", newChanger("CHG0000002", scsitest.MIXED_IDENTIFIER_DATA_TRANSFER))
+
+	s.add(t, discover.TAPE_CLASS, "nst0", "sg0", newDevice(scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS, "ULT3580-TD8", "1013000101"))
+	s.add(t, discover.TAPE_CLASS, "nst1", "sg1", newDevice(scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS, "ULT3580-TD8", "1013000100"))
+	s.add(t, discover.TAPE_CLASS, "nst2", "sg3", newDevice(scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS, "Ultrium 8-SCSI", "HU19087F4K"))
+	s.add(t, discover.TAPE_CLASS, "nst3", "sg4", newDevice(scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS, "ULT3580-TD9", "10WT000003", naaDesignator))
+	s.add(t, discover.TAPE_CLASS, "nst4", "sg6", newDevice(scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS, "ULT3580-TD8", "STANDALONE"))
+	// The rewinding and mode devices of a drive are not drives of their own
+	for _, name := range []string{"st0", "nst0a", "nst0l", "st0m"} {
+		s.add(t, discover.TAPE_CLASS, name, "", nil)
+	}
+	return s
+}
+
+func TestDiscover(t *testing.T) {
+	result, err := newLibrary(t).discoverer().Discover()
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+
+	if len(result.Changers) != 2 {
+		t.Fatalf("found %d changers, want 2", len(result.Changers))
+	}
+	for i, changer := range result.Changers {
+		if changer.Error != "" {
+			t.Errorf("changer %s: %s", changer.Path, changer.Error)
+		}
+		wantSerial := fmt.Sprintf("CHG000000%d", i+1)
+		wantGeneric := []string{"/dev/sg2", "/dev/sg5"}[i]
+		if changer.Serial != wantSerial || changer.GenericPath != wantGeneric || changer.Inquiry == nil || changer.Inquiry.DeviceType != scsi.DEVICE_TYPE_MEDIUM_CHANGER {
+			t.Errorf("changer %d: got %+v, want serial %s at %s", i, changer, wantSerial, wantGeneric)
+		}
+	}
+
+	want := map[string]struct {
+		generic string
+		serial  string
+		changer string
+		address uint16
+	}{
+		"/dev/nst0": {"/dev/sg0", "1013000101", "/dev/sch0", 0x101},
+		"/dev/nst1": {"/dev/sg1", "1013000100", "/dev/sch0", 0x100},
+		"/dev/nst2": {"/dev/sg3", "HU19087F4K", "/dev/sch1", 0x101},
+		"/dev/nst3": {"/dev/sg4", "10WT000003", "/dev/sch1", 0x100},
+		"/dev/nst4": {"/dev/sg6", "STANDALONE", "", 0},
+	}
+	if len(result.Drives) != len(want) {
+		t.Fatalf("found %d drives, want %d", len(result.Drives), len(want))
+	}
+	for _, drive := range result.Drives {
+		w, ok := want[drive.Path]
+		if !ok {
+			t.Errorf("unexpected drive %s", drive.Path)
+			continue
+		}
+		if drive.Error != "" {
+			t.Errorf("drive %s: %s", drive.Path, drive.Error)
+		}
+		if drive.GenericPath != w.generic || drive.Serial != w.serial {
+			t.Errorf("drive %s: got generic path %s and serial %s, want %s and %s", drive.Path, drive.GenericPath, drive.Serial, w.generic, w.serial)
+		}
+		if drive.Changer != w.changer {
+			t.Errorf("drive %s: got changer %q, want %q", drive.Path, drive.Changer, w.changer)
+		}
+		switch {
+		case w.changer == "" && drive.ElementAddress != nil:
+			t.Errorf("standalone drive %s got element address %d", drive.Path, *drive.ElementAddress)
+		case w.changer != "" && (drive.ElementAddress == nil || *drive.ElementAddress != w.address):
+			t.Errorf("drive %s: got element address %v, want %d", drive.Path, drive.ElementAddress, w.address)
+		}
+	}
+}
+
+func TestDiscoverWithoutDrivers(t *testing.T) {
+	result, err := newSystem(t).discoverer().Discover()
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if len(result.Changers) != 0 || len(result.Drives) != 0 {
+		t.Errorf("found %d changers and %d drives without sysfs classes", len(result.Changers), len(result.Drives))
+	}
+}
+
+func TestDiscoverUnreachableChanger(t *testing.T) {
+	s := newSystem(t)
+	s.add(t, discover.CHANGER_CLASS, "sch0", "sg1", newChanger("CHG0000001", scsitest.IBM_TS4300_DATA_TRANSFER))
+	// No generic device and no device node to open
+	err := os.MkdirAll(filepath.Join(s.sysfs, discover.CHANGER_CLASS, "sch1", "device"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.add(t, discover.TAPE_CLASS, "nst0", "sg0", newDevice(scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS, "ULT3580-TD8", "1013000100"))
+
+	result, err := s.discoverer().Discover()
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if len(result.Changers) != 2 || result.Changers[1].Error == "" || result.Changers[1].GenericPath != "" {
+		t.Fatalf("got changers %+v, want the second one with an error", result.Changers)
+	}
+	if drive := result.Drives[0]; drive.Changer != "/dev/sch0" {
+		t.Errorf("drive %s: got changer %q, want /dev/sch0", drive.Path, drive.Changer)
+	}
+}
+
+func TestDevicePath(t *testing.T) {
+	s := newLibrary(t)
+	// A second drive reporting the same serial, as some virtual tape libraries do
+	s.add(t, discover.TAPE_CLASS, "nst5", "sg7", newDevice(scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS, "ULT3580-TD8", "STANDALONE"))
+	d := s.discoverer()
+
+	tests := []struct {
+		name    string
+		lookup  func(string) (string, error)
+		device  string
+		want    string
+		wantErr string
+	}{
+		{"drive by serial", d.DrivePath, "1013000100", "/dev/nst1", ""},
+		{"drive by serial ignoring case", d.DrivePath, "hu19087f4k", "/dev/nst2", ""},
+		{"drive by path", d.DrivePath, "/dev/nst9", "/dev/nst9", ""},
+		{"unknown drive serial", d.DrivePath, "UNKNOWN", "", "no tape drive with serial UNKNOWN found, 6 checked"},
+		{"ambiguous drive serial", d.DrivePath, "STANDALONE", "", "2 tape drives with serial STANDALONE found: /dev/nst4, /dev/nst5"},
+		{"changer by serial", d.ChangerPath, "CHG0000002", "/dev/sch1", ""},
+		{"changer by path", d.ChangerPath, "/dev/sch7", "/dev/sch7", ""},
+		{"drive serial is no changer", d.ChangerPath, "1013000100", "", "no changer with serial 1013000100 found, 2 checked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := tt.lookup(tt.device)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %q, %v, want error %q", path, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || path != tt.want {
+				t.Errorf("got %q, %v, want %q", path, err, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,14 @@
 package drive
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/FoxDenHome/tapemgr/scsi"
 )
@@ -15,13 +16,27 @@ import (
 var ErrAlreadyMounted = errors.New("tape drive is already mounted")
 var ErrLTFSExited = errors.New("ltfs command exited unexpectedly")
 
+const (
+	DEFAULT_READY_TIMEOUT = 5 * time.Minute
+	DEFAULT_MOUNT_TIMEOUT = 10 * time.Minute
+	// A cleaning cycle takes a few minutes on LTO drives
+	DEFAULT_CLEANING_TIMEOUT = 10 * time.Minute
+)
+
 type TapeDrive struct {
 	DevicePath  string
 	GenericPath string
 
+	ReadyTimeout    time.Duration
+	MountTimeout    time.Duration
+	CleaningTimeout time.Duration
+
+	open scsi.Opener
+
 	mountPoint string
-	mountWait  *sync.WaitGroup
 	mountProc  *exec.Cmd
+	// mountExited is closed once the ltfs process was reaped
+	mountExited chan struct{}
 }
 
 func NewTapeDrive(devicePath string, mountPoint string) (*TapeDrive, error) {
@@ -39,14 +54,18 @@ func NewTapeDrive(devicePath string, mountPoint string) (*TapeDrive, error) {
 	}
 
 	return &TapeDrive{
-		DevicePath:  devicePath,
-		GenericPath: fmt.Sprintf("/dev/%s", filepath.Base(linkDest)),
-		mountPoint:  mountPoint,
+		DevicePath:      devicePath,
+		GenericPath:     fmt.Sprintf("/dev/%s", filepath.Base(linkDest)),
+		ReadyTimeout:    DEFAULT_READY_TIMEOUT,
+		MountTimeout:    DEFAULT_MOUNT_TIMEOUT,
+		CleaningTimeout: DEFAULT_CLEANING_TIMEOUT,
+		open:            scsi.Open,
+		mountPoint:      mountPoint,
 	}, nil
 }
 
 func (d *TapeDrive) SerialNumber() (string, error) {
-	dev, err := scsi.Open(d.GenericPath)
+	dev, err := d.open(d.GenericPath)
 	if err != nil {
 		return "", err
 	}
@@ -57,6 +76,93 @@ func (d *TapeDrive) SerialNumber() (string, error) {
 	return dev.SerialNumber()
 }
 
+func (d *TapeDrive) Identification() (*scsi.DeviceIdentification, error) {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	return dev.Identification()
+}
+
+func (d *TapeDrive) DeviceInfo() (*scsi.DeviceInfo, error) {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	return dev.DeviceInfo()
+}
+
+func (d *TapeDrive) WaitForReady(ctx context.Context) error {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	return dev.WaitForReady(ctx, d.ReadyTimeout)
+}
+
+func (d *TapeDrive) WaitForCleaning(ctx context.Context) error {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	return dev.WaitForCleaning(ctx, d.CleaningTimeout)
+}
+
+// PreventMediumRemoval disables (or enables) the eject button of the drive
+func (d *TapeDrive) PreventMediumRemoval(prevent bool) error {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	return dev.PreventMediumRemoval(prevent)
+}
+
 func (d *TapeDrive) MountPoint() string {
 	return d.mountPoint
 }
+
+// Eject rewinds and ejects the tape, for standalone drives without a loader
+func (d *TapeDrive) Eject() error {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	return dev.Unload()
+}
+
+// MediumPresent reports whether a tape is in the drive, without waiting for it to become ready
+func (d *TapeDrive) MediumPresent() (bool, error) {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	return dev.MediumPresent()
+}
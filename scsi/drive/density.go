@@ -0,0 +1,39 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+)
+
+// SupportedDensities lists the densities the drive can read, marking those it can also write
+func (d *TapeDrive) SupportedDensities() ([]scsi.Density, error) {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	return dev.ReportDensitySupport(false)
+}
+
+// MediumDensities lists the densities of the loaded cartridge
+func (d *TapeDrive) MediumDensities(ctx context.Context) ([]scsi.Density, error) {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	err = dev.WaitForReady(ctx, d.ReadyTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for drive %s: %w", d.DevicePath, err)
+	}
+
+	return dev.ReportDensitySupport(true)
+}
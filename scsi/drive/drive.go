@@ -0,0 +1,36 @@
+package drive
+
+import (
+	"context"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+)
+
+// Drive is implemented by TapeDrive and by the simulated drives in scsi/sim
+type Drive interface {
+	SerialNumber() (string, error)
+	Identification() (*scsi.DeviceIdentification, error)
+	DeviceInfo() (*scsi.DeviceInfo, error)
+	MountPoint() string
+	Mount(ctx context.Context) error
+	WaitForCleaning(ctx context.Context) error
+	PreventMediumRemoval(prevent bool) error
+	Eject() error
+	MediumPresent() (bool, error)
+	Unmount() error
+	WaitForUnmount()
+	Format(barcode string) error
+	Stats() (size int64, free int64, err error)
+	FileLocation(path string) (partition string, startBlock int, err error)
+	TapeAlerts() ([]scsi.TapeAlertFlag, error)
+	Health() (*Health, error)
+	MediumAuxiliaryMemory(ctx context.Context) (*scsi.MediumAuxiliaryMemory, error)
+	WriteProtected(ctx context.Context) (bool, error)
+	SupportedDensities() ([]scsi.Density, error)
+	MediumDensities(ctx context.Context) ([]scsi.Density, error)
+	EncryptionAlgorithms() ([]scsi.EncryptionAlgorithm, error)
+	EncryptionStatus() (*scsi.EncryptionStatus, error)
+	SetEncryption(ctx context.Context, settings *scsi.EncryptionSettings) error
+	HostLabel(ctx context.Context) (string, error)
+	SetHostLabel(ctx context.Context, label string) error
+}
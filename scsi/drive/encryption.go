@@ -0,0 +1,51 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+)
+
+// EncryptionAlgorithms lists the data encryption algorithms of the drive
+func (d *TapeDrive) EncryptionAlgorithms() ([]scsi.EncryptionAlgorithm, error) {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	return dev.EncryptionAlgorithms()
+}
+
+func (d *TapeDrive) EncryptionStatus() (*scsi.EncryptionStatus, error) {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	return dev.EncryptionStatus()
+}
+
+// SetEncryption waits for the loaded tape first, as the key is cleared when it is unloaded
+func (d *TapeDrive) SetEncryption(ctx context.Context, settings *scsi.EncryptionSettings) error {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	err = dev.WaitForReady(ctx, d.ReadyTimeout)
+	if err != nil {
+		return fmt.Errorf("waiting for drive %s: %w", d.DevicePath, err)
+	}
+
+	return dev.SetEncryption(settings)
+}
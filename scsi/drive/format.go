@@ -1,8 +1,11 @@
 package drive
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
+
+	"github.com/FoxDenHome/tapemgr/scsi/lto"
 )
 
 func (d *TapeDrive) Format(barcode string) error {
@@ -10,7 +13,10 @@ func (d *TapeDrive) Format(barcode string) error {
 		return ErrAlreadyMounted
 	}
 
-	serial := barcode[:6]
+	serial, _, err := lto.ParseVolumeTag(barcode)
+	if err != nil {
+		return fmt.Errorf("cannot derive the LTFS volume serial: %w", err)
+	}
 
 	cmd := exec.Command("mkltfs", "--device", d.DevicePath, "-n", barcode, "-s", serial, "-f")
 	cmd.Stdout = os.Stdout
@@ -0,0 +1,66 @@
+package drive
+
+import (
+	"errors"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+)
+
+// Health holds the drive's log pages, pages the drive does not support
+// (or that need a loaded tape when there is none) are left nil
+type Health struct {
+	TapeAlerts       []scsi.TapeAlertFlag
+	WriteErrors      *scsi.ErrorCounters
+	ReadErrors       *scsi.ErrorCounters
+	VolumeStatistics *scsi.VolumeStatistics
+}
+
+func (d *TapeDrive) TapeAlerts() ([]scsi.TapeAlertFlag, error) {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	return dev.TapeAlerts()
+}
+
+func (d *TapeDrive) Health() (*Health, error) {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	health := &Health{}
+	health.TapeAlerts, err = dev.TapeAlerts()
+	if err != nil {
+		return nil, err
+	}
+
+	health.WriteErrors, err = dev.ErrorCounters(scsi.LOG_PAGE_WRITE_ERROR_COUNTERS)
+	if ignoreUnavailable(err) != nil {
+		return nil, err
+	}
+	health.ReadErrors, err = dev.ErrorCounters(scsi.LOG_PAGE_READ_ERROR_COUNTERS)
+	if ignoreUnavailable(err) != nil {
+		return nil, err
+	}
+	health.VolumeStatistics, err = dev.VolumeStatistics()
+	if ignoreUnavailable(err) != nil {
+		return nil, err
+	}
+
+	return health, nil
+}
+
+func ignoreUnavailable(err error) error {
+	if errors.Is(err, scsi.ErrIllegalRequest) || errors.Is(err, scsi.ErrNotReady) {
+		return nil
+	}
+	return err
+}
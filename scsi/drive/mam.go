@@ -0,0 +1,78 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+)
+
+// MediumAuxiliaryMemory reads the MAM of the loaded cartridge, which does not require LTFS to be mounted
+func (d *TapeDrive) MediumAuxiliaryMemory(ctx context.Context) (*scsi.MediumAuxiliaryMemory, error) {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	err = dev.WaitForReady(ctx, d.ReadyTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for drive %s: %w", d.DevicePath, err)
+	}
+
+	return dev.MediumAuxiliaryMemory()
+}
+
+// WriteProtected reports the write-protect tab of the loaded cartridge
+func (d *TapeDrive) WriteProtected(ctx context.Context) (bool, error) {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	err = dev.WaitForReady(ctx, d.ReadyTimeout)
+	if err != nil {
+		return false, fmt.Errorf("waiting for drive %s: %w", d.DevicePath, err)
+	}
+
+	return dev.WriteProtected()
+}
+
+func (d *TapeDrive) HostLabel(ctx context.Context) (string, error) {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	err = dev.WaitForReady(ctx, d.ReadyTimeout)
+	if err != nil {
+		return "", fmt.Errorf("waiting for drive %s: %w", d.DevicePath, err)
+	}
+
+	return dev.HostLabel()
+}
+
+func (d *TapeDrive) SetHostLabel(ctx context.Context, label string) error {
+	dev, err := d.open(d.GenericPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	err = dev.WaitForReady(ctx, d.ReadyTimeout)
+	if err != nil {
+		return fmt.Errorf("waiting for drive %s: %w", d.DevicePath, err)
+	}
+
+	return dev.SetHostLabel(label)
+}
@@ -1,14 +1,18 @@
 package drive
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"strings"
-	"sync"
 	"syscall"
+
+	"github.com/FoxDenHome/tapemgr/util"
 )
 
-func (d *TapeDrive) Mount() error {
+func (d *TapeDrive) Mount(ctx context.Context) error {
 	if d.isMounted() {
 		return nil
 	}
@@ -18,66 +22,98 @@ func (d *TapeDrive) Mount() error {
 		return err
 	}
 
-	d.mountProc = exec.Command("ltfs", "-o", "devname="+d.GenericPath, "-f", "-o", "umask=077", "-o", "eject", "-o", "sync_type=unmount", d.mountPoint)
-	d.mountProc.Stdout = os.Stdout
-	d.mountProc.Stderr = os.Stderr
+	err = d.WaitForReady(ctx)
+	if err != nil {
+		return fmt.Errorf("waiting for drive %s: %w", d.DevicePath, err)
+	}
+
+	proc := exec.Command("ltfs", "-o", "devname="+d.GenericPath, "-f", "-o", "umask=077", "-o", "eject", "-o", "sync_type=unmount", d.mountPoint)
+	proc.Stdout = os.Stdout
+	proc.Stderr = os.Stderr
+	// A terminal's SIGINT would otherwise reach ltfs as well and have it unmount while tapemgr is still cleaning up
+	proc.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
-	err = d.mountProc.Start()
+	err = proc.Start()
 	if err != nil {
 		return err
 	}
 
-	d.mountWait = &sync.WaitGroup{}
-	d.mountWait.Add(1)
+	// The goroutine keeps its own references, Unmount clears the fields
+	exited := make(chan struct{})
+	d.mountProc = proc
+	d.mountExited = exited
 	go func() {
-		_ = d.mountProc.Wait()
-		d.mountWait.Done()
+		_ = proc.Wait()
+		close(exited)
 	}()
 
-	for {
+	err = util.Poll(ctx, d.MountTimeout, func() (bool, error) {
+		if d.isMounted() {
+			return true, nil
+		}
 		if !d.mountProcAlive() {
-			return ErrLTFSExited
+			return false, ErrLTFSExited
 		}
+		return false, nil
+	})
+	if err == nil {
+		return nil
+	}
 
-		if d.isMounted() {
-			return nil
-		}
+	// Kills an ltfs that never mounted, so it does not keep holding the drive
+	unmountErr := d.Unmount()
+	if unmountErr != nil {
+		err = errors.Join(err, fmt.Errorf("stopping ltfs: %w", unmountErr))
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("ltfs did not mount %s within %v: %w", d.mountPoint, d.MountTimeout, err)
 	}
+	return err
 }
 
-func (d *TapeDrive) Unmount() (err error) {
+// Unmount unmounts the LTFS volume, or kills ltfs if it has not mounted it, and waits for ltfs to exit
+// The process is only forgotten once it exited, so a failed unmount can be retried
+func (d *TapeDrive) Unmount() error {
 	proc := d.mountProc
 	if proc == nil {
 		return nil
 	}
-	d.mountProc = nil
 
+	var err error
 	if d.isMounted() {
 		err = syscall.Unmount(d.mountPoint, 0)
 	} else if d.mountProcAlive() {
-		err = d.mountProc.Process.Kill()
-	} else {
-		return nil
+		// ltfs runs in its own process group, which is killed as a whole
+		err = syscall.Kill(-proc.Process.Pid, syscall.SIGKILL)
 	}
-
 	if err != nil {
-		return
+		return err
 	}
 
 	d.WaitForUnmount()
+	d.mountProc = nil
+	d.mountExited = nil
 	return nil
 }
 
 func (d *TapeDrive) WaitForUnmount() {
-	d.mountWait.Wait()
+	exited := d.mountExited
+	if exited != nil {
+		<-exited
+	}
 }
 
 func (d *TapeDrive) mountProcAlive() bool {
-	proc := d.mountProc
-	if proc == nil {
+	exited := d.mountExited
+	if exited == nil {
+		return false
+	}
+	select {
+	case <-exited:
 		return false
+	default:
+		return true
 	}
-	return proc.ProcessState == nil
 }
 
 func (d *TapeDrive) isMounted() bool {
@@ -0,0 +1,110 @@
+package drive
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/FoxDenHome/tapemgr/scsi/scsitest"
+	"github.com/FoxDenHome/tapemgr/util"
+)
+
+const OPCODE_TEST_UNIT_READY = 0x00
+
+// newHangingDrive returns a drive whose ltfs starts but never mounts, and the file ltfs writes its PID to
+func newHangingDrive(t *testing.T) (*TapeDrive, string) {
+	t.Helper()
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "ltfs.pid")
+
+	script := "#!/bin/sh\necho $$ > " + pidFile + "\nexec sleep 600\n"
+	err := os.WriteFile(filepath.Join(dir, "ltfs"), []byte(script), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dev := scsitest.New()
+	dev.Handle(OPCODE_TEST_UNIT_READY, func(cdb []byte, todev []byte) ([]byte, error) {
+		return nil, nil
+	})
+
+	mountPoint := filepath.Join(dir, "mnt")
+	err = os.Mkdir(mountPoint, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &TapeDrive{
+		DevicePath:   "/dev/nst0",
+		GenericPath:  "/dev/sg0",
+		ReadyTimeout: time.Second,
+		MountTimeout: time.Hour,
+		open:         dev.Open(),
+		mountPoint:   mountPoint,
+	}, pidFile
+}
+
+// assertKilled checks the fake ltfs is gone, Mount has reaped it before returning
+func assertKilled(t *testing.T, d *TapeDrive, pidFile string) {
+	t.Helper()
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("ltfs did not start: %v", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = syscall.Kill(pid, 0)
+	if !errors.Is(err, syscall.ESRCH) {
+		_ = syscall.Kill(pid, syscall.SIGKILL)
+		t.Errorf("ltfs (PID %d) is still running: %v", pid, err)
+	}
+	if d.mountProc != nil || d.mountProcAlive() {
+		t.Errorf("drive still tracks the ltfs process")
+	}
+}
+
+// waitForStart makes sure the fake ltfs wrote its PID before the mount gets aborted
+func waitForStart(ctx context.Context, pidFile string) error {
+	return util.Poll(ctx, 10*time.Second, func() (bool, error) {
+		_, err := os.Stat(pidFile)
+		return err == nil, nil
+	})
+}
+
+func TestMountTimeoutKillsLTFS(t *testing.T) {
+	d, pidFile := newHangingDrive(t)
+	d.MountTimeout = 2 * time.Second
+
+	err := d.Mount(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Mount: got %v, want a timeout", err)
+	}
+	assertKilled(t, d, pidFile)
+}
+
+func TestMountCancelKillsLTFS(t *testing.T) {
+	d, pidFile := newHangingDrive(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		_ = waitForStart(ctx, pidFile)
+		cancel()
+	}()
+
+	err := d.Mount(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Mount: got %v, want it to be cancelled", err)
+	}
+	assertKilled(t, d, pidFile)
+}
@@ -0,0 +1,38 @@
+package drive
+
+import (
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/xattr"
+	"golang.org/x/sys/unix"
+)
+
+func (d *TapeDrive) Stats() (int64, int64, error) {
+	var stat unix.Statfs_t
+	err := unix.Statfs(d.mountPoint, &stat)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return int64(stat.Blocks) * int64(stat.Bsize), int64(stat.Bfree) * int64(stat.Bsize), nil
+}
+
+func (d *TapeDrive) FileLocation(path string) (string, int, error) {
+	fullPath := filepath.Join(d.mountPoint, path)
+
+	partitionXattr, err := xattr.Get(fullPath, "user.ltfs.partition")
+	if err != nil {
+		return "", 0, err
+	}
+	startBlockXattr, err := xattr.Get(fullPath, "user.ltfs.startblock")
+	if err != nil {
+		return "", 0, err
+	}
+	startBlockNum, err := strconv.ParseInt(string(startBlockXattr), 10, 64)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return string(partitionXattr), int(startBlockNum), nil
+}
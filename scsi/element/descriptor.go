@@ -3,6 +3,7 @@ package element
 import (
 	"bytes"
 	"fmt"
+	"strings"
 )
 
 const (
@@ -14,12 +15,18 @@ type MediumType uint8
 type CodeSet uint8
 type IdentifierType uint8
 type Flag uint16
+type VolumeTagSource uint8
 
 const (
 	IDENTIFIER_TYPE_VENDOR IdentifierType = 0x00
+	IDENTIFIER_TYPE_T10    IdentifierType = 0x01
+	IDENTIFIER_TYPE_EUI64  IdentifierType = 0x02
+	IDENTIFIER_TYPE_NAA    IdentifierType = 0x03
 
 	CODE_SET_UNDEFINED CodeSet = 0x00
+	CODE_SET_BINARY    CodeSet = 0x01
 	CODE_SET_ASCII     CodeSet = 0x02
+	CODE_SET_UTF8      CodeSet = 0x03
 
 	FLAG_FULL           Flag = 1 << 0
 	FLAG_IMPORT_EXPORT  Flag = 1 << 1
@@ -33,8 +40,29 @@ const (
 	FLAG_ED                  Flag = 1 << (8 + 3)
 	FLAG_INVERT              Flag = 1 << (8 + 6)
 	FLAG_SOURCE_INVERT_VALID Flag = 1 << (8 + 7)
+
+	VOLUME_TAG_PRIMARY   VolumeTagSource = 0
+	VOLUME_TAG_ALTERNATE VolumeTagSource = 1
 )
 
+func ParseVolumeTagSource(str string) (VolumeTagSource, error) {
+	switch strings.ToLower(str) {
+	case "", "primary":
+		return VOLUME_TAG_PRIMARY, nil
+	case "alternate":
+		return VOLUME_TAG_ALTERNATE, nil
+	default:
+		return VOLUME_TAG_PRIMARY, fmt.Errorf("unknown volume tag source %q (expected primary or alternate)", str)
+	}
+}
+
+func (s VolumeTagSource) String() string {
+	if s == VOLUME_TAG_ALTERNATE {
+		return "alternate"
+	}
+	return "primary"
+}
+
 type Descriptor struct {
 	Address                     uint16
 	ElementType                 Type
@@ -45,15 +73,23 @@ type Descriptor struct {
 	SourceElementAddress        uint16
 	CodeSet                     CodeSet
 	IdentifierType              IdentifierType
-	VolumeTag                   string
 	Identifier                  string
+	RawIdentifier               []byte
+
+	// VolumeTag is the tag identifying the tape, by default the primary volume tag
+	VolumeTag          string
+	PrimaryVolumeTag   string
+	AlternateVolumeTag string
 }
 
-func ParseDescriptor(elementType Type, hasPVolTag bool, data []byte) (*Descriptor, error) {
+func ParseDescriptor(elementType Type, hasPVolTag bool, hasAVolTag bool, data []byte) (*Descriptor, error) {
 	dataLength := 16
 	if hasPVolTag {
 		dataLength += VolumeTagLength
 	}
+	if hasAVolTag {
+		dataLength += VolumeTagLength
+	}
 	if len(data) < dataLength {
 		return nil, fmt.Errorf("too small data length for element descriptor: expected >= %d, got %d", dataLength, len(data))
 	}
@@ -62,7 +98,14 @@ func ParseDescriptor(elementType Type, hasPVolTag bool, data []byte) (*Descripto
 	if hasPVolTag {
 		volTagEnd += VolumeTagLength
 	}
+	aVolTagStart := volTagEnd
+	if hasAVolTag {
+		volTagEnd += VolumeTagLength
+	}
 	identifierLen := int(data[volTagEnd+3])
+	if volTagEnd+4+identifierLen > len(data) {
+		return nil, fmt.Errorf("identifier length %d exceeds element descriptor length %d", identifierLen, len(data))
+	}
 
 	baseDesc := &Descriptor{
 		Address:     uint16(data[0])<<8 | uint16(data[1]),
@@ -75,17 +118,36 @@ func ParseDescriptor(elementType Type, hasPVolTag bool, data []byte) (*Descripto
 		CodeSet:                     CodeSet(data[volTagEnd] & 0x0F),
 		IdentifierType:              IdentifierType(data[volTagEnd+1] & 0x0F),
 		Identifier:                  string(bytes.Trim(data[volTagEnd+4:volTagEnd+4+identifierLen], "\x00 ")),
+		RawIdentifier:               bytes.Clone(data[volTagEnd+4 : volTagEnd+4+identifierLen]),
 
 		Flags: uint16(data[9])<<8 | uint16(data[2]),
 	}
 
 	if hasPVolTag {
-		baseDesc.VolumeTag = string(bytes.Trim(data[12:48], "\x00 "))
+		baseDesc.PrimaryVolumeTag = parseVolumeTag(data[12 : 12+VolumeTagLength])
 	}
+	if hasAVolTag {
+		baseDesc.AlternateVolumeTag = parseVolumeTag(data[aVolTagStart : aVolTagStart+VolumeTagLength])
+	}
+	baseDesc.VolumeTag = baseDesc.PrimaryVolumeTag
 
 	return baseDesc, nil
 }
 
+// parseVolumeTag returns the volume identification field, ignoring the trailing volume sequence number
+func parseVolumeTag(data []byte) string {
+	return string(bytes.Trim(data[:32], "\x00 "))
+}
+
+// SelectVolumeTag sets VolumeTag to the primary or alternate volume tag
+func (d *Descriptor) SelectVolumeTag(source VolumeTagSource) {
+	if source == VOLUME_TAG_ALTERNATE {
+		d.VolumeTag = d.AlternateVolumeTag
+	} else {
+		d.VolumeTag = d.PrimaryVolumeTag
+	}
+}
+
 func (d *Descriptor) HasFlag(flag Flag) bool {
 	return d.Flags&uint16(flag) != 0
 }
@@ -0,0 +1,78 @@
+package element
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	T10_VENDOR_LENGTH  = 8
+	T10_PRODUCT_LENGTH = 16
+)
+
+func (t IdentifierType) String() string {
+	switch t {
+	case IDENTIFIER_TYPE_VENDOR:
+		return "Vendor Specific"
+	case IDENTIFIER_TYPE_T10:
+		return "T10 Vendor ID"
+	case IDENTIFIER_TYPE_EUI64:
+		return "EUI-64"
+	case IDENTIFIER_TYPE_NAA:
+		return "NAA"
+	default:
+		return fmt.Sprintf("Type %#02x", uint8(t))
+	}
+}
+
+func (c CodeSet) IsText() bool {
+	return c == CODE_SET_ASCII || c == CODE_SET_UTF8
+}
+
+// Designator is a device identifier as reported in element descriptors and in VPD page 0x83
+type Designator struct {
+	CodeSet CodeSet
+	Type    IdentifierType
+	Value   []byte
+}
+
+func (d Designator) Text() string {
+	return string(bytes.Trim(d.Value, "\x00 "))
+}
+
+// SplitT10 splits a T10 vendor ID designator into vendor, product and the vendor specific remainder (usually the serial number)
+func (d Designator) SplitT10() (vendor string, product string, serial string, ok bool) {
+	if d.Type != IDENTIFIER_TYPE_T10 || !d.CodeSet.IsText() || len(d.Value) <= T10_VENDOR_LENGTH {
+		return "", "", "", false
+	}
+
+	vendor = string(bytes.TrimSpace(d.Value[:T10_VENDOR_LENGTH]))
+	rest := d.Value[T10_VENDOR_LENGTH:]
+	if len(rest) <= T10_PRODUCT_LENGTH {
+		return vendor, "", string(bytes.Trim(rest, "\x00 ")), true
+	}
+	product = string(bytes.TrimSpace(rest[:T10_PRODUCT_LENGTH]))
+	serial = string(bytes.Trim(rest[T10_PRODUCT_LENGTH:], "\x00 "))
+	return vendor, product, serial, true
+}
+
+func (d Designator) String() string {
+	if len(d.Value) == 0 {
+		return "none"
+	}
+	if vendor, product, serial, ok := d.SplitT10(); ok {
+		return fmt.Sprintf("%s vendor=%q product=%q serial=%q", d.Type, vendor, product, serial)
+	}
+	if d.CodeSet.IsText() {
+		return fmt.Sprintf("%s %q", d.Type, d.Text())
+	}
+	return fmt.Sprintf("%s %x", d.Type, d.Value)
+}
+
+func (d *Descriptor) Designator() Designator {
+	return Designator{
+		CodeSet: d.CodeSet,
+		Type:    d.IdentifierType,
+		Value:   d.RawIdentifier,
+	}
+}
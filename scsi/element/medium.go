@@ -0,0 +1,45 @@
+package element
+
+import "strings"
+
+const (
+	MEDIUM_TYPE_UNSPECIFIED MediumType = 0x00
+	MEDIUM_TYPE_DATA        MediumType = 0x01
+	MEDIUM_TYPE_CLEANING    MediumType = 0x02
+	MEDIUM_TYPE_DIAGNOSTIC  MediumType = 0x03
+	MEDIUM_TYPE_WORM        MediumType = 0x04
+	MEDIUM_TYPE_MICROCODE   MediumType = 0x05
+
+	// LTO cleaning cartridges are labelled CLNxxxCU (universal) or CLNxxxLn
+	CLEANING_VOLUME_TAG_PREFIX = "CLN"
+)
+
+func (t MediumType) String() string {
+	switch t {
+	case MEDIUM_TYPE_UNSPECIFIED:
+		return "Unspecified"
+	case MEDIUM_TYPE_DATA:
+		return "Data"
+	case MEDIUM_TYPE_CLEANING:
+		return "Cleaning"
+	case MEDIUM_TYPE_DIAGNOSTIC:
+		return "Diagnostic"
+	case MEDIUM_TYPE_WORM:
+		return "WORM"
+	case MEDIUM_TYPE_MICROCODE:
+		return "Microcode"
+	default:
+		return "Unknown"
+	}
+}
+
+// IsCleaningVolumeTag reports whether a volume tag follows the cleaning cartridge naming convention
+func IsCleaningVolumeTag(volumeTag string) bool {
+	return strings.HasPrefix(strings.ToUpper(volumeTag), CLEANING_VOLUME_TAG_PREFIX)
+}
+
+// IsCleaning reports whether the element holds a cleaning cartridge, going by the medium type
+// the changer reports and, as not every changer reports one, by the volume tag
+func (d *Descriptor) IsCleaning() bool {
+	return d.MediumType == MEDIUM_TYPE_CLEANING || IsCleaningVolumeTag(d.VolumeTag)
+}
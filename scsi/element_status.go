@@ -1,41 +1,88 @@
 package scsi
 
 import (
-	"errors"
+	"fmt"
+	"time"
 
 	scsidefs "github.com/FoxDenHome/goscsi/godefs/scsi"
 	"github.com/FoxDenHome/tapemgr/scsi/element"
 )
 
-func (d *SCSIDevice) ReadElementStatus(elementType element.Type, start uint16, count uint16, curData bool, readVolumeTag bool, readDeviceId bool) ([]*element.Descriptor, error) {
+const (
+	// Kept well below the 24 bit allocation length limit so that any HBA can transfer it in one command
+	ELEMENT_STATUS_MAX_LENGTH = 64 * 1024
+
+	// Libraries scan the barcodes of every slot before answering if their inventory is stale,
+	// e.g. after a magazine was opened, which takes minutes on large libraries
+	ELEMENT_STATUS_TIMEOUT = 10 * time.Minute
+)
+
+func perElementStatusLength(readVolumeTag bool, readDeviceId bool) int {
 	perElementLen := 52 // Maximum plain response len observed in docs
 	if readVolumeTag {
-		perElementLen += element.VolumeTagLength
+		perElementLen += 2 * element.VolumeTagLength // Primary and alternate
 	}
 	if readDeviceId {
 		perElementLen += element.DeviceIDLengthMax
 	}
+	return perElementLen
+}
+
+func elementStatusLength(count uint16, readVolumeTag bool, readDeviceId bool) int {
 	// Expected element length + (page header * max pages [one per element type at most]) + header
-	reservedRespLen := (perElementLen * int(count)) + (8 * element.ElementTypes) + 8
-
-	resp, err := d.request([]byte{
-		scsidefs.READ_ELEMENT_STATUS,
-		boolToFlag(readVolumeTag, 4) | uint8(elementType),
-		uint8(start >> 8), uint8(start & 0xFF),
-		uint8(count >> 8), uint8(count & 0xFF),
-		boolToFlag(curData, 1) | boolToFlag(readDeviceId, 0),
-		uint8(reservedRespLen >> 16), uint8((reservedRespLen >> 8) & 0xFF), uint8(reservedRespLen & 0xFF),
-		0x00, 0x00,
-	}, reservedRespLen)
+	return (perElementStatusLength(readVolumeTag, readDeviceId) * int(count)) + (8 * element.ElementTypes) + 8
+}
+
+// MaxElementsPerRequest returns how many elements fit into one READ ELEMENT STATUS response
+func MaxElementsPerRequest(readVolumeTag bool, readDeviceId bool) uint16 {
+	return uint16((ELEMENT_STATUS_MAX_LENGTH - (8 * element.ElementTypes) - 8) / perElementStatusLength(readVolumeTag, readDeviceId))
+}
+
+func (d *SCSIDevice) ReadElementStatus(elementType element.Type, start uint16, count uint16, curData bool, readVolumeTag bool, readDeviceId bool) ([]*element.Descriptor, error) {
+	reservedRespLen := elementStatusLength(count, readVolumeTag, readDeviceId)
+	if reservedRespLen > ELEMENT_STATUS_MAX_LENGTH {
+		return nil, fmt.Errorf("element status for %d elements exceeds maximum response length, request at most %d", count, MaxElementsPerRequest(readVolumeTag, readDeviceId))
+	}
+
+	request := func(allocLen int) ([]byte, error) {
+		return d.requestWithTimeout([]byte{
+			scsidefs.READ_ELEMENT_STATUS,
+			boolToFlag(readVolumeTag, 4) | uint8(elementType),
+			uint8(start >> 8), uint8(start & 0xFF),
+			uint8(count >> 8), uint8(count & 0xFF),
+			boolToFlag(curData, 1) | boolToFlag(readDeviceId, 0),
+			uint8(allocLen >> 16), uint8((allocLen >> 8) & 0xFF), uint8(allocLen & 0xFF),
+			0x00, 0x00,
+		}, allocLen, ELEMENT_STATUS_TIMEOUT)
+	}
+
+	resp, err := request(reservedRespLen)
 	if err != nil {
 		return nil, err
 	}
+	if len(resp) < 8 {
+		return nil, fmt.Errorf("too short element status response: %d bytes", len(resp))
+	}
 
-	var elementStatuses []*element.Descriptor
-	reportLength := int(resp[5])<<16 | int(resp[6])<<8 | int(resp[7])
+	// Descriptors larger than expected make the report exceed our estimate, ask again for all of it
+	end := elementStatusReportLength(resp) + 8
+	if end > reservedRespLen && end <= ELEMENT_STATUS_MAX_LENGTH {
+		resp, err = request(end)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp) < 8 {
+			return nil, fmt.Errorf("too short element status response: %d bytes", len(resp))
+		}
+		end = elementStatusReportLength(resp) + 8
+	}
+	if end > len(resp) {
+		return nil, fmt.Errorf("truncated element status response: %d of %d bytes", len(resp), end)
+	}
 
+	var elementStatuses []*element.Descriptor
 	pos := 8
-	for pos < reportLength+8 {
+	for pos+8 <= end {
 		elementType := element.Type(resp[pos] & 0x0F)
 		elementLength := int(resp[pos+2])<<8 | int(resp[pos+3])
 		pageLength := int(resp[pos+5])<<16 | int(resp[pos+6])<<8 | int(resp[pos+7])
@@ -43,19 +90,22 @@ func (d *SCSIDevice) ReadElementStatus(elementType element.Type, start uint16, c
 		hasPVolTag := flagToBool(resp[pos+1], 7)
 		hasAVolTag := flagToBool(resp[pos+1], 6)
 
-		if hasAVolTag {
-			return nil, errors.New("received Alternative Volume Tag, which is not supported")
+		if elementLength == 0 {
+			return nil, fmt.Errorf("invalid zero element descriptor length in %s page", elementType)
 		}
 
 		pos += 8
-		subPos := 0
-		for subPos < pageLength && pos+subPos+elementLength <= reportLength+8 {
-			desc, err := element.ParseDescriptor(elementType, hasPVolTag, resp[pos+subPos:pos+subPos+elementLength])
+		pageEnd := pos + pageLength
+		if pageEnd > end {
+			pageEnd = end
+		}
+
+		for descPos := pos; descPos+elementLength <= pageEnd; descPos += elementLength {
+			desc, err := element.ParseDescriptor(elementType, hasPVolTag, hasAVolTag, resp[descPos:descPos+elementLength])
 			if err != nil {
 				return nil, err
 			}
 			elementStatuses = append(elementStatuses, desc)
-			subPos += elementLength
 		}
 
 		pos += pageLength
@@ -63,3 +113,7 @@ func (d *SCSIDevice) ReadElementStatus(elementType element.Type, start uint16, c
 
 	return elementStatuses, nil
 }
+
+func elementStatusReportLength(resp []byte) int {
+	return int(resp[5])<<16 | int(resp[6])<<8 | int(resp[7])
+}
@@ -0,0 +1,175 @@
+package scsi_test
+
+import (
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+	"github.com/FoxDenHome/tapemgr/scsi/scsitest"
+)
+
+const READ_ELEMENT_STATUS = 0xB8
+
+type wantElement struct {
+	address     uint16
+	elementType element.Type
+	volumeTag   string
+	source      uint16
+	exception   bool
+	identifier  string
+}
+
+func TestReadElementStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		response []byte
+		want     []wantElement
+	}{
+		{
+			name:     "IBM TS4300 drives",
+			response: scsitest.IBM_TS4300_DATA_TRANSFER,
+			want: []wantElement{
+				{address: 256, elementType: element.ELEMENT_TYPE_DATA_TRANSFER, volumeTag: "IBM102L8", source: 4098, identifier: "IBM     ULT3580-TD8     1013000100"},
+				{address: 257, elementType: element.ELEMENT_TYPE_DATA_TRANSFER, identifier: "IBM     ULT3580-TD8     1013000101"},
+			},
+		},
+		{
+			name:     "mixed drive identifiers",
+			response: scsitest.MIXED_IDENTIFIER_DATA_TRANSFER,
+			want: []wantElement{
+				{address: 256, elementType: element.ELEMENT_TYPE_DATA_TRANSFER, identifier: "\x50\x05\x07\x63\x12\x4b\x0a\x11"},
+				{address: 257, elementType: element.ELEMENT_TYPE_DATA_TRANSFER, identifier: "HU19087F4K"},
+				{address: 258, elementType: element.ELEMENT_TYPE_DATA_TRANSFER, identifier: "HP"},
+			},
+		},
+		{
+			name:     "IBM TS4300 all elements",
+			response: scsitest.IBM_TS4300_ALL,
+			want: []wantElement{
+				{address: 1, elementType: element.ELEMENT_TYPE_MEDIUM_TRANSPORT},
+				{address: 4096, elementType: element.ELEMENT_TYPE_STORAGE, volumeTag: "IBM100L8"},
+				{address: 4097, elementType: element.ELEMENT_TYPE_STORAGE, volumeTag: "IBM101L8"},
+				{address: 4098, elementType: element.ELEMENT_TYPE_STORAGE},
+				{address: 4099, elementType: element.ELEMENT_TYPE_STORAGE, volumeTag: "IBM103L8"},
+				{address: 4100, elementType: element.ELEMENT_TYPE_STORAGE},
+				{address: 4101, elementType: element.ELEMENT_TYPE_STORAGE, volumeTag: "IBM105L8"},
+				{address: 4102, elementType: element.ELEMENT_TYPE_STORAGE},
+				{address: 4103, elementType: element.ELEMENT_TYPE_STORAGE},
+				{address: 768, elementType: element.ELEMENT_TYPE_IMPORT_EXPORT, volumeTag: "IBM900L7"},
+				{address: 769, elementType: element.ELEMENT_TYPE_IMPORT_EXPORT},
+				{address: 256, elementType: element.ELEMENT_TYPE_DATA_TRANSFER, volumeTag: "IBM102L8", source: 4098},
+				{address: 257, elementType: element.ELEMENT_TYPE_DATA_TRANSFER},
+			},
+		},
+		{
+			name:     "HPE MSL3040 drives",
+			response: scsitest.HPE_MSL3040_DATA_TRANSFER,
+			want: []wantElement{
+				{address: 500, elementType: element.ELEMENT_TYPE_DATA_TRANSFER, identifier: "HPE     Ultrium 9-SCSI  DEC9123400"},
+				{address: 501, elementType: element.ELEMENT_TYPE_DATA_TRANSFER, volumeTag: "HPE017L9", source: 1001, identifier: "HPE     Ultrium 9-SCSI  DEC9123401"},
+			},
+		},
+		{
+			name:     "HPE MSL3040 all elements",
+			response: scsitest.HPE_MSL3040_ALL,
+			want: []wantElement{
+				{address: 16, elementType: element.ELEMENT_TYPE_IMPORT_EXPORT},
+				{address: 17, elementType: element.ELEMENT_TYPE_IMPORT_EXPORT, volumeTag: "CLN001CU"},
+				{address: 1000, elementType: element.ELEMENT_TYPE_STORAGE, volumeTag: "HPE016L9"},
+				{address: 1001, elementType: element.ELEMENT_TYPE_STORAGE},
+				{address: 1002, elementType: element.ELEMENT_TYPE_STORAGE, volumeTag: "HPE018L9"},
+				{address: 1003, elementType: element.ELEMENT_TYPE_STORAGE},
+				{address: 1004, elementType: element.ELEMENT_TYPE_STORAGE, volumeTag: "HPE020L9"},
+				{address: 1005, elementType: element.ELEMENT_TYPE_STORAGE},
+			},
+		},
+		{
+			name:     "Quantum Scalar i3 all elements",
+			response: scsitest.QUANTUM_SCALAR_I3_ALL,
+			want: []wantElement{
+				{address: 256, elementType: element.ELEMENT_TYPE_DATA_TRANSFER, volumeTag: "QTM002L8", source: 4098, identifier: "QUANTUM ULTRIUM-HH8     10WT045612"},
+				{address: 257, elementType: element.ELEMENT_TYPE_DATA_TRANSFER, exception: true, identifier: "QUANTUM ULTRIUM-HH8     10WT045613"},
+				{address: 4096, elementType: element.ELEMENT_TYPE_STORAGE, volumeTag: "QTM000L8"},
+				{address: 4097, elementType: element.ELEMENT_TYPE_STORAGE, volumeTag: "QTM001L8"},
+				{address: 4098, elementType: element.ELEMENT_TYPE_STORAGE},
+				{address: 4099, elementType: element.ELEMENT_TYPE_STORAGE, volumeTag: "QTM003L8"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dev := scsitest.New()
+			dev.Reply(READ_ELEMENT_STATUS, test.response, nil)
+
+			elements, err := scsi.NewDevice(dev).ReadElementStatus(element.ELEMENT_TYPE_ALL, 0, 64, false, true, true)
+			if err != nil {
+				t.Fatalf("ReadElementStatus: %v", err)
+			}
+			if len(elements) != len(test.want) {
+				t.Fatalf("got %d elements, want %d", len(elements), len(test.want))
+			}
+			for i, want := range test.want {
+				elem := elements[i]
+				if elem.Address != want.address || elem.ElementType != want.elementType {
+					t.Errorf("element %d is %s %d, want %s %d", i, elem.ElementType, elem.Address, want.elementType, want.address)
+				}
+				if elem.HasFlag(element.FLAG_FULL) != (want.volumeTag != "") || elem.VolumeTag != want.volumeTag {
+					t.Errorf("element %d holds %q (full %v), want %q", want.address, elem.VolumeTag, elem.HasFlag(element.FLAG_FULL), want.volumeTag)
+				}
+				if want.source != 0 && (!elem.HasFlag(element.FLAG_SOURCE_INVERT_VALID) || elem.SourceElementAddress != want.source) {
+					t.Errorf("element %d has source %d, want %d", want.address, elem.SourceElementAddress, want.source)
+				}
+				if elem.HasFlag(element.FLAG_EXCEPTION) != want.exception {
+					t.Errorf("element %d exception %v, want %v", want.address, elem.HasFlag(element.FLAG_EXCEPTION), want.exception)
+				}
+				if elem.Identifier != want.identifier {
+					t.Errorf("element %d identifier %q, want %q", want.address, elem.Identifier, want.identifier)
+				}
+			}
+		})
+	}
+}
+
+func TestReadElementStatusTruncated(t *testing.T) {
+	dev := scsitest.New()
+	dev.Reply(READ_ELEMENT_STATUS, scsitest.QUANTUM_SCALAR_I3_TRUNCATED, nil)
+
+	elements, err := scsi.NewDevice(dev).ReadElementStatus(element.ELEMENT_TYPE_ALL, 0, 64, false, true, true)
+	if err == nil {
+		t.Fatalf("expected an error, got %d elements", len(elements))
+	}
+}
+
+func TestReadElementStatusReissuesWithReportLength(t *testing.T) {
+	dev := scsitest.New()
+	// Every request is answered with the full report, cut off at the allocation length
+	dev.Handle(READ_ELEMENT_STATUS, func(cdb []byte, todev []byte) ([]byte, error) {
+		return scsitest.IBM_TS4300_ALL, nil
+	})
+
+	elements, err := scsi.NewDevice(dev).ReadElementStatus(element.ELEMENT_TYPE_ALL, 0, 1, false, true, false)
+	if err != nil {
+		t.Fatalf("ReadElementStatus: %v", err)
+	}
+	if len(elements) != 13 {
+		t.Errorf("got %d elements, want 13", len(elements))
+	}
+
+	requests := dev.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	for _, request := range requests {
+		if len(request.CDB) != 12 {
+			t.Errorf("CDB length %d, want 12", len(request.CDB))
+		}
+		if request.Timeout != scsi.ELEMENT_STATUS_TIMEOUT {
+			t.Errorf("timeout %v, want %v", request.Timeout, scsi.ELEMENT_STATUS_TIMEOUT)
+		}
+	}
+	allocLen := int(requests[1].CDB[7])<<16 | int(requests[1].CDB[8])<<8 | int(requests[1].CDB[9])
+	if allocLen != len(scsitest.IBM_TS4300_ALL) {
+		t.Errorf("second request allocates %d bytes, want the reported %d", allocLen, len(scsitest.IBM_TS4300_ALL))
+	}
+}
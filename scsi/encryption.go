@@ -0,0 +1,223 @@
+package scsi
+
+import (
+	"fmt"
+)
+
+const (
+	SECURITY_PROTOCOL_IN  = 0xA2
+	SECURITY_PROTOCOL_OUT = 0xB5
+
+	SECURITY_PROTOCOL_TAPE_DATA_ENCRYPTION = 0x20
+
+	// Tape data encryption pages, capabilities and status are read, the settings page is written
+	ENCRYPTION_PAGE_CAPABILITIES = 0x0010
+	ENCRYPTION_PAGE_STATUS       = 0x0020
+	ENCRYPTION_PAGE_SET          = 0x0010
+
+	ENCRYPTION_PAGE_MAX_LENGTH = 0x2000
+
+	SECURITY_ALGORITHM_AES_256_GCM = 0x00010014
+
+	ENCRYPTION_MODE_DISABLE  = 0x00
+	ENCRYPTION_MODE_EXTERNAL = 0x01
+	ENCRYPTION_MODE_ENCRYPT  = 0x02
+
+	DECRYPTION_MODE_DISABLE = 0x00
+	DECRYPTION_MODE_RAW     = 0x01
+	DECRYPTION_MODE_DECRYPT = 0x02
+	// Reads encrypted and unencrypted blocks, so tapes written before encryption was enabled stay readable
+	DECRYPTION_MODE_MIXED = 0x03
+
+	// Settings apply to every I_T nexus, so the st driver LTFS writes through is covered
+	ENCRYPTION_SCOPE_ALL_IT_NEXUS = 0x02 << 5
+	// Clear key on demount, the key never outlives the tape it was set for
+	ENCRYPTION_FLAG_CKOD = 2
+
+	KAD_TYPE_UNAUTHENTICATED = 0x00
+	// The drive stores the unauthenticated key-associated data with every block, it identifies the key
+	KAD_MAX_UNAUTHENTICATED_LENGTH = 32
+
+	ENCRYPTION_ALGORITHM_HEADER_LENGTH = 20
+	ENCRYPTION_STATUS_HEADER_LENGTH    = 24
+)
+
+// EncryptionAlgorithm is a data encryption algorithm the drive supports, indexed by the drive
+type EncryptionAlgorithm struct {
+	Index      uint8  `json:"index"`
+	Code       uint32 `json:"code"`
+	KeyLength  uint16 `json:"key-length"`
+	CanEncrypt bool   `json:"can-encrypt"`
+	CanDecrypt bool   `json:"can-decrypt"`
+}
+
+// EncryptionStatus is the current data encryption state of the drive
+type EncryptionStatus struct {
+	EncryptionMode     uint8  `json:"encryption-mode"`
+	DecryptionMode     uint8  `json:"decryption-mode"`
+	AlgorithmIndex     uint8  `json:"algorithm-index"`
+	KeyInstanceCounter uint32 `json:"key-instance-counter"`
+	// KeyID is the unauthenticated key-associated data set along with the key
+	KeyID string `json:"key-id,omitempty"`
+}
+
+// EncryptionSettings are written with SetEncryption, the key is omitted to disable encryption
+type EncryptionSettings struct {
+	EncryptionMode uint8
+	DecryptionMode uint8
+	AlgorithmIndex uint8
+	Key            []byte
+	KeyID          string
+}
+
+func (d *SCSIDevice) securityProtocolIn(page uint16) ([]byte, error) {
+	return d.request([]byte{
+		SECURITY_PROTOCOL_IN,
+		SECURITY_PROTOCOL_TAPE_DATA_ENCRYPTION,
+		uint8(page >> 8), uint8(page),
+		0x00, 0x00,
+		0x00, 0x00,
+		ENCRYPTION_PAGE_MAX_LENGTH >> 8,
+		ENCRYPTION_PAGE_MAX_LENGTH & 0xFF,
+		0x00,
+		0x00,
+	}, ENCRYPTION_PAGE_MAX_LENGTH)
+}
+
+// EncryptionAlgorithms reads the data encryption capabilities page
+func (d *SCSIDevice) EncryptionAlgorithms() ([]EncryptionAlgorithm, error) {
+	resp, err := d.securityProtocolIn(ENCRYPTION_PAGE_CAPABILITIES)
+	if err != nil {
+		return nil, err
+	}
+	return parseEncryptionAlgorithms(resp)
+}
+
+func parseEncryptionAlgorithms(data []byte) ([]EncryptionAlgorithm, error) {
+	if len(data) < ENCRYPTION_ALGORITHM_HEADER_LENGTH {
+		return nil, fmt.Errorf("too short data encryption capabilities page: %d bytes", len(data))
+	}
+
+	end := 4 + (int(data[2])<<8 | int(data[3]))
+	if end > len(data) {
+		end = len(data)
+	}
+
+	var algorithms []EncryptionAlgorithm
+	for pos := ENCRYPTION_ALGORITHM_HEADER_LENGTH; pos+4 <= end; {
+		length := 4 + (int(data[pos+2])<<8 | int(data[pos+3]))
+		if pos+length > end {
+			break
+		}
+		desc := data[pos : pos+length]
+		pos += length
+
+		// The security algorithm code ends the 24 byte descriptor
+		if len(desc) < 24 {
+			continue
+		}
+		algorithms = append(algorithms, EncryptionAlgorithm{
+			Index:      desc[0],
+			Code:       uint32(desc[20])<<24 | uint32(desc[21])<<16 | uint32(desc[22])<<8 | uint32(desc[23]),
+			KeyLength:  uint16(desc[10])<<8 | uint16(desc[11]),
+			CanEncrypt: desc[4]&0x03 == 0x02,
+			CanDecrypt: (desc[4]>>2)&0x03 == 0x02,
+		})
+	}
+	return algorithms, nil
+}
+
+// EncryptionStatus reads the data encryption status page
+func (d *SCSIDevice) EncryptionStatus() (*EncryptionStatus, error) {
+	resp, err := d.securityProtocolIn(ENCRYPTION_PAGE_STATUS)
+	if err != nil {
+		return nil, err
+	}
+	return parseEncryptionStatus(resp)
+}
+
+func parseEncryptionStatus(data []byte) (*EncryptionStatus, error) {
+	if len(data) < ENCRYPTION_STATUS_HEADER_LENGTH {
+		return nil, fmt.Errorf("too short data encryption status page: %d bytes", len(data))
+	}
+
+	end := 4 + (int(data[2])<<8 | int(data[3]))
+	if end > len(data) {
+		end = len(data)
+	}
+
+	status := &EncryptionStatus{
+		EncryptionMode:     data[5],
+		DecryptionMode:     data[6],
+		AlgorithmIndex:     data[7],
+		KeyInstanceCounter: uint32(data[8])<<24 | uint32(data[9])<<16 | uint32(data[10])<<8 | uint32(data[11]),
+	}
+
+	for pos := ENCRYPTION_STATUS_HEADER_LENGTH; pos+4 <= end; {
+		length := int(data[pos+2])<<8 | int(data[pos+3])
+		if pos+4+length > end {
+			break
+		}
+		if data[pos] == KAD_TYPE_UNAUTHENTICATED {
+			status.KeyID = string(data[pos+4 : pos+4+length])
+		}
+		pos += 4 + length
+	}
+	return status, nil
+}
+
+// SetEncryption writes the set data encryption page
+func (d *SCSIDevice) SetEncryption(settings *EncryptionSettings) error {
+	data, err := buildSetEncryption(settings)
+	if err != nil {
+		return err
+	}
+
+	return d.send([]byte{
+		SECURITY_PROTOCOL_OUT,
+		SECURITY_PROTOCOL_TAPE_DATA_ENCRYPTION,
+		ENCRYPTION_PAGE_SET >> 8, ENCRYPTION_PAGE_SET & 0xFF,
+		0x00, 0x00,
+		uint8(len(data) >> 24),
+		uint8(len(data) >> 16),
+		uint8(len(data) >> 8),
+		uint8(len(data)),
+		0x00,
+		0x00,
+	}, data)
+}
+
+func buildSetEncryption(settings *EncryptionSettings) ([]byte, error) {
+	if len(settings.KeyID) > KAD_MAX_UNAUTHENTICATED_LENGTH {
+		return nil, fmt.Errorf("key ID %q is longer than %d bytes", settings.KeyID, KAD_MAX_UNAUTHENTICATED_LENGTH)
+	}
+
+	data := []byte{
+		ENCRYPTION_PAGE_SET >> 8, ENCRYPTION_PAGE_SET & 0xFF,
+		0x00, 0x00, // Page length, filled in below
+		ENCRYPTION_SCOPE_ALL_IT_NEXUS,
+		boolToFlag(len(settings.Key) > 0, ENCRYPTION_FLAG_CKOD),
+		settings.EncryptionMode,
+		settings.DecryptionMode,
+		settings.AlgorithmIndex,
+		0x00, // Plain key
+		0x00, // KAD format
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		uint8(len(settings.Key) >> 8), uint8(len(settings.Key)),
+	}
+	data = append(data, settings.Key...)
+
+	if settings.KeyID != "" {
+		data = append(data,
+			KAD_TYPE_UNAUTHENTICATED,
+			0x00,
+			uint8(len(settings.KeyID)>>8), uint8(len(settings.KeyID)),
+		)
+		data = append(data, settings.KeyID...)
+	}
+
+	pageLen := len(data) - 4
+	data[2] = uint8(pageLen >> 8)
+	data[3] = uint8(pageLen)
+	return data, nil
+}
@@ -0,0 +1,358 @@
+package scsi_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/scsitest"
+)
+
+// algorithmDescriptor encodes a 24 byte data encryption algorithm descriptor
+func algorithmDescriptor(index uint8, capabilities uint8, keyLength uint16, code uint32) []byte {
+	desc := make([]byte, 24)
+	desc[0] = index
+	binary.BigEndian.PutUint16(desc[2:], 20)
+	desc[4] = capabilities
+	binary.BigEndian.PutUint16(desc[10:], keyLength)
+	binary.BigEndian.PutUint32(desc[20:], code)
+	return desc
+}
+
+// capabilitiesPage encodes a data encryption capabilities page with the given descriptors
+func capabilitiesPage(descs ...[]byte) []byte {
+	body := bytes.Join(descs, nil)
+	page := make([]byte, scsi.ENCRYPTION_ALGORITHM_HEADER_LENGTH)
+	binary.BigEndian.PutUint16(page, scsi.ENCRYPTION_PAGE_CAPABILITIES)
+	binary.BigEndian.PutUint16(page[2:], uint16(len(page)-4+len(body)))
+	return append(page, body...)
+}
+
+// keyAssociatedData encodes a key-associated data descriptor of the status page
+func keyAssociatedData(kadType uint8, value string) []byte {
+	kad := []byte{kadType, 0x00}
+	kad = binary.BigEndian.AppendUint16(kad, uint16(len(value)))
+	return append(kad, value...)
+}
+
+// statusPage encodes a data encryption status page with the given key-associated data
+func statusPage(encryptionMode, decryptionMode, index uint8, counter uint32, kads ...[]byte) []byte {
+	body := bytes.Join(kads, nil)
+	page := make([]byte, scsi.ENCRYPTION_STATUS_HEADER_LENGTH)
+	binary.BigEndian.PutUint16(page, scsi.ENCRYPTION_PAGE_STATUS)
+	binary.BigEndian.PutUint16(page[2:], uint16(len(page)-4+len(body)))
+	page[4] = scsi.ENCRYPTION_SCOPE_ALL_IT_NEXUS
+	page[5] = encryptionMode
+	page[6] = decryptionMode
+	page[7] = index
+	binary.BigEndian.PutUint32(page[8:], counter)
+	return append(page, body...)
+}
+
+func TestEncryptionAlgorithms(t *testing.T) {
+	aes := algorithmDescriptor(1, 0x0A, 32, scsi.SECURITY_ALGORITHM_AES_256_GCM)
+
+	tests := []struct {
+		name    string
+		resp    []byte
+		want    []scsi.EncryptionAlgorithm
+		wantErr string
+	}{
+		{
+			name: "encrypt and decrypt",
+			resp: capabilitiesPage(aes),
+			want: []scsi.EncryptionAlgorithm{
+				{Index: 1, Code: scsi.SECURITY_ALGORITHM_AES_256_GCM, KeyLength: 32, CanEncrypt: true, CanDecrypt: true},
+			},
+		},
+		{
+			// Capability 0x01 is external control only, 0x03 is reserved
+			name: "several algorithms",
+			resp: capabilitiesPage(
+				algorithmDescriptor(1, 0x09, 32, 0x00010010),
+				algorithmDescriptor(2, 0x07, 16, 0x00010014),
+			),
+			want: []scsi.EncryptionAlgorithm{
+				{Index: 1, Code: 0x00010010, KeyLength: 32, CanDecrypt: true},
+				{Index: 2, Code: 0x00010014, KeyLength: 16},
+			},
+		},
+		{
+			name: "no algorithms",
+			resp: capabilitiesPage(),
+		},
+		{
+			// The descriptor claims to end before the algorithm code
+			name: "short descriptor skipped",
+			resp: capabilitiesPage([]byte{0x02, 0x00, 0x00, 0x04, 0x0A, 0x00, 0x00, 0x00}, aes),
+			want: []scsi.EncryptionAlgorithm{
+				{Index: 1, Code: scsi.SECURITY_ALGORITHM_AES_256_GCM, KeyLength: 32, CanEncrypt: true, CanDecrypt: true},
+			},
+		},
+		{
+			name: "page longer than the response",
+			resp: capabilitiesPage(aes, algorithmDescriptor(2, 0x0A, 32, 0x00010010))[:60],
+			want: []scsi.EncryptionAlgorithm{
+				{Index: 1, Code: scsi.SECURITY_ALGORITHM_AES_256_GCM, KeyLength: 32, CanEncrypt: true, CanDecrypt: true},
+			},
+		},
+		{
+			name:    "too short",
+			resp:    capabilitiesPage()[:8],
+			wantErr: "too short data encryption capabilities page: 8 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := scsitest.New()
+			dev.Reply(scsi.SECURITY_PROTOCOL_IN, tt.resp, nil)
+
+			algorithms, err := scsi.NewDevice(dev).EncryptionAlgorithms()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EncryptionAlgorithms: %v", err)
+			}
+			if !reflect.DeepEqual(algorithms, tt.want) {
+				t.Errorf("got algorithms %+v, want %+v", algorithms, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncryptionStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    []byte
+		want    scsi.EncryptionStatus
+		wantErr string
+	}{
+		{
+			name: "encrypting with key ID",
+			resp: statusPage(scsi.ENCRYPTION_MODE_ENCRYPT, scsi.DECRYPTION_MODE_MIXED, 1, 3,
+				keyAssociatedData(scsi.KAD_TYPE_UNAUTHENTICATED, "backup-2026"),
+			),
+			want: scsi.EncryptionStatus{
+				EncryptionMode:     scsi.ENCRYPTION_MODE_ENCRYPT,
+				DecryptionMode:     scsi.DECRYPTION_MODE_MIXED,
+				AlgorithmIndex:     1,
+				KeyInstanceCounter: 3,
+				KeyID:              "backup-2026",
+			},
+		},
+		{
+			name: "disabled",
+			resp: statusPage(scsi.ENCRYPTION_MODE_DISABLE, scsi.DECRYPTION_MODE_DISABLE, 0, 0x01020304),
+			want: scsi.EncryptionStatus{KeyInstanceCounter: 0x01020304},
+		},
+		{
+			// Only the unauthenticated key-associated data identifies the key
+			name: "other key-associated data ignored",
+			resp: statusPage(scsi.ENCRYPTION_MODE_ENCRYPT, scsi.DECRYPTION_MODE_DECRYPT, 1, 1,
+				keyAssociatedData(0x01, "authenticated"),
+				keyAssociatedData(scsi.KAD_TYPE_UNAUTHENTICATED, "key"),
+			),
+			want: scsi.EncryptionStatus{
+				EncryptionMode:     scsi.ENCRYPTION_MODE_ENCRYPT,
+				DecryptionMode:     scsi.DECRYPTION_MODE_DECRYPT,
+				AlgorithmIndex:     1,
+				KeyInstanceCounter: 1,
+				KeyID:              "key",
+			},
+		},
+		{
+			name: "truncated key-associated data",
+			resp: statusPage(scsi.ENCRYPTION_MODE_ENCRYPT, scsi.DECRYPTION_MODE_DECRYPT, 1, 1,
+				keyAssociatedData(scsi.KAD_TYPE_UNAUTHENTICATED, "backup-2026"),
+			)[:32],
+			want: scsi.EncryptionStatus{
+				EncryptionMode:     scsi.ENCRYPTION_MODE_ENCRYPT,
+				DecryptionMode:     scsi.DECRYPTION_MODE_DECRYPT,
+				AlgorithmIndex:     1,
+				KeyInstanceCounter: 1,
+			},
+		},
+		{
+			name:    "too short",
+			resp:    statusPage(0, 0, 0, 0)[:12],
+			wantErr: "too short data encryption status page: 12 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := scsitest.New()
+			dev.Reply(scsi.SECURITY_PROTOCOL_IN, tt.resp, nil)
+
+			status, err := scsi.NewDevice(dev).EncryptionStatus()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EncryptionStatus: %v", err)
+			}
+			if *status != tt.want {
+				t.Errorf("got %+v, want %+v", *status, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecurityProtocolInCDB(t *testing.T) {
+	tests := []struct {
+		name string
+		read func(*scsi.SCSIDevice) error
+		want []byte
+	}{
+		{
+			name: "capabilities",
+			read: func(dev *scsi.SCSIDevice) error {
+				_, err := dev.EncryptionAlgorithms()
+				return err
+			},
+			want: []byte{0xA2, 0x20, 0x00, 0x10, 0x00, 0x00, 0x00, 0x00, 0x20, 0x00, 0x00, 0x00},
+		},
+		{
+			name: "status",
+			read: func(dev *scsi.SCSIDevice) error {
+				_, err := dev.EncryptionStatus()
+				return err
+			},
+			want: []byte{0xA2, 0x20, 0x00, 0x20, 0x00, 0x00, 0x00, 0x00, 0x20, 0x00, 0x00, 0x00},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := scsitest.New()
+			dev.Handle(scsi.SECURITY_PROTOCOL_IN, func(cdb []byte, todev []byte) ([]byte, error) {
+				return statusPage(0, 0, 0, 0), nil
+			})
+
+			err := tt.read(scsi.NewDevice(dev))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			requests := dev.Requests()
+			if len(requests) != 1 || !bytes.Equal(requests[0].CDB, tt.want) {
+				t.Errorf("got requests %+v, want CDB % x", requests, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetEncryption(t *testing.T) {
+	key := bytes.Repeat([]byte{0x5A}, 32)
+
+	tests := []struct {
+		name     string
+		settings scsi.EncryptionSettings
+		wantCDB  []byte
+		wantData []byte
+		wantErr  string
+	}{
+		{
+			name: "key with key ID",
+			settings: scsi.EncryptionSettings{
+				EncryptionMode: scsi.ENCRYPTION_MODE_ENCRYPT,
+				DecryptionMode: scsi.DECRYPTION_MODE_MIXED,
+				AlgorithmIndex: 1,
+				Key:            key,
+				KeyID:          "backup",
+			},
+			wantCDB: []byte{0xB5, 0x20, 0x00, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x3E, 0x00, 0x00},
+			wantData: append(append([]byte{
+				0x00, 0x10, 0x00, 0x3A,
+				0x40, 0x04, 0x02, 0x03, 0x01, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x20,
+			}, key...),
+				0x00, 0x00, 0x00, 0x06, 'b', 'a', 'c', 'k', 'u', 'p',
+			),
+		},
+		{
+			name: "key without key ID",
+			settings: scsi.EncryptionSettings{
+				EncryptionMode: scsi.ENCRYPTION_MODE_ENCRYPT,
+				DecryptionMode: scsi.DECRYPTION_MODE_DECRYPT,
+				AlgorithmIndex: 1,
+				Key:            key,
+			},
+			wantCDB: []byte{0xB5, 0x20, 0x00, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x34, 0x00, 0x00},
+			wantData: append([]byte{
+				0x00, 0x10, 0x00, 0x30,
+				0x40, 0x04, 0x02, 0x02, 0x01, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x20,
+			}, key...),
+		},
+		{
+			// Without a key, the key is not cleared on demount either
+			name: "disable",
+			settings: scsi.EncryptionSettings{
+				EncryptionMode: scsi.ENCRYPTION_MODE_DISABLE,
+				DecryptionMode: scsi.DECRYPTION_MODE_DISABLE,
+			},
+			wantCDB: []byte{0xB5, 0x20, 0x00, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x14, 0x00, 0x00},
+			wantData: []byte{
+				0x00, 0x10, 0x00, 0x10,
+				0x40, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00,
+			},
+		},
+		{
+			name: "key ID too long",
+			settings: scsi.EncryptionSettings{
+				EncryptionMode: scsi.ENCRYPTION_MODE_ENCRYPT,
+				DecryptionMode: scsi.DECRYPTION_MODE_DECRYPT,
+				Key:            key,
+				KeyID:          strings.Repeat("k", scsi.KAD_MAX_UNAUTHENTICATED_LENGTH+1),
+			},
+			wantErr: "is longer than 32 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := scsitest.New()
+			dev.Reply(scsi.SECURITY_PROTOCOL_OUT, nil, nil)
+
+			err := scsi.NewDevice(dev).SetEncryption(&tt.settings)
+			requests := dev.Requests()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				if len(requests) != 0 {
+					t.Errorf("sent %d requests for invalid settings", len(requests))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetEncryption: %v", err)
+			}
+			if len(requests) != 1 {
+				t.Fatalf("got %d requests, want 1", len(requests))
+			}
+			if !bytes.Equal(requests[0].CDB, tt.wantCDB) {
+				t.Errorf("got CDB % x, want % x", requests[0].CDB, tt.wantCDB)
+			}
+			if !bytes.Equal(requests[0].Data, tt.wantData) {
+				t.Errorf("got data % x, want % x", requests[0].Data, tt.wantData)
+			}
+		})
+	}
+}
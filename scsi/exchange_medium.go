@@ -0,0 +1,24 @@
+package scsi
+
+import (
+	"time"
+)
+
+const EXCHANGE_MEDIUM = 0xA6
+
+// ExchangeMedium moves the medium in the source element to the first destination,
+// and the medium that was in the first destination to the second destination.
+// Swapping two tapes passes the source address as the second destination.
+func (d *SCSIDevice) ExchangeMedium(sourceAddress uint16, firstDestAddress uint16, secondDestAddress uint16) error {
+	_, err := d.requestWithTimeout([]byte{
+		EXCHANGE_MEDIUM,
+		0x00,
+		0x00, 0x00, // Transport element address, auto-selected like for MOVE MEDIUM
+		uint8(sourceAddress >> 8), uint8(sourceAddress & 0xFF),
+		uint8(firstDestAddress >> 8), uint8(firstDestAddress & 0xFF),
+		uint8(secondDestAddress >> 8), uint8(secondDestAddress & 0xFF),
+		0x00, // Invert flags, not supported
+		0x00,
+	}, 0, time.Minute*10)
+	return err
+}
@@ -0,0 +1,79 @@
+package scsi
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+)
+
+type DeviceIdentification struct {
+	Serial      string
+	Designators []element.Designator
+}
+
+// Identification returns the unit serial number (VPD page 0x80) and the
+// device identification designators (VPD page 0x83)
+func (d *SCSIDevice) Identification() (*DeviceIdentification, error) {
+	serial, err := d.SerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	ident := &DeviceIdentification{
+		Serial: serial,
+	}
+
+	identifiers, err := d.Identifiers()
+	if err != nil {
+		// Page 0x83 is mandatory, but matching by serial alone still works without it
+		return ident, nil
+	}
+
+	for _, identifier := range identifiers {
+		ident.Designators = append(ident.Designators, identifier.Designator)
+	}
+
+	return ident, nil
+}
+
+// Matches reports whether a designator reported by a media changer refers to this device
+func (i *DeviceIdentification) Matches(designator element.Designator) bool {
+	if len(designator.Value) == 0 {
+		return false
+	}
+
+	for _, own := range i.Designators {
+		if own.Type == designator.Type && bytes.Equal(bytes.Trim(own.Value, "\x00 "), bytes.Trim(designator.Value, "\x00 ")) {
+			return true
+		}
+	}
+
+	if i.Serial == "" || !designator.CodeSet.IsText() {
+		return false
+	}
+
+	switch designator.Type {
+	case element.IDENTIFIER_TYPE_T10:
+		_, _, serial, ok := designator.SplitT10()
+		return ok && serial == i.Serial
+	case element.IDENTIFIER_TYPE_VENDOR:
+		text := designator.Text()
+		return text == i.Serial || bytes.HasSuffix([]byte(text), []byte(" "+i.Serial))
+	default:
+		return false
+	}
+}
+
+func (i *DeviceIdentification) String() string {
+	if len(i.Designators) == 0 {
+		return fmt.Sprintf("with serial %q", i.Serial)
+	}
+
+	designators := make([]string, 0, len(i.Designators))
+	for _, designator := range i.Designators {
+		designators = append(designators, designator.String())
+	}
+	return fmt.Sprintf("with serial %q (%s)", i.Serial, strings.Join(designators, "; "))
+}
@@ -0,0 +1,65 @@
+package scsi
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// InquiryData holds the standard INQUIRY fields used to tell devices apart
+type InquiryData struct {
+	DeviceType uint8  `json:"device-type"`
+	Removable  bool   `json:"removable"`
+	Version    uint8  `json:"version"`
+	Vendor     string `json:"vendor"`
+	Product    string `json:"product"`
+	Revision   string `json:"revision"`
+}
+
+const (
+	INQUIRY = 0x12
+
+	INQUIRY_FLAG_EVPD = 0
+	// Bit of the second byte of the standard INQUIRY data
+	INQUIRY_FLAG_RMB = 7
+
+	INQUIRY_STANDARD_LENGTH = 36
+	INQUIRY_MAX_LENGTH      = 0xFF
+
+	DEVICE_TYPE_SEQUENTIAL_ACCESS = 0x01
+	DEVICE_TYPE_MEDIUM_CHANGER    = 0x08
+)
+
+func (d *SCSIDevice) inquiry(evpd bool, page uint8, length uint16) ([]byte, error) {
+	return d.request([]byte{
+		INQUIRY,
+		boolToFlag(evpd, INQUIRY_FLAG_EVPD),
+		page,
+		uint8(length >> 8),
+		uint8(length),
+		0x00,
+	}, int(length))
+}
+
+func (d *SCSIDevice) Inquiry() (*InquiryData, error) {
+	resp, err := d.inquiry(false, 0x00, INQUIRY_MAX_LENGTH)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseInquiry(resp)
+}
+
+func parseInquiry(data []byte) (*InquiryData, error) {
+	if len(data) < INQUIRY_STANDARD_LENGTH {
+		return nil, fmt.Errorf("too short standard inquiry data: %d bytes", len(data))
+	}
+
+	return &InquiryData{
+		DeviceType: data[0] & 0x1F,
+		Removable:  flagToBool(data[1], INQUIRY_FLAG_RMB),
+		Version:    data[2],
+		Vendor:     string(bytes.TrimRight(data[8:16], "\x00 ")),
+		Product:    string(bytes.TrimRight(data[16:32], "\x00 ")),
+		Revision:   string(bytes.TrimRight(data[32:36], "\x00 ")),
+	}, nil
+}
@@ -0,0 +1,97 @@
+package scsi_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/scsitest"
+)
+
+// standardInquiry encodes standard INQUIRY data with space padded identification fields
+func standardInquiry(deviceType uint8, removable bool, vendor string, product string, revision string) []byte {
+	rmb := uint8(0x00)
+	if removable {
+		rmb = 0x80
+	}
+	data := []byte{deviceType, rmb, 0x06, 0x02, 0x1F, 0x00, 0x00, 0x00}
+	return fmt.Appendf(data, "%-8s%-16s%-4s", vendor, product, revision)
+}
+
+func TestInquiry(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    []byte
+		want    scsi.InquiryData
+		wantErr string
+	}{
+		{
+			name: "tape drive",
+			resp: standardInquiry(scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS, true, "IBM", "ULTRIUM-HH8", "N9M1"),
+			want: scsi.InquiryData{
+				DeviceType: scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS,
+				Removable:  true,
+				Version:    0x06,
+				Vendor:     "IBM",
+				Product:    "ULTRIUM-HH8",
+				Revision:   "N9M1",
+			},
+		},
+		{
+			// The peripheral qualifier is not part of the device type
+			name: "medium changer with qualifier",
+			resp: standardInquiry(0x20|scsi.DEVICE_TYPE_MEDIUM_CHANGER, false, "IBM", "3573-TL", "E.10"),
+			want: scsi.InquiryData{
+				DeviceType: scsi.DEVICE_TYPE_MEDIUM_CHANGER,
+				Version:    0x06,
+				Vendor:     "IBM",
+				Product:    "3573-TL",
+				Revision:   "E.10",
+			},
+		},
+		{
+			name: "NUL padded with vendor specific data",
+			resp: []byte("\x01\x80\x06\x02\x1f\x00\x00\x00HP\x00\x00\x00\x00\x00\x00" + strings.Repeat("\x00", 20) + "vendor specific"),
+			want: scsi.InquiryData{
+				DeviceType: scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS,
+				Removable:  true,
+				Version:    0x06,
+				Vendor:     "HP",
+			},
+		},
+		{
+			name:    "too short",
+			resp:    standardInquiry(scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS, true, "IBM", "ULTRIUM-HH8", "N9M1")[:32],
+			wantErr: "too short standard inquiry data: 32 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := scsitest.New()
+			dev.Reply(scsi.INQUIRY, tt.resp, nil)
+
+			inquiry, err := scsi.NewDevice(dev).Inquiry()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Inquiry: %v", err)
+			}
+			if *inquiry != tt.want {
+				t.Errorf("got %+v, want %+v", *inquiry, tt.want)
+			}
+
+			want := []byte{scsi.INQUIRY, 0x00, 0x00, 0x00, 0xFF, 0x00}
+			requests := dev.Requests()
+			if len(requests) != 1 || !bytes.Equal(requests[0].CDB, want) {
+				t.Errorf("got requests %+v, want CDB % x", requests, want)
+			}
+		})
+	}
+}
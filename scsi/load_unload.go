@@ -0,0 +1,21 @@
+package scsi
+
+import (
+	"time"
+
+	scsidefs "github.com/FoxDenHome/goscsi/godefs/scsi"
+)
+
+// Unloading rewinds the tape first, which takes minutes from the end of a full tape
+const UNLOAD_TIMEOUT = 10 * time.Minute
+
+// Unload rewinds the tape and ejects the cartridge from the drive
+func (d *SCSIDevice) Unload() error {
+	_, err := d.requestWithTimeout([]byte{
+		scsidefs.START_STOP, // LOAD UNLOAD on tape drives
+		0x00, 0x00, 0x00,
+		0x00, // LOAD bit cleared
+		0x00,
+	}, 0, UNLOAD_TIMEOUT)
+	return err
+}
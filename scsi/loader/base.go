@@ -1,13 +1,38 @@
 package loader
 
+import (
+	"sync"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+)
+
 const LOADER_MAX_ELEMENTS = 255
 
 type TapeLoader struct {
 	DevicePath string
+
+	// VolumeTagSource selects whether the primary or alternate volume tag identifies tapes
+	VolumeTagSource element.VolumeTagSource
+
+	open scsi.Opener
+
+	geometryLock      sync.Mutex
+	addressAssignment scsi.ElementAddressAssignment
 }
 
 func NewTapeLoader(devicePath string) (*TapeLoader, error) {
+	return NewTapeLoaderWithOpener(devicePath, scsi.Open)
+}
+
+// NewTapeLoaderWithOpener allows substituting the SCSI transport, for example with scsitest
+func NewTapeLoaderWithOpener(devicePath string, opener scsi.Opener) (*TapeLoader, error) {
 	return &TapeLoader{
 		DevicePath: devicePath,
+		open:       opener,
 	}, nil
 }
+
+func (l *TapeLoader) openDevice() (*scsi.SCSIDevice, error) {
+	return l.open(l.DevicePath)
+}
@@ -0,0 +1,21 @@
+package loader
+
+import (
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+)
+
+// Changer is implemented by TapeLoader and by the simulated library in scsi/sim
+type Changer interface {
+	DeviceInfo() (*scsi.DeviceInfo, error)
+	DriveAddress(ident *scsi.DeviceIdentification) (uint16, error)
+	MoveTapeToDrive(driveAddress uint16, volumeTag string) error
+	MoveDriveTapeToStorage(driveAddress uint16) error
+	MoveMedium(sourceAddress uint16, destAddress uint16) error
+	ExchangeMedium(firstAddress uint16, secondAddress uint16) error
+	GetVolumeTags() ([]string, error)
+	GetElements() ([]*element.Descriptor, error)
+	ExportTapes(volumeTags ...string) ([]Move, error)
+	ImportTapes() ([]Move, error)
+	PreventMediumRemoval(prevent bool) error
+}
@@ -2,30 +2,58 @@ package loader
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/FoxDenHome/tapemgr/scsi"
 	"github.com/FoxDenHome/tapemgr/scsi/element"
 )
 
-func (l *TapeLoader) DriveAddressBySerial(serial string) (uint16, error) {
-	dev, err := scsi.Open(l.DevicePath)
+// DriveAddress finds the data transfer element of the drive identified by ident
+func (l *TapeLoader) DriveAddress(ident *scsi.DeviceIdentification) (uint16, error) {
+	elements, err := l.DriveElements()
 	if err != nil {
 		return 0, err
 	}
+
+	var matches []*element.Descriptor
+	for _, elem := range elements {
+		if ident.Matches(elem.Designator()) {
+			matches = append(matches, elem)
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return matches[0].Address, nil
+	case 0:
+		return 0, fmt.Errorf("no drive in loader matches drive %s\n%s", ident, describeDrives(elements))
+	default:
+		return 0, fmt.Errorf("%d drives in loader match drive %s, set drive-element-address\n%s", len(matches), ident, describeDrives(matches))
+	}
+}
+
+// DriveElements returns the data transfer elements along with the identifiers of the drives in them
+func (l *TapeLoader) DriveElements() ([]*element.Descriptor, error) {
+	dev, err := l.openDevice()
+	if err != nil {
+		return nil, err
+	}
 	defer func() {
 		_ = dev.Close()
 	}()
 
-	elements, err := dev.ReadElementStatus(element.ELEMENT_TYPE_DATA_TRANSFER, 0, LOADER_MAX_ELEMENTS, true, false, true)
-	if err != nil {
-		return 0, err
+	return l.readElements(dev, element.ELEMENT_TYPE_DATA_TRANSFER, false, true)
+}
+
+func describeDrives(elements []*element.Descriptor) string {
+	if len(elements) == 0 {
+		return "loader reports no drives"
 	}
 
+	lines := make([]string, 0, len(elements)+1)
+	lines = append(lines, "loader reports these drives:")
 	for _, elem := range elements {
-		if serial == elem.Identifier[24:] {
-			return elem.Address, nil
-		}
+		lines = append(lines, fmt.Sprintf("  address %d: %s", elem.Address, elem.Designator()))
 	}
-
-	return 0, fmt.Errorf("no drive with serial %s found in loader", serial)
+	return strings.Join(lines, "\n")
 }
@@ -0,0 +1,89 @@
+package loader
+
+import (
+	"errors"
+	"log"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+)
+
+var elementTypesInOrder = []element.Type{
+	element.ELEMENT_TYPE_MEDIUM_TRANSPORT,
+	element.ELEMENT_TYPE_STORAGE,
+	element.ELEMENT_TYPE_IMPORT_EXPORT,
+	element.ELEMENT_TYPE_DATA_TRANSFER,
+}
+
+// geometry returns the element address assignment of the library, cached after the first successful query
+// Libraries not supporting the mode page get a single range of 0..LOADER_MAX_ELEMENTS per type,
+// other MODE SENSE failures (e.g. a library still initializing) use that range only until the next call
+func (l *TapeLoader) geometry(dev *scsi.SCSIDevice) scsi.ElementAddressAssignment {
+	l.geometryLock.Lock()
+	defer l.geometryLock.Unlock()
+
+	if l.addressAssignment != nil {
+		return l.addressAssignment
+	}
+
+	assignment, err := dev.ElementAddressAssignment()
+	if err == nil {
+		l.addressAssignment = assignment
+		return assignment
+	}
+
+	log.Printf("Failed to read element address assignment, falling back to addresses 0..%d: %v", LOADER_MAX_ELEMENTS, err)
+	assignment = make(scsi.ElementAddressAssignment)
+	for _, elementType := range elementTypesInOrder {
+		assignment[elementType] = scsi.ElementRange{First: 0, Count: LOADER_MAX_ELEMENTS}
+	}
+	if errors.Is(err, scsi.ErrIllegalRequest) {
+		l.addressAssignment = assignment
+	}
+	return assignment
+}
+
+// readElements reads the status of every element of the given type (or all types),
+// split into as many READ ELEMENT STATUS requests as the response size requires
+func (l *TapeLoader) readElements(dev *scsi.SCSIDevice, elementType element.Type, readVolumeTag bool, readDeviceId bool) ([]*element.Descriptor, error) {
+	assignment := l.geometry(dev)
+
+	types := []element.Type{elementType}
+	if elementType == element.ELEMENT_TYPE_ALL {
+		types = elementTypesInOrder
+	}
+
+	maxCount := scsi.MaxElementsPerRequest(readVolumeTag, readDeviceId)
+
+	var elements []*element.Descriptor
+	for _, t := range types {
+		elemRange := assignment[t]
+
+		remaining := uint32(elemRange.Count)
+		start := uint32(elemRange.First)
+		for remaining > 0 {
+			count := min(remaining, uint32(maxCount))
+
+			chunk, err := dev.ReadElementStatus(t, uint16(start), uint16(count), true, readVolumeTag, readDeviceId)
+			if err != nil {
+				return nil, err
+			}
+			for _, elem := range chunk {
+				l.selectVolumeTag(elem)
+			}
+			elements = append(elements, chunk...)
+
+			start += count
+			remaining -= count
+		}
+	}
+
+	return elements, nil
+}
+
+func (l *TapeLoader) selectVolumeTag(elem *element.Descriptor) {
+	elem.SelectVolumeTag(l.VolumeTagSource)
+	if elem.VolumeTag == "" && elem.HasFlag(element.FLAG_FULL) && elem.PrimaryVolumeTag != "" {
+		log.Printf("Tape %s in %s element %d has no %s volume tag and will be ignored", elem.PrimaryVolumeTag, elem.ElementType, elem.Address, l.VolumeTagSource)
+	}
+}
@@ -0,0 +1,232 @@
+package loader_test
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+	"github.com/FoxDenHome/tapemgr/scsi/loader"
+	"github.com/FoxDenHome/tapemgr/scsi/scsitest"
+)
+
+const (
+	OPCODE_MODE_SENSE          = 0x1A
+	OPCODE_READ_ELEMENT_STATUS = 0xB8
+)
+
+// storageStarts returns the starting addresses of the READ ELEMENT STATUS requests for storage elements
+func storageStarts(requests []scsitest.Request) []uint16 {
+	var starts []uint16
+	for _, req := range requests {
+		if req.CDB[0] == OPCODE_READ_ELEMENT_STATUS && req.CDB[1]&0x0F == 2 {
+			starts = append(starts, binary.BigEndian.Uint16(req.CDB[2:4]))
+		}
+	}
+	return starts
+}
+
+type elementRequest struct {
+	elementType element.Type
+	start       uint16
+	count       uint16
+}
+
+func elementRequests(requests []scsitest.Request) []elementRequest {
+	var reads []elementRequest
+	for _, req := range requests {
+		if req.CDB[0] == OPCODE_READ_ELEMENT_STATUS {
+			reads = append(reads, elementRequest{
+				elementType: element.Type(req.CDB[1] & 0x0F),
+				start:       binary.BigEndian.Uint16(req.CDB[2:4]),
+				count:       binary.BigEndian.Uint16(req.CDB[4:6]),
+			})
+		}
+	}
+	return reads
+}
+
+// addressAssignmentPage builds a MODE SENSE(6) response with an element address assignment page
+func addressAssignmentPage(transport, storage, importExport, dataTransfer scsi.ElementRange) []byte {
+	resp := []byte{0x17, 0x00, 0x00, 0x00, 0x1D, 0x12}
+	for _, r := range []scsi.ElementRange{transport, storage, importExport, dataTransfer} {
+		resp = binary.BigEndian.AppendUint16(resp, r.First)
+		resp = binary.BigEndian.AppendUint16(resp, r.Count)
+	}
+	return append(resp, 0x00, 0x00)
+}
+
+func countOpcode(requests []scsitest.Request, opcode uint8) int {
+	count := 0
+	for _, req := range requests {
+		if req.CDB[0] == opcode {
+			count++
+		}
+	}
+	return count
+}
+
+func TestGeometryRetriesAfterFailure(t *testing.T) {
+	dev := scsitest.New()
+	dev.Handle(OPCODE_READ_ELEMENT_STATUS, func(cdb []byte, todev []byte) ([]byte, error) {
+		return make([]byte, 8), nil
+	})
+	// Not ready, initializing command required, as reported by libraries still taking inventory
+	dev.Reply(OPCODE_MODE_SENSE, nil, scsitest.CheckCondition(OPCODE_MODE_SENSE, scsi.SENSE_KEY_NOT_READY, 0x04, 0x02))
+	dev.Handle(OPCODE_MODE_SENSE, func(cdb []byte, todev []byte) ([]byte, error) {
+		return scsitest.IBM_TS4300_ELEMENT_ADDRESS_ASSIGNMENT, nil
+	})
+
+	l, err := loader.NewTapeLoaderWithOpener("test", dev.Open())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		_, err = l.GetElements()
+		if err != nil {
+			t.Fatalf("GetElements: %v", err)
+		}
+	}
+
+	requests := dev.Requests()
+	if count := countOpcode(requests, OPCODE_MODE_SENSE); count != 2 {
+		t.Errorf("sent %d MODE SENSE commands, want 2 (a failed one and the one cached)", count)
+	}
+	starts := storageStarts(requests)
+	want := []uint16{0, 0x1000, 0x1000}
+	if len(starts) != len(want) {
+		t.Fatalf("storage element requests start at %v, want %v", starts, want)
+	}
+	for i := range want {
+		if starts[i] != want[i] {
+			t.Errorf("storage element requests start at %v, want %v", starts, want)
+			break
+		}
+	}
+}
+
+func TestGeometryCachesUnsupportedPage(t *testing.T) {
+	dev := scsitest.New()
+	dev.Handle(OPCODE_READ_ELEMENT_STATUS, func(cdb []byte, todev []byte) ([]byte, error) {
+		return make([]byte, 8), nil
+	})
+	// Invalid field in CDB, the library has no element address assignment page
+	dev.Handle(OPCODE_MODE_SENSE, func(cdb []byte, todev []byte) ([]byte, error) {
+		return nil, scsitest.CheckCondition(OPCODE_MODE_SENSE, scsi.SENSE_KEY_ILLEGAL_REQUEST, 0x24, 0x00)
+	})
+
+	l, err := loader.NewTapeLoaderWithOpener("test", dev.Open())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		_, err = l.GetElements()
+		if err != nil {
+			t.Fatalf("GetElements: %v", err)
+		}
+	}
+
+	if count := countOpcode(dev.Requests(), OPCODE_MODE_SENSE); count != 1 {
+		t.Errorf("sent %d MODE SENSE commands, want 1", count)
+	}
+}
+
+func TestGeometryDrivesElementRanges(t *testing.T) {
+	maxCount := scsi.MaxElementsPerRequest(true, false)
+	storageCount := 2*maxCount + 10
+
+	dev := scsitest.New()
+	dev.Handle(OPCODE_READ_ELEMENT_STATUS, func(cdb []byte, todev []byte) ([]byte, error) {
+		return make([]byte, 8), nil
+	})
+	dev.Handle(OPCODE_MODE_SENSE, func(cdb []byte, todev []byte) ([]byte, error) {
+		return addressAssignmentPage(
+			scsi.ElementRange{First: 0x0001, Count: 1},
+			scsi.ElementRange{First: 0x1000, Count: storageCount},
+			scsi.ElementRange{First: 0x0300, Count: 0},
+			scsi.ElementRange{First: 0x0100, Count: 4},
+		), nil
+	})
+
+	l, err := loader.NewTapeLoaderWithOpener("test", dev.Open())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = l.GetElements()
+	if err != nil {
+		t.Fatalf("GetElements: %v", err)
+	}
+
+	// The empty I/E range is skipped, the storage range is split into requests of at most maxCount elements
+	want := []elementRequest{
+		{element.ELEMENT_TYPE_MEDIUM_TRANSPORT, 0x0001, 1},
+		{element.ELEMENT_TYPE_STORAGE, 0x1000, maxCount},
+		{element.ELEMENT_TYPE_STORAGE, 0x1000 + maxCount, maxCount},
+		{element.ELEMENT_TYPE_STORAGE, 0x1000 + 2*maxCount, 10},
+		{element.ELEMENT_TYPE_DATA_TRANSFER, 0x0100, 4},
+	}
+	got := elementRequests(dev.Requests())
+	if !slices.Equal(got, want) {
+		t.Errorf("READ ELEMENT STATUS requests %v, want %v", got, want)
+	}
+}
+
+func TestGeometryFallback(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    scsi.SenseKey
+		asc    uint8
+		ascq   uint8
+		cached bool
+	}{
+		{"invalid field in CDB", scsi.SENSE_KEY_ILLEGAL_REQUEST, 0x24, 0x00, true},
+		{"invalid command", scsi.SENSE_KEY_ILLEGAL_REQUEST, 0x20, 0x00, true},
+		{"becoming ready", scsi.SENSE_KEY_NOT_READY, 0x04, 0x01, false},
+		{"unit attention", scsi.SENSE_KEY_UNIT_ATTENTION, 0x28, 0x00, false},
+		{"hardware error", scsi.SENSE_KEY_HARDWARE_ERROR, 0x44, 0x00, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := scsitest.New()
+			dev.Handle(OPCODE_READ_ELEMENT_STATUS, func(cdb []byte, todev []byte) ([]byte, error) {
+				return make([]byte, 8), nil
+			})
+			dev.Handle(OPCODE_MODE_SENSE, func(cdb []byte, todev []byte) ([]byte, error) {
+				return nil, scsitest.CheckCondition(OPCODE_MODE_SENSE, tt.key, tt.asc, tt.ascq)
+			})
+
+			l, err := loader.NewTapeLoaderWithOpener("test", dev.Open())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range 2 {
+				_, err = l.GetElements()
+				if err != nil {
+					t.Fatalf("GetElements: %v", err)
+				}
+			}
+
+			requests := dev.Requests()
+			wantModeSense := 2
+			if tt.cached {
+				wantModeSense = 1
+			}
+			if count := countOpcode(requests, OPCODE_MODE_SENSE); count != wantModeSense {
+				t.Errorf("sent %d MODE SENSE commands, want %d", count, wantModeSense)
+			}
+
+			// Every element type is read from 0..LOADER_MAX_ELEMENTS
+			reads := elementRequests(requests)
+			if len(reads) != 2*element.ElementTypes {
+				t.Errorf("sent %d READ ELEMENT STATUS commands, want one per element type and call", len(reads))
+			}
+			for _, req := range reads {
+				if req.start != 0 || req.count != loader.LOADER_MAX_ELEMENTS {
+					t.Errorf("fallback request %v, want elements 0..%d", req, loader.LOADER_MAX_ELEMENTS)
+				}
+			}
+		})
+	}
+}
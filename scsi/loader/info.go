@@ -0,0 +1,16 @@
+package loader
+
+import "github.com/FoxDenHome/tapemgr/scsi"
+
+// DeviceInfo reads the INQUIRY data and VPD pages of the library
+func (l *TapeLoader) DeviceInfo() (*scsi.DeviceInfo, error) {
+	dev, err := l.openDevice()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	return dev.DeviceInfo()
+}
@@ -0,0 +1,14 @@
+package loader
+
+// PreventMediumRemoval locks (or unlocks) the magazines and mail slots of the library
+func (l *TapeLoader) PreventMediumRemoval(prevent bool) error {
+	dev, err := l.openDevice()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	return dev.PreventMediumRemoval(prevent)
+}
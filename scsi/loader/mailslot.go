@@ -0,0 +1,141 @@
+package loader
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+)
+
+type Move struct {
+	VolumeTag   string
+	Source      uint16
+	Destination uint16
+}
+
+func isAccessible(elem *element.Descriptor) bool {
+	return elem.HasFlag(element.FLAG_ACCESS) && !elem.HasFlag(element.FLAG_EXCEPTION)
+}
+
+// ExportTapes moves the given tapes into free import/export elements (mailslots)
+// Tapes in a drive are refused, they have to be unmounted and unloaded first
+// It stops at the first failure, returning the moves done so far
+func (l *TapeLoader) ExportTapes(volumeTags ...string) ([]Move, error) {
+	dev, err := l.openDevice()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	elements, err := l.readElements(dev, element.ELEMENT_TYPE_ALL, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var freeMailslots []*element.Descriptor
+	sources := make(map[string]*element.Descriptor)
+	for _, elem := range elements {
+		switch {
+		case elem.ElementType == element.ELEMENT_TYPE_IMPORT_EXPORT:
+			if !elem.HasFlag(element.FLAG_FULL) && elem.HasFlag(element.FLAG_EXPORT_ENABLED) && isAccessible(elem) {
+				freeMailslots = append(freeMailslots, elem)
+			}
+		case elem.HasFlag(element.FLAG_FULL):
+			sources[elem.VolumeTag] = elem
+		}
+	}
+
+	var moves []Move
+	for _, volumeTag := range volumeTags {
+		source := sources[volumeTag]
+		if source == nil {
+			return moves, fmt.Errorf("no tape found with volume tag %s outside of the mailslots", volumeTag)
+		}
+		if !isAccessible(source) {
+			return moves, fmt.Errorf("tape %s in %s element %d is not accessible (ASC %#02x, ASCQ %#02x)", volumeTag, source.ElementType, source.Address, source.ExceptionSenseCode, source.ExceptionSenseCodeQualifier)
+		}
+		if source.ElementType == element.ELEMENT_TYPE_DATA_TRANSFER {
+			return moves, fmt.Errorf("tape %s is in drive %d, unload it before exporting", volumeTag, source.Address)
+		}
+		if len(freeMailslots) == 0 {
+			return moves, fmt.Errorf("no free export-enabled mailslot left for tape %s", volumeTag)
+		}
+
+		dest := freeMailslots[0]
+		log.Printf("Exporting tape %s from %s element %d to mailslot %d", volumeTag, source.ElementType, source.Address, dest.Address)
+		err = moveMedium(dev, source.Address, dest.Address)
+		if err != nil {
+			return moves, err
+		}
+
+		freeMailslots = freeMailslots[1:]
+		moves = append(moves, Move{
+			VolumeTag:   volumeTag,
+			Source:      source.Address,
+			Destination: dest.Address,
+		})
+	}
+
+	return moves, nil
+}
+
+// ImportTapes moves every tape in an import-enabled mailslot into a free storage slot
+func (l *TapeLoader) ImportTapes() ([]Move, error) {
+	dev, err := l.openDevice()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	elements, err := l.readElements(dev, element.ELEMENT_TYPE_ALL, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var mailslots []*element.Descriptor
+	var freeSlots []*element.Descriptor
+	for _, elem := range elements {
+		switch elem.ElementType {
+		case element.ELEMENT_TYPE_IMPORT_EXPORT:
+			if !elem.HasFlag(element.FLAG_FULL) {
+				continue
+			}
+			if !elem.HasFlag(element.FLAG_IMPORT_ENABLED) || !isAccessible(elem) {
+				log.Printf("Skipping tape %s in mailslot %d, it is not accessible for import", elem.VolumeTag, elem.Address)
+				continue
+			}
+			mailslots = append(mailslots, elem)
+		case element.ELEMENT_TYPE_STORAGE:
+			if !elem.HasFlag(element.FLAG_FULL) && isAccessible(elem) {
+				freeSlots = append(freeSlots, elem)
+			}
+		}
+	}
+
+	var moves []Move
+	for _, source := range mailslots {
+		if len(freeSlots) == 0 {
+			return moves, fmt.Errorf("no free storage slot left for tape %s in mailslot %d", source.VolumeTag, source.Address)
+		}
+
+		dest := freeSlots[0]
+		log.Printf("Importing tape %s from mailslot %d to slot %d", source.VolumeTag, source.Address, dest.Address)
+		err = moveMedium(dev, source.Address, dest.Address)
+		if err != nil {
+			return moves, err
+		}
+
+		freeSlots = freeSlots[1:]
+		moves = append(moves, Move{
+			VolumeTag:   source.VolumeTag,
+			Source:      source.Address,
+			Destination: dest.Address,
+		})
+	}
+
+	return moves, nil
+}
@@ -1,6 +1,7 @@
 package loader
 
 import (
+	"errors"
 	"fmt"
 	"log"
 
@@ -9,7 +10,7 @@ import (
 )
 
 func (l *TapeLoader) MoveTapeToDrive(driveAddress uint16, volumeTag string) error {
-	dev, err := scsi.Open(l.DevicePath)
+	dev, err := l.openDevice()
 	if err != nil {
 		return err
 	}
@@ -17,7 +18,7 @@ func (l *TapeLoader) MoveTapeToDrive(driveAddress uint16, volumeTag string) erro
 		_ = dev.Close()
 	}()
 
-	elements, err := dev.ReadElementStatus(element.ELEMENT_TYPE_ALL, 0, LOADER_MAX_ELEMENTS, true, true, false)
+	elements, err := l.readElements(dev, element.ELEMENT_TYPE_ALL, true, false)
 	if err != nil {
 		return err
 	}
@@ -35,7 +36,7 @@ func (l *TapeLoader) MoveTapeToDrive(driveAddress uint16, volumeTag string) erro
 			}
 
 			log.Printf("moving tape %s from address %d to drive %d", volumeTag, elem.Address, driveAddress)
-			return dev.MoveMedium(elem.Address, driveAddress, scsi.MOVE_OPTION_NORMAL)
+			return moveMedium(dev, elem.Address, driveAddress)
 		}
 	}
 
@@ -43,7 +44,7 @@ func (l *TapeLoader) MoveTapeToDrive(driveAddress uint16, volumeTag string) erro
 }
 
 func (l *TapeLoader) MoveDriveTapeToStorage(driveAddress uint16) error {
-	dev, err := scsi.Open(l.DevicePath)
+	dev, err := l.openDevice()
 	if err != nil {
 		return err
 	}
@@ -82,11 +83,11 @@ func (l *TapeLoader) moveDriveTapeToStorage(driveAddress uint16, dev *scsi.SCSID
 
 		if len(elements) == 1 && elements[0].Address == sourceAddr && !elements[0].HasFlag(element.FLAG_FULL) {
 			log.Printf("Moving tape from drive %d back to source %d", driveAddress, sourceAddr)
-			return dev.MoveMedium(driveAddress, sourceAddr, scsi.MOVE_OPTION_NORMAL)
+			return moveMedium(dev, driveAddress, sourceAddr)
 		}
 	}
 
-	elements, err = dev.ReadElementStatus(element.ELEMENT_TYPE_STORAGE, 0, LOADER_MAX_ELEMENTS, true, false, false)
+	elements, err = l.readElements(dev, element.ELEMENT_TYPE_STORAGE, false, false)
 	if err != nil {
 		return err
 	}
@@ -96,8 +97,85 @@ func (l *TapeLoader) moveDriveTapeToStorage(driveAddress uint16, dev *scsi.SCSID
 			continue
 		}
 		log.Printf("Moving tape from drive %d to free slot %d", driveAddress, elem.Address)
-		return dev.MoveMedium(driveAddress, elem.Address, scsi.MOVE_OPTION_NORMAL)
+		return moveMedium(dev, driveAddress, elem.Address)
 	}
 
 	return fmt.Errorf("no free slot found for tape in drive %d", driveAddress)
 }
+
+// MoveMedium moves the tape in the source element to the empty destination element
+func (l *TapeLoader) MoveMedium(sourceAddress uint16, destAddress uint16) error {
+	dev, err := l.openDevice()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	return moveMedium(dev, sourceAddress, destAddress)
+}
+
+// ExchangeMedium swaps the tapes of two elements. Libraries without EXCHANGE MEDIUM
+// get three moves through a free storage slot instead.
+func (l *TapeLoader) ExchangeMedium(firstAddress uint16, secondAddress uint16) error {
+	dev, err := l.openDevice()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	err = dev.ExchangeMedium(firstAddress, secondAddress, firstAddress)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, scsi.ErrIllegalRequest) {
+		return mediumError(err, firstAddress, secondAddress)
+	}
+
+	elements, err := l.readElements(dev, element.ELEMENT_TYPE_STORAGE, false, false)
+	if err != nil {
+		return err
+	}
+	for _, elem := range elements {
+		if elem.HasFlag(element.FLAG_FULL) || !isAccessible(elem) {
+			continue
+		}
+
+		log.Printf("Library does not support EXCHANGE MEDIUM, swapping elements %d and %d through free slot %d", firstAddress, secondAddress, elem.Address)
+		for _, move := range [][2]uint16{{firstAddress, elem.Address}, {secondAddress, firstAddress}, {elem.Address, secondAddress}} {
+			err = moveMedium(dev, move[0], move[1])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("library does not support EXCHANGE MEDIUM and has no free slot to swap elements %d and %d through", firstAddress, secondAddress)
+}
+
+func moveMedium(dev *scsi.SCSIDevice, sourceAddress uint16, destAddress uint16) error {
+	err := dev.MoveMedium(sourceAddress, destAddress, scsi.MOVE_OPTION_NORMAL)
+	if err == nil {
+		return nil
+	}
+	return mediumError(err, sourceAddress, destAddress)
+}
+
+// mediumError describes a failed MOVE MEDIUM or EXCHANGE MEDIUM
+func mediumError(err error, sourceAddress uint16, destAddress uint16) error {
+	switch {
+	case errors.Is(err, scsi.ErrDoorOpen):
+		return fmt.Errorf("library door or magazine is open, cannot move %d to %d: %w", sourceAddress, destAddress, err)
+	case errors.Is(err, scsi.ErrSourceElementEmpty):
+		return fmt.Errorf("source element %d is empty: %w", sourceAddress, err)
+	case errors.Is(err, scsi.ErrDestinationElementFull):
+		return fmt.Errorf("destination element %d is full: %w", destAddress, err)
+	case errors.Is(err, scsi.ErrMediumError), errors.Is(err, scsi.ErrIncompatibleMedium):
+		return fmt.Errorf("cartridge in element %d is damaged or incompatible: %w", sourceAddress, err)
+	default:
+		return fmt.Errorf("failed to move %d to %d: %w", sourceAddress, destAddress, err)
+	}
+}
@@ -1,12 +1,12 @@
 package loader
 
 import (
-	"github.com/FoxDenHome/tapemgr/scsi"
 	"github.com/FoxDenHome/tapemgr/scsi/element"
 )
 
+// GetVolumeTags returns the volume tags of all data tapes, leaving out cleaning cartridges
 func (l *TapeLoader) GetVolumeTags() ([]string, error) {
-	dev, err := scsi.Open(l.DevicePath)
+	dev, err := l.openDevice()
 	if err != nil {
 		return nil, err
 	}
@@ -14,17 +14,29 @@ func (l *TapeLoader) GetVolumeTags() ([]string, error) {
 		_ = dev.Close()
 	}()
 
-	elements, err := dev.ReadElementStatus(element.ELEMENT_TYPE_ALL, 0, LOADER_MAX_ELEMENTS, true, true, false)
+	elements, err := l.readElements(dev, element.ELEMENT_TYPE_ALL, true, false)
 	if err != nil {
 		return nil, err
 	}
 
 	var barcodes []string
 	for _, elem := range elements {
-		if elem.HasFlag(element.FLAG_FULL) {
+		if elem.HasFlag(element.FLAG_FULL) && elem.VolumeTag != "" && !elem.IsCleaning() {
 			barcodes = append(barcodes, elem.VolumeTag)
 		}
 	}
 
 	return barcodes, nil
 }
+
+func (l *TapeLoader) GetElements() ([]*element.Descriptor, error) {
+	dev, err := l.openDevice()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	return l.readElements(dev, element.ELEMENT_TYPE_ALL, true, false)
+}
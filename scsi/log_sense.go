@@ -0,0 +1,174 @@
+package scsi
+
+import (
+	"fmt"
+
+	scsidefs "github.com/FoxDenHome/goscsi/godefs/scsi"
+)
+
+const (
+	LOG_PAGE_WRITE_ERROR_COUNTERS = 0x02
+	LOG_PAGE_READ_ERROR_COUNTERS  = 0x03
+	LOG_PAGE_VOLUME_STATISTICS    = 0x17
+	LOG_PAGE_TAPE_ALERT           = 0x2E
+
+	LOG_SENSE_MAX_LENGTH = 0xFFFF
+)
+
+type LogParameter struct {
+	Code    uint16
+	Control uint8
+	Value   []byte
+}
+
+// Uint returns the value of a binary counter parameter, which can be up to 8 bytes long
+func (p *LogParameter) Uint() uint64 {
+	var value uint64
+	for _, b := range p.Value {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+// LogSense issues LOG SENSE for the cumulative values of a page and returns its parameters
+func (d *SCSIDevice) LogSense(page uint8, subpage uint8) ([]LogParameter, error) {
+	resp, err := d.request([]byte{
+		scsidefs.LOG_SENSE,
+		0x00,
+		0x40 | page&0x3F, // Page control 01 = current cumulative values
+		subpage,
+		0x00,
+		0x00, // Parameter pointer
+		0x00,
+		LOG_SENSE_MAX_LENGTH >> 8,
+		LOG_SENSE_MAX_LENGTH & 0xFF,
+		0x00,
+	}, LOG_SENSE_MAX_LENGTH)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseLogPage(page, resp)
+}
+
+func parseLogPage(page uint8, data []byte) ([]LogParameter, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("too short log sense response: %d bytes", len(data))
+	}
+	if data[0]&0x3F != page {
+		return nil, fmt.Errorf("expected log page %#02x, got %#02x", page, data[0]&0x3F)
+	}
+
+	end := 4 + (int(data[2])<<8 | int(data[3]))
+	if end > len(data) {
+		end = len(data)
+	}
+
+	var params []LogParameter
+	pos := 4
+	for pos+4 <= end {
+		paramEnd := pos + 4 + int(data[pos+3])
+		if paramEnd > end {
+			return nil, fmt.Errorf("log page %#02x parameter %#04x truncated", page, int(data[pos])<<8|int(data[pos+1]))
+		}
+
+		params = append(params, LogParameter{
+			Code:    uint16(data[pos])<<8 | uint16(data[pos+1]),
+			Control: data[pos+2],
+			Value:   data[pos+4 : paramEnd],
+		})
+		pos = paramEnd
+	}
+
+	return params, nil
+}
+
+type ErrorCounters struct {
+	CorrectedWithoutDelay uint64 `json:"corrected-without-delay"`
+	CorrectedWithDelay    uint64 `json:"corrected-with-delay"`
+	Retries               uint64 `json:"retries"`
+	Corrected             uint64 `json:"corrected"`
+	CorrectionInvocations uint64 `json:"correction-invocations"`
+	BytesProcessed        uint64 `json:"bytes-processed"`
+	Uncorrected           uint64 `json:"uncorrected"`
+}
+
+// ErrorCounters reads the write (LOG_PAGE_WRITE_ERROR_COUNTERS) or read (LOG_PAGE_READ_ERROR_COUNTERS) error counter page
+func (d *SCSIDevice) ErrorCounters(page uint8) (*ErrorCounters, error) {
+	params, err := d.LogSense(page, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	counters := &ErrorCounters{}
+	for _, param := range params {
+		switch param.Code {
+		case 0x0000:
+			counters.CorrectedWithoutDelay = param.Uint()
+		case 0x0001:
+			counters.CorrectedWithDelay = param.Uint()
+		case 0x0002:
+			counters.Retries = param.Uint()
+		case 0x0003:
+			counters.Corrected = param.Uint()
+		case 0x0004:
+			counters.CorrectionInvocations = param.Uint()
+		case 0x0005:
+			counters.BytesProcessed = param.Uint()
+		case 0x0006:
+			counters.Uncorrected = param.Uint()
+		}
+	}
+	return counters, nil
+}
+
+type VolumeStatistics struct {
+	Mounts                    uint64 `json:"mounts"`
+	DatasetsWritten           uint64 `json:"datasets-written"`
+	RecoveredWriteErrors      uint64 `json:"recovered-write-errors"`
+	UnrecoveredWriteErrors    uint64 `json:"unrecovered-write-errors"`
+	DatasetsRead              uint64 `json:"datasets-read"`
+	RecoveredReadErrors       uint64 `json:"recovered-read-errors"`
+	UnrecoveredReadErrors     uint64 `json:"unrecovered-read-errors"`
+	LastMountMegabytesWritten uint64 `json:"last-mount-megabytes-written"`
+	LastMountMegabytesRead    uint64 `json:"last-mount-megabytes-read"`
+	LifetimeMegabytesWritten  uint64 `json:"lifetime-megabytes-written"`
+	LifetimeMegabytesRead     uint64 `json:"lifetime-megabytes-read"`
+}
+
+// VolumeStatistics reads the volume statistics page of the loaded tape
+func (d *SCSIDevice) VolumeStatistics() (*VolumeStatistics, error) {
+	params, err := d.LogSense(LOG_PAGE_VOLUME_STATISTICS, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &VolumeStatistics{}
+	for _, param := range params {
+		switch param.Code {
+		case 0x0001:
+			stats.Mounts = param.Uint()
+		case 0x0002:
+			stats.DatasetsWritten = param.Uint()
+		case 0x0003:
+			stats.RecoveredWriteErrors = param.Uint()
+		case 0x0004:
+			stats.UnrecoveredWriteErrors = param.Uint()
+		case 0x0007:
+			stats.DatasetsRead = param.Uint()
+		case 0x0008:
+			stats.RecoveredReadErrors = param.Uint()
+		case 0x0009:
+			stats.UnrecoveredReadErrors = param.Uint()
+		case 0x000E:
+			stats.LastMountMegabytesWritten = param.Uint()
+		case 0x000F:
+			stats.LastMountMegabytesRead = param.Uint()
+		case 0x0010:
+			stats.LifetimeMegabytesWritten = param.Uint()
+		case 0x0011:
+			stats.LifetimeMegabytesRead = param.Uint()
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,195 @@
+package scsi_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/scsitest"
+)
+
+const OPCODE_LOG_SENSE = 0x4D
+
+// logParameter encodes a log parameter with the given code and value
+func logParameter(code uint16, value ...byte) []byte {
+	param := binary.BigEndian.AppendUint16(nil, code)
+	param = append(param, 0x03, uint8(len(value)))
+	return append(param, value...)
+}
+
+// logPage encodes a LOG SENSE response of the given page
+func logPage(page uint8, params ...[]byte) []byte {
+	body := bytes.Join(params, nil)
+	resp := []byte{page, 0x00}
+	resp = binary.BigEndian.AppendUint16(resp, uint16(len(body)))
+	return append(resp, body...)
+}
+
+func TestLogSense(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    []byte
+		want    []scsi.LogParameter
+		wantErr string
+	}{
+		{
+			name: "parameters",
+			resp: logPage(scsi.LOG_PAGE_WRITE_ERROR_COUNTERS,
+				logParameter(0x0000, 0x01),
+				logParameter(0x0005, 0x00, 0x00, 0x01, 0x00),
+			),
+			want: []scsi.LogParameter{
+				{Code: 0x0000, Control: 0x03, Value: []byte{0x01}},
+				{Code: 0x0005, Control: 0x03, Value: []byte{0x00, 0x00, 0x01, 0x00}},
+			},
+		},
+		{
+			name: "empty page",
+			resp: logPage(scsi.LOG_PAGE_WRITE_ERROR_COUNTERS),
+		},
+		{
+			// The page is cut off after the first parameter header, the length claims more
+			name: "page longer than the response",
+			resp: logPage(scsi.LOG_PAGE_WRITE_ERROR_COUNTERS,
+				logParameter(0x0001, 0x02),
+				logParameter(0x0002, 0x03),
+			)[:9],
+			want: []scsi.LogParameter{
+				{Code: 0x0001, Control: 0x03, Value: []byte{0x02}},
+			},
+		},
+		{
+			name: "truncated parameter",
+			resp: logPage(scsi.LOG_PAGE_WRITE_ERROR_COUNTERS,
+				logParameter(0x0001, 0x02),
+				logParameter(0x0002, 0x00, 0x00, 0x00, 0x03),
+			)[:13],
+			wantErr: "parameter 0x0002 truncated",
+		},
+		{
+			name:    "wrong page code",
+			resp:    logPage(scsi.LOG_PAGE_READ_ERROR_COUNTERS, logParameter(0x0000, 0x01)),
+			wantErr: "expected log page 0x02, got 0x03",
+		},
+		{
+			name:    "too short",
+			resp:    []byte{scsi.LOG_PAGE_WRITE_ERROR_COUNTERS, 0x00},
+			wantErr: "too short log sense response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := scsitest.New()
+			dev.Reply(OPCODE_LOG_SENSE, tt.resp, nil)
+
+			params, err := scsi.NewDevice(dev).LogSense(scsi.LOG_PAGE_WRITE_ERROR_COUNTERS, 0)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LogSense: %v", err)
+			}
+			if !slices.EqualFunc(params, tt.want, func(a, b scsi.LogParameter) bool {
+				return a.Code == b.Code && a.Control == b.Control && bytes.Equal(a.Value, b.Value)
+			}) {
+				t.Errorf("got parameters %+v, want %+v", params, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogSenseCDB(t *testing.T) {
+	dev := scsitest.New()
+	dev.Reply(OPCODE_LOG_SENSE, logPage(scsi.LOG_PAGE_TAPE_ALERT), nil)
+
+	_, err := scsi.NewDevice(dev).LogSense(scsi.LOG_PAGE_TAPE_ALERT, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cumulative values of page 0x2E, the largest allocation length
+	want := []byte{0x4D, 0x00, 0x6E, 0x00, 0x00, 0x00, 0x00, 0xFF, 0xFF, 0x00}
+	requests := dev.Requests()
+	if len(requests) != 1 || !bytes.Equal(requests[0].CDB, want) {
+		t.Errorf("got requests %+v, want CDB % x", requests, want)
+	}
+}
+
+func TestVolumeStatistics(t *testing.T) {
+	dev := scsitest.New()
+	dev.Reply(OPCODE_LOG_SENSE, logPage(scsi.LOG_PAGE_VOLUME_STATISTICS,
+		logParameter(0x0000, 0x01), // Page valid
+		logParameter(0x0001, 0x00, 0x00, 0x00, 0x2A),
+		logParameter(0x0002, 0x00, 0x00, 0x10, 0x00),
+		logParameter(0x0003, 0x05),
+		logParameter(0x0004, 0x00),
+		logParameter(0x0007, 0x00, 0x00, 0x08, 0x00),
+		logParameter(0x0008, 0x07),
+		logParameter(0x0009, 0x01),
+		logParameter(0x000E, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00),
+		logParameter(0x000F, 0x00, 0x80),
+		logParameter(0x0010, 0x01, 0x00, 0x00, 0x00, 0x00),
+		logParameter(0x0011, 0x00, 0x00, 0x40, 0x00),
+		logParameter(0x0101, 0xFF), // Beginning of medium passes, not decoded
+	), nil)
+
+	stats, err := scsi.NewDevice(dev).VolumeStatistics()
+	if err != nil {
+		t.Fatalf("VolumeStatistics: %v", err)
+	}
+
+	want := scsi.VolumeStatistics{
+		Mounts:                    42,
+		DatasetsWritten:           0x1000,
+		RecoveredWriteErrors:      5,
+		UnrecoveredWriteErrors:    0,
+		DatasetsRead:              0x800,
+		RecoveredReadErrors:       7,
+		UnrecoveredReadErrors:     1,
+		LastMountMegabytesWritten: 0x10000,
+		LastMountMegabytesRead:    0x80,
+		LifetimeMegabytesWritten:  0x100000000,
+		LifetimeMegabytesRead:     0x4000,
+	}
+	if *stats != want {
+		t.Errorf("got %+v, want %+v", *stats, want)
+	}
+}
+
+func TestErrorCounters(t *testing.T) {
+	dev := scsitest.New()
+	dev.Reply(OPCODE_LOG_SENSE, logPage(scsi.LOG_PAGE_READ_ERROR_COUNTERS,
+		logParameter(0x0000, 0x01),
+		logParameter(0x0001, 0x02),
+		logParameter(0x0002, 0x03),
+		logParameter(0x0003, 0x04),
+		logParameter(0x0004, 0x05),
+		logParameter(0x0005, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00),
+		logParameter(0x0006, 0x07),
+	), nil)
+
+	counters, err := scsi.NewDevice(dev).ErrorCounters(scsi.LOG_PAGE_READ_ERROR_COUNTERS)
+	if err != nil {
+		t.Fatalf("ErrorCounters: %v", err)
+	}
+
+	want := scsi.ErrorCounters{
+		CorrectedWithoutDelay: 1,
+		CorrectedWithDelay:    2,
+		Retries:               3,
+		Corrected:             4,
+		CorrectionInvocations: 5,
+		BytesProcessed:        0x10000000000,
+		Uncorrected:           7,
+	}
+	if *counters != want {
+		t.Errorf("got %+v, want %+v", *counters, want)
+	}
+}
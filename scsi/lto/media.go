@@ -0,0 +1,186 @@
+// Package lto knows the LTO cartridge types, as printed in the last two
+// characters of an LTO barcode, and the density codes drives report for them.
+package lto
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Generation uint8
+
+const (
+	GENERATION_UNKNOWN Generation = 0
+
+	// LTO barcodes are a six character volume serial followed by the cartridge type
+	VOLUME_SERIAL_LENGTH = 6
+	MEDIA_TYPE_LENGTH    = 2
+
+	MEDIA_TYPE_CLEANING = "CU"
+	MEDIA_TYPE_TYPE_M   = "M8" // LTO-7 cartridge initialized as Type M by an LTO-8 drive
+
+	// Native capacities are in decimal units, like on the cartridge
+	TB = 1000 * 1000 * 1000 * 1000
+	GB = 1000 * 1000 * 1000
+)
+
+func (g Generation) String() string {
+	if g == GENERATION_UNKNOWN {
+		return "Unknown"
+	}
+	return fmt.Sprintf("LTO-%d", g)
+}
+
+// Media describes an LTO cartridge type
+type Media struct {
+	Generation Generation
+	WORM       bool
+	TypeM      bool
+	Cleaning   bool
+}
+
+type generationInfo struct {
+	dataType       string
+	wormType       string
+	densityCode    uint8
+	nativeCapacity int64
+}
+
+var generations = map[Generation]generationInfo{
+	1: {dataType: "L1", densityCode: 0x40, nativeCapacity: 100 * GB},
+	2: {dataType: "L2", densityCode: 0x42, nativeCapacity: 200 * GB},
+	3: {dataType: "L3", wormType: "LT", densityCode: 0x44, nativeCapacity: 400 * GB},
+	4: {dataType: "L4", wormType: "LU", densityCode: 0x46, nativeCapacity: 800 * GB},
+	5: {dataType: "L5", wormType: "LV", densityCode: 0x58, nativeCapacity: 1500 * GB},
+	6: {dataType: "L6", wormType: "LW", densityCode: 0x5A, nativeCapacity: 2500 * GB},
+	7: {dataType: "L7", wormType: "LX", densityCode: 0x5C, nativeCapacity: 6 * TB},
+	8: {dataType: "L8", wormType: "LY", densityCode: 0x5E, nativeCapacity: 12 * TB},
+	9: {dataType: "L9", wormType: "LZ", densityCode: 0x60, nativeCapacity: 18 * TB},
+}
+
+const (
+	TYPE_M_DENSITY_CODE    = 0x5D
+	TYPE_M_NATIVE_CAPACITY = 9 * TB
+)
+
+// ParseMediaType parses a cartridge type such as L8, LW or M8
+func ParseMediaType(mediaType string) (Media, error) {
+	mediaType = strings.ToUpper(mediaType)
+	switch mediaType {
+	case MEDIA_TYPE_CLEANING:
+		return Media{Cleaning: true}, nil
+	case MEDIA_TYPE_TYPE_M:
+		return Media{Generation: 7, TypeM: true}, nil
+	}
+
+	for generation, info := range generations {
+		switch mediaType {
+		case info.dataType:
+			return Media{Generation: generation}, nil
+		case info.wormType:
+			return Media{Generation: generation, WORM: true}, nil
+		}
+	}
+	return Media{}, fmt.Errorf("unknown LTO media type %q", mediaType)
+}
+
+// ParseVolumeTag splits an LTO barcode into its volume serial and cartridge type.
+// Libraries can be configured to report only the six character volume serial,
+// the media is unknown (and no error returned) in that case.
+func ParseVolumeTag(volumeTag string) (serial string, media Media, err error) {
+	volumeTag = strings.TrimSpace(volumeTag)
+	if len(volumeTag) == VOLUME_SERIAL_LENGTH {
+		return volumeTag, Media{}, nil
+	}
+	if len(volumeTag) != VOLUME_SERIAL_LENGTH+MEDIA_TYPE_LENGTH {
+		return "", Media{}, fmt.Errorf("volume tag %q is not an LTO barcode", volumeTag)
+	}
+
+	media, err = ParseMediaType(volumeTag[VOLUME_SERIAL_LENGTH:])
+	if err != nil {
+		return "", Media{}, fmt.Errorf("volume tag %q: %w", volumeTag, err)
+	}
+	return volumeTag[:VOLUME_SERIAL_LENGTH], media, nil
+}
+
+// MediaFromDensity returns the media a drive reported the density code of, WORM is not part of the density
+func MediaFromDensity(densityCode uint8) (Media, bool) {
+	if densityCode == TYPE_M_DENSITY_CODE {
+		return Media{Generation: 7, TypeM: true}, true
+	}
+	for generation, info := range generations {
+		if info.densityCode == densityCode {
+			return Media{Generation: generation}, true
+		}
+	}
+	return Media{}, false
+}
+
+func (m Media) Known() bool {
+	return m.Generation != GENERATION_UNKNOWN || m.Cleaning
+}
+
+// Type returns the cartridge type as printed on the barcode, or an empty string if unknown
+func (m Media) Type() string {
+	info := generations[m.Generation]
+	switch {
+	case m.Cleaning:
+		return MEDIA_TYPE_CLEANING
+	case m.TypeM:
+		return MEDIA_TYPE_TYPE_M
+	case m.WORM:
+		return info.wormType
+	default:
+		return info.dataType
+	}
+}
+
+// DensityCode returns the density the media is written with, or 0 if unknown
+func (m Media) DensityCode() uint8 {
+	if m.TypeM {
+		return TYPE_M_DENSITY_CODE
+	}
+	return generations[m.Generation].densityCode
+}
+
+// NativeCapacity returns the uncompressed capacity in bytes, or 0 if unknown
+func (m Media) NativeCapacity() int64 {
+	if m.TypeM {
+		return TYPE_M_NATIVE_CAPACITY
+	}
+	return generations[m.Generation].nativeCapacity
+}
+
+func (m Media) String() string {
+	switch {
+	case m.Cleaning:
+		return "Cleaning"
+	case !m.Known():
+		return "Unknown"
+	case m.TypeM:
+		return "LTO-7 Type M"
+	case m.WORM:
+		return m.Generation.String() + " WORM"
+	default:
+		return m.Generation.String()
+	}
+}
+
+// DriveSupport returns the media a drive of the given generation writes and the media it can only read
+func DriveSupport(drive Generation) (writable []Media, readOnly []Media) {
+	switch {
+	case drive >= 9:
+		// From LTO-9 on drives only read and write one generation back
+		return []Media{{Generation: drive - 1}, {Generation: drive}}, nil
+	case drive == 8:
+		return []Media{{Generation: 7}, {Generation: 7, TypeM: true}, {Generation: 8}}, nil
+	case drive >= 3:
+		return []Media{{Generation: drive - 1}, {Generation: drive}}, []Media{{Generation: drive - 2}}
+	case drive == 2:
+		return []Media{{Generation: 1}, {Generation: 2}}, nil
+	case drive == 1:
+		return []Media{{Generation: 1}}, nil
+	default:
+		return nil, nil
+	}
+}
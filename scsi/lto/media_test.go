@@ -0,0 +1,119 @@
+package lto_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi/lto"
+)
+
+func TestParseVolumeTag(t *testing.T) {
+	tests := []struct {
+		volumeTag string
+		serial    string
+		media     lto.Media
+		wantErr   bool
+	}{
+		{volumeTag: "ABC123L8", serial: "ABC123", media: lto.Media{Generation: 8}},
+		{volumeTag: "ABC123l9", serial: "ABC123", media: lto.Media{Generation: 9}},
+		{volumeTag: " ABC123L1 ", serial: "ABC123", media: lto.Media{Generation: 1}},
+		{volumeTag: "ABC123LT", serial: "ABC123", media: lto.Media{Generation: 3, WORM: true}},
+		{volumeTag: "ABC123LZ", serial: "ABC123", media: lto.Media{Generation: 9, WORM: true}},
+		{volumeTag: "ABC123M8", serial: "ABC123", media: lto.Media{Generation: 7, TypeM: true}},
+		{volumeTag: "CLN001CU", serial: "CLN001", media: lto.Media{Cleaning: true}},
+		// Libraries reporting only the volume serial
+		{volumeTag: "ABC123", serial: "ABC123"},
+		{volumeTag: "ABC123XX", wantErr: true},
+		{volumeTag: "ABC12L8", wantErr: true},
+		{volumeTag: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		serial, media, err := lto.ParseVolumeTag(tt.volumeTag)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseVolumeTag(%q): got %s %v, want an error", tt.volumeTag, serial, media)
+			}
+			continue
+		}
+		if err != nil || serial != tt.serial || media != tt.media {
+			t.Errorf("ParseVolumeTag(%q): got %q %v %v, want %q %v", tt.volumeTag, serial, media, err, tt.serial, tt.media)
+		}
+	}
+}
+
+func TestMedia(t *testing.T) {
+	tests := []struct {
+		media       lto.Media
+		mediaType   string
+		name        string
+		densityCode uint8
+		capacity    int64
+	}{
+		{lto.Media{Generation: 1}, "L1", "LTO-1", 0x40, 100 * lto.GB},
+		{lto.Media{Generation: 5}, "L5", "LTO-5", 0x58, 1500 * lto.GB},
+		{lto.Media{Generation: 6, WORM: true}, "LW", "LTO-6 WORM", 0x5A, 2500 * lto.GB},
+		{lto.Media{Generation: 7, TypeM: true}, "M8", "LTO-7 Type M", 0x5D, 9 * lto.TB},
+		{lto.Media{Generation: 8}, "L8", "LTO-8", 0x5E, 12 * lto.TB},
+		{lto.Media{Generation: 9}, "L9", "LTO-9", 0x60, 18 * lto.TB},
+		{lto.Media{Cleaning: true}, "CU", "Cleaning", 0, 0},
+		{lto.Media{}, "", "Unknown", 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.media.Type(); got != tt.mediaType {
+			t.Errorf("%v: got type %q, want %q", tt.media, got, tt.mediaType)
+		}
+		if got := tt.media.String(); got != tt.name {
+			t.Errorf("%v: got name %q, want %q", tt.media, got, tt.name)
+		}
+		if got := tt.media.DensityCode(); got != tt.densityCode {
+			t.Errorf("%v: got density code %#02x, want %#02x", tt.media, got, tt.densityCode)
+		}
+		if got := tt.media.NativeCapacity(); got != tt.capacity {
+			t.Errorf("%v: got capacity %d, want %d", tt.media, got, tt.capacity)
+		}
+
+		if tt.mediaType != "" {
+			parsed, err := lto.ParseMediaType(tt.mediaType)
+			if err != nil || parsed != tt.media {
+				t.Errorf("ParseMediaType(%q): got %v %v, want %v", tt.mediaType, parsed, err, tt.media)
+			}
+		}
+		if tt.densityCode != 0 {
+			fromDensity, ok := lto.MediaFromDensity(tt.densityCode)
+			want := tt.media
+			want.WORM = false
+			if !ok || fromDensity != want {
+				t.Errorf("MediaFromDensity(%#02x): got %v %v, want %v", tt.densityCode, fromDensity, ok, want)
+			}
+		}
+	}
+
+	_, ok := lto.MediaFromDensity(0x00)
+	if ok {
+		t.Errorf("MediaFromDensity accepted density code 0")
+	}
+}
+
+func TestDriveSupport(t *testing.T) {
+	tests := []struct {
+		drive    lto.Generation
+		writable []lto.Media
+		readOnly []lto.Media
+	}{
+		{1, []lto.Media{{Generation: 1}}, nil},
+		{2, []lto.Media{{Generation: 1}, {Generation: 2}}, nil},
+		{6, []lto.Media{{Generation: 5}, {Generation: 6}}, []lto.Media{{Generation: 4}}},
+		{8, []lto.Media{{Generation: 7}, {Generation: 7, TypeM: true}, {Generation: 8}}, nil},
+		{9, []lto.Media{{Generation: 8}, {Generation: 9}}, nil},
+		{lto.GENERATION_UNKNOWN, nil, nil},
+	}
+
+	for _, tt := range tests {
+		writable, readOnly := lto.DriveSupport(tt.drive)
+		if !slices.Equal(writable, tt.writable) || !slices.Equal(readOnly, tt.readOnly) {
+			t.Errorf("%s: got writable %v and read-only %v, want %v and %v", tt.drive, writable, readOnly, tt.writable, tt.readOnly)
+		}
+	}
+}
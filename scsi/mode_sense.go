@@ -0,0 +1,111 @@
+package scsi
+
+import (
+	"fmt"
+
+	scsidefs "github.com/FoxDenHome/goscsi/godefs/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+)
+
+const (
+	MODE_PAGE_ELEMENT_ADDRESS_ASSIGNMENT = 0x1D
+	MODE_PAGE_ALL                        = 0x3F
+
+	// Device specific parameter of the mode parameter header, for sequential access devices
+	MODE_HEADER_WRITE_PROTECT = 0x80
+)
+
+// ModeSense issues MODE SENSE(6) for the current values of a page, without block descriptors
+// It returns the page itself, with the mode parameter header and block descriptors stripped
+func (d *SCSIDevice) ModeSense(page uint8, subpage uint8) ([]byte, error) {
+	return d.modeSense(page, subpage, true)
+}
+
+// WriteProtected reports whether the loaded cartridge is write-protected (tape drives only)
+func (d *SCSIDevice) WriteProtected() (bool, error) {
+	resp, err := d.modeSenseData(MODE_PAGE_ALL, 0, true)
+	if err != nil {
+		return false, err
+	}
+	return resp[2]&MODE_HEADER_WRITE_PROTECT != 0, nil
+}
+
+// modeSenseData returns the whole response, starting with the mode parameter header
+func (d *SCSIDevice) modeSenseData(page uint8, subpage uint8, disableBlockDescriptors bool) ([]byte, error) {
+	const allocLen = 0xFF
+
+	resp, err := d.request([]byte{
+		scsidefs.MODE_SENSE,
+		boolToFlag(disableBlockDescriptors, 3),
+		page & 0x3F, // Page control 00 = current values
+		subpage,
+		allocLen,
+		0x00,
+	}, allocLen)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp) < 4 {
+		return nil, fmt.Errorf("too short mode sense response: %d bytes", len(resp))
+	}
+	return resp, nil
+}
+
+func (d *SCSIDevice) modeSense(page uint8, subpage uint8, disableBlockDescriptors bool) ([]byte, error) {
+	resp, err := d.modeSenseData(page, subpage, disableBlockDescriptors)
+	if err != nil {
+		return nil, err
+	}
+
+	dataEnd := int(resp[0]) + 1
+	if dataEnd > len(resp) {
+		dataEnd = len(resp)
+	}
+	pageStart := 4 + int(resp[3])
+	if pageStart+2 > dataEnd {
+		return nil, fmt.Errorf("mode page %#02x not returned", page)
+	}
+
+	pageData := resp[pageStart:dataEnd]
+	if pageData[0]&0x3F != page {
+		return nil, fmt.Errorf("expected mode page %#02x, got %#02x", page, pageData[0]&0x3F)
+	}
+	pageEnd := 2 + int(pageData[1])
+	if pageEnd > len(pageData) {
+		return nil, fmt.Errorf("mode page %#02x truncated: expected %d bytes, got %d", page, pageEnd, len(pageData))
+	}
+
+	return pageData[:pageEnd], nil
+}
+
+type ElementRange struct {
+	First uint16
+	Count uint16
+}
+
+type ElementAddressAssignment map[element.Type]ElementRange
+
+func (d *SCSIDevice) ElementAddressAssignment() (ElementAddressAssignment, error) {
+	page, err := d.ModeSense(MODE_PAGE_ELEMENT_ADDRESS_ASSIGNMENT, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(page) < 18 {
+		return nil, fmt.Errorf("too short element address assignment page: %d bytes", len(page))
+	}
+
+	readRange := func(pos int) ElementRange {
+		return ElementRange{
+			First: uint16(page[pos])<<8 | uint16(page[pos+1]),
+			Count: uint16(page[pos+2])<<8 | uint16(page[pos+3]),
+		}
+	}
+
+	return ElementAddressAssignment{
+		element.ELEMENT_TYPE_MEDIUM_TRANSPORT: readRange(2),
+		element.ELEMENT_TYPE_STORAGE:          readRange(6),
+		element.ELEMENT_TYPE_IMPORT_EXPORT:    readRange(10),
+		element.ELEMENT_TYPE_DATA_TRANSFER:    readRange(14),
+	}, nil
+}
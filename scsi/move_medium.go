@@ -21,9 +21,9 @@ func (d *SCSIDevice) MoveMedium(sourceAddress uint16, destAddress uint16, moveOp
 		0x00, 0x00, // Transport element address, no library seems to care about this and auto-select the arm instead
 		uint8(sourceAddress >> 8), uint8(sourceAddress & 0xFF),
 		uint8(destAddress >> 8), uint8(destAddress & 0xFF),
-		0x00,
+		0x00, 0x00, // Reserved, MOVE MEDIUM is a 12 byte CDB with the invert flag in byte 10
 		0x00,             // Last bit is invert flag, but this is not supported
 		byte(moveOption), // Last 5 bits are control byte, which are always 0
-	}, 6, time.Minute*5)
+	}, 0, time.Minute*5)
 	return err
 }
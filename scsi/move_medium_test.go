@@ -0,0 +1,47 @@
+package scsi_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/scsitest"
+)
+
+func TestMoveMediumCDB(t *testing.T) {
+	dev := scsitest.New()
+	dev.Reply(0xA5, nil, nil)
+
+	err := scsi.NewDevice(dev).MoveMedium(0x1002, 0x0100, scsi.MOVE_OPTION_NORMAL)
+	if err != nil {
+		t.Fatalf("MoveMedium: %v", err)
+	}
+
+	want := []byte{0xA5, 0x00, 0x00, 0x00, 0x10, 0x02, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00}
+	requests := dev.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	if !bytes.Equal(requests[0].CDB, want) {
+		t.Errorf("got CDB % x, want % x", requests[0].CDB, want)
+	}
+}
+
+func TestExchangeMediumCDB(t *testing.T) {
+	dev := scsitest.New()
+	dev.Reply(scsi.EXCHANGE_MEDIUM, nil, nil)
+
+	err := scsi.NewDevice(dev).ExchangeMedium(0x1000, 0x1003, 0x1000)
+	if err != nil {
+		t.Fatalf("ExchangeMedium: %v", err)
+	}
+
+	want := []byte{0xA6, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x03, 0x10, 0x00, 0x00, 0x00}
+	requests := dev.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	if !bytes.Equal(requests[0].CDB, want) {
+		t.Errorf("got CDB % x, want % x", requests[0].CDB, want)
+	}
+}
@@ -0,0 +1,28 @@
+package scsi
+
+import (
+	scsidefs "github.com/FoxDenHome/goscsi/godefs/scsi"
+)
+
+const (
+	MEDIUM_REMOVAL_ALLOW   = 0b00
+	MEDIUM_REMOVAL_PREVENT = 0b01
+)
+
+// PreventMediumRemoval locks (or unlocks) the magazines, mail slots or eject
+// button of the device. The state belongs to the initiator, not the open file,
+// so it outlives this process and has to be cleared explicitly.
+func (d *SCSIDevice) PreventMediumRemoval(prevent bool) error {
+	var mode byte = MEDIUM_REMOVAL_ALLOW
+	if prevent {
+		mode = MEDIUM_REMOVAL_PREVENT
+	}
+
+	_, err := d.request([]byte{
+		scsidefs.ALLOW_MEDIUM_REMOVAL, // PREVENT ALLOW MEDIUM REMOVAL
+		0x00, 0x00, 0x00,
+		mode,
+		0x00,
+	}, 0)
+	return err
+}
@@ -0,0 +1,280 @@
+package scsi
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	READ_ATTRIBUTE = 0x8C
+
+	READ_ATTRIBUTE_VALUES         = 0x00
+	READ_ATTRIBUTE_PARTITION_LIST = 0x03
+
+	READ_ATTRIBUTE_MAX_LENGTH = 0xFFFF
+
+	// Drives only answer attribute commands once a cartridge being loaded is threaded far enough to reach its MAM chip
+	ATTRIBUTE_TIMEOUT = time.Minute
+)
+
+type AttributeFormat uint8
+
+const (
+	ATTRIBUTE_FORMAT_BINARY AttributeFormat = 0x00
+	ATTRIBUTE_FORMAT_ASCII  AttributeFormat = 0x01
+	ATTRIBUTE_FORMAT_TEXT   AttributeFormat = 0x02
+)
+
+// Medium auxiliary memory attribute identifiers, as assigned by SPC and SSC
+const (
+	ATTRIBUTE_REMAINING_CAPACITY      = 0x0000
+	ATTRIBUTE_MAXIMUM_CAPACITY        = 0x0001
+	ATTRIBUTE_LOAD_COUNT              = 0x0003
+	ATTRIBUTE_LAST_LOAD_DEVICE        = 0x020A // Up to 0x020D, most recent first
+	ATTRIBUTE_LAST_LOAD_DEVICE_COUNT  = 4
+	ATTRIBUTE_TOTAL_WRITTEN           = 0x0220
+	ATTRIBUTE_TOTAL_READ              = 0x0221
+	ATTRIBUTE_MEDIUM_MANUFACTURER     = 0x0400
+	ATTRIBUTE_MEDIUM_SERIAL_NUMBER    = 0x0401
+	ATTRIBUTE_MEDIUM_DENSITY_CODE     = 0x0405
+	ATTRIBUTE_MEDIUM_MANUFACTURE_DATE = 0x0406
+	ATTRIBUTE_APPLICATION_VENDOR      = 0x0800
+	ATTRIBUTE_APPLICATION_NAME        = 0x0801
+	ATTRIBUTE_APPLICATION_VERSION     = 0x0802
+	ATTRIBUTE_USER_MEDIUM_TEXT_LABEL  = 0x0803
+	ATTRIBUTE_BARCODE                 = 0x0806
+)
+
+// LTFS_APPLICATION_NAME is the application name LTFS stores when formatting a cartridge
+const LTFS_APPLICATION_NAME = "LTFS"
+
+type Attribute struct {
+	ID       uint16
+	Format   AttributeFormat
+	ReadOnly bool
+	Value    []byte
+}
+
+func (a *Attribute) Uint() uint64 {
+	var value uint64
+	for _, b := range a.Value {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+func (a *Attribute) String() string {
+	return string(bytes.TrimRight(a.Value, "\x00 "))
+}
+
+func readAttributeCDB(serviceAction uint8, partition uint8, allocLen uint32) []byte {
+	return []byte{
+		READ_ATTRIBUTE,
+		serviceAction & 0x1F,
+		0x00, 0x00, 0x00, // Restricted
+		0x00, // Logical volume number
+		0x00,
+		partition,
+		0x00, 0x00, // First attribute identifier
+		uint8(allocLen >> 24),
+		uint8(allocLen >> 16),
+		uint8(allocLen >> 8),
+		uint8(allocLen),
+		0x00,
+		0x00,
+	}
+}
+
+// ReadAttributes returns all medium auxiliary memory attributes of a partition
+func (d *SCSIDevice) ReadAttributes(partition uint8) ([]Attribute, error) {
+	resp, err := d.requestWithTimeout(readAttributeCDB(READ_ATTRIBUTE_VALUES, partition, READ_ATTRIBUTE_MAX_LENGTH), READ_ATTRIBUTE_MAX_LENGTH, ATTRIBUTE_TIMEOUT)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAttributes(resp)
+}
+
+func parseAttributes(data []byte) ([]Attribute, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("too short read attribute response: %d bytes", len(data))
+	}
+
+	end := 4 + int(uint32(data[0])<<24|uint32(data[1])<<16|uint32(data[2])<<8|uint32(data[3]))
+	if end > len(data) {
+		// Attributes that did not fit into the allocation length are dropped below
+		end = len(data)
+	}
+
+	var attributes []Attribute
+	pos := 4
+	for pos+5 <= end {
+		valueEnd := pos + 5 + (int(data[pos+3])<<8 | int(data[pos+4]))
+		if valueEnd > end {
+			break
+		}
+
+		attributes = append(attributes, Attribute{
+			ID:       uint16(data[pos])<<8 | uint16(data[pos+1]),
+			Format:   AttributeFormat(data[pos+2] & 0x03),
+			ReadOnly: flagToBool(data[pos+2], 7),
+			Value:    data[pos+5 : valueEnd],
+		})
+		pos = valueEnd
+	}
+
+	return attributes, nil
+}
+
+// Partitions returns the first partition number and the number of partitions on the loaded medium
+func (d *SCSIDevice) Partitions() (uint8, uint8, error) {
+	const allocLen = 8
+
+	resp, err := d.requestWithTimeout(readAttributeCDB(READ_ATTRIBUTE_PARTITION_LIST, 0, allocLen), allocLen, ATTRIBUTE_TIMEOUT)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(resp) < 4 {
+		return 0, 0, fmt.Errorf("too short partition list: %d bytes", len(resp))
+	}
+
+	return resp[2], resp[3], nil
+}
+
+type PartitionCapacity struct {
+	Partition    uint8  `json:"partition"`
+	RemainingMiB uint64 `json:"remaining-mib"`
+	MaximumMiB   uint64 `json:"maximum-mib"`
+}
+
+type MediumAuxiliaryMemory struct {
+	Manufacturer    string              `json:"manufacturer"`
+	SerialNumber    string              `json:"serial-number"`
+	ManufactureDate string              `json:"manufacture-date"`
+	Barcode         string              `json:"barcode,omitempty"`
+	DensityCode     uint8               `json:"density-code"`
+	LoadCount       uint64              `json:"load-count"`
+	TotalWrittenMiB uint64              `json:"total-written-mib"`
+	TotalReadMiB    uint64              `json:"total-read-mib"`
+	Partitions      []PartitionCapacity `json:"partitions"`
+	LastLoads       []string            `json:"last-loads,omitempty"`
+
+	// Application that formatted the cartridge, like LTFS, and the volume name it set
+	ApplicationVendor  string `json:"application-vendor,omitempty"`
+	ApplicationName    string `json:"application-name,omitempty"`
+	ApplicationVersion string `json:"application-version,omitempty"`
+	VolumeLabel        string `json:"volume-label,omitempty"`
+}
+
+// HasLTFSVolume reports whether LTFS formatted the cartridge, its data is not necessarily tapemgr's
+func (m *MediumAuxiliaryMemory) HasLTFSVolume() bool {
+	return strings.EqualFold(m.ApplicationName, LTFS_APPLICATION_NAME)
+}
+
+// Application returns the vendor, name and version of the application that formatted the cartridge
+func (m *MediumAuxiliaryMemory) Application() string {
+	var fields []string
+	for _, field := range []string{m.ApplicationVendor, m.ApplicationName, m.ApplicationVersion} {
+		if field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// RemainingMiB returns the remaining capacity summed over all partitions
+func (m *MediumAuxiliaryMemory) RemainingMiB() uint64 {
+	var remaining uint64
+	for _, partition := range m.Partitions {
+		remaining += partition.RemainingMiB
+	}
+	return remaining
+}
+
+// MaximumMiB returns the maximum capacity summed over all partitions
+func (m *MediumAuxiliaryMemory) MaximumMiB() uint64 {
+	var maximum uint64
+	for _, partition := range m.Partitions {
+		maximum += partition.MaximumMiB
+	}
+	return maximum
+}
+
+// MediumAuxiliaryMemory reads the well-known attributes of the loaded cartridge's MAM chip
+func (d *SCSIDevice) MediumAuxiliaryMemory() (*MediumAuxiliaryMemory, error) {
+	first, count, err := d.Partitions()
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		count = 1
+	}
+
+	mam := &MediumAuxiliaryMemory{}
+	for partition := first; partition < first+count; partition++ {
+		attributes, err := d.ReadAttributes(partition)
+		if err != nil {
+			return nil, fmt.Errorf("reading attributes of partition %d: %w", partition, err)
+		}
+
+		capacity := PartitionCapacity{
+			Partition: partition,
+		}
+		for _, attr := range attributes {
+			switch attr.ID {
+			case ATTRIBUTE_REMAINING_CAPACITY:
+				capacity.RemainingMiB = attr.Uint()
+			case ATTRIBUTE_MAXIMUM_CAPACITY:
+				capacity.MaximumMiB = attr.Uint()
+			}
+
+			// Medium attributes are the same for every partition
+			if partition != first {
+				continue
+			}
+			mam.parseMediumAttribute(&attr)
+		}
+		mam.Partitions = append(mam.Partitions, capacity)
+	}
+
+	return mam, nil
+}
+
+func (m *MediumAuxiliaryMemory) parseMediumAttribute(attr *Attribute) {
+	switch {
+	case attr.ID == ATTRIBUTE_LOAD_COUNT:
+		m.LoadCount = attr.Uint()
+	case attr.ID == ATTRIBUTE_TOTAL_WRITTEN:
+		m.TotalWrittenMiB = attr.Uint()
+	case attr.ID == ATTRIBUTE_TOTAL_READ:
+		m.TotalReadMiB = attr.Uint()
+	case attr.ID == ATTRIBUTE_MEDIUM_MANUFACTURER:
+		m.Manufacturer = attr.String()
+	case attr.ID == ATTRIBUTE_MEDIUM_SERIAL_NUMBER:
+		m.SerialNumber = attr.String()
+	case attr.ID == ATTRIBUTE_MEDIUM_MANUFACTURE_DATE:
+		m.ManufactureDate = attr.String()
+	case attr.ID == ATTRIBUTE_MEDIUM_DENSITY_CODE && len(attr.Value) > 0:
+		m.DensityCode = attr.Value[0]
+	case attr.ID == ATTRIBUTE_BARCODE:
+		m.Barcode = attr.String()
+	case attr.ID == ATTRIBUTE_APPLICATION_NAME:
+		m.ApplicationName = attr.String()
+	case attr.ID == ATTRIBUTE_APPLICATION_VERSION:
+		m.ApplicationVersion = attr.String()
+	case attr.ID == ATTRIBUTE_APPLICATION_VENDOR:
+		m.ApplicationVendor = attr.String()
+	case attr.ID == ATTRIBUTE_USER_MEDIUM_TEXT_LABEL:
+		m.VolumeLabel = attr.String()
+	case attr.ID >= ATTRIBUTE_LAST_LOAD_DEVICE && attr.ID < ATTRIBUTE_LAST_LOAD_DEVICE+ATTRIBUTE_LAST_LOAD_DEVICE_COUNT:
+		// 8 byte T10 vendor identification followed by the drive's serial number
+		if len(attr.Value) <= 8 || len(bytes.Trim(attr.Value, "\x00 ")) == 0 {
+			return
+		}
+		vendor := string(bytes.TrimSpace(attr.Value[:8]))
+		serial := string(bytes.Trim(attr.Value[8:], "\x00 "))
+		m.LastLoads = append(m.LastLoads, vendor+" "+serial)
+	}
+}
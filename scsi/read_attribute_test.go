@@ -0,0 +1,178 @@
+package scsi_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/scsitest"
+)
+
+func binaryAttribute(id uint16, value uint64, length int) []byte {
+	header := []byte{0, 0, uint8(scsi.ATTRIBUTE_FORMAT_BINARY), 0, 0}
+	binary.BigEndian.PutUint16(header[0:2], id)
+	binary.BigEndian.PutUint16(header[3:5], uint16(length))
+	return append(header, binary.BigEndian.AppendUint64(nil, value)[8-length:]...)
+}
+
+func attributeList(attributes ...[]byte) []byte {
+	body := bytes.Join(attributes, nil)
+	return append(binary.BigEndian.AppendUint32(nil, uint32(len(body))), body...)
+}
+
+// lastLoad encodes a last load device attribute: the T10 vendor identification padded to 8 bytes and the drive serial
+func lastLoad(index uint16, vendor string, serial string) []byte {
+	value := make([]byte, 8, 8+len(serial))
+	copy(value, vendor+"        ")
+	value = append(value, serial...)
+	return attribute(scsi.ATTRIBUTE_LAST_LOAD_DEVICE+index, string(value))
+}
+
+func TestReadAttributes(t *testing.T) {
+	full := attributeList(
+		binaryAttribute(scsi.ATTRIBUTE_LOAD_COUNT, 12, 8),
+		attribute(scsi.ATTRIBUTE_MEDIUM_MANUFACTURER, "FUJIFILM"),
+		attribute(scsi.ATTRIBUTE_BARCODE, "ABC123L8"),
+	)
+
+	tests := []struct {
+		name string
+		resp []byte
+		want []uint16
+	}{
+		{"complete list", full, []uint16{scsi.ATTRIBUTE_LOAD_COUNT, scsi.ATTRIBUTE_MEDIUM_MANUFACTURER, scsi.ATTRIBUTE_BARCODE}},
+		// The drive reports the length of all attributes but only sends what fits the allocation length
+		{"truncated in a value", full[:len(full)-3], []uint16{scsi.ATTRIBUTE_LOAD_COUNT, scsi.ATTRIBUTE_MEDIUM_MANUFACTURER}},
+		{"truncated in a header", full[:4+13+2], []uint16{scsi.ATTRIBUTE_LOAD_COUNT}},
+		{"empty list", attributeList(), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := scsitest.New()
+			dev.Reply(OPCODE_READ_ATTRIBUTE, tt.resp, nil)
+
+			attributes, err := scsi.NewDevice(dev).ReadAttributes(0)
+			if err != nil {
+				t.Fatalf("ReadAttributes: %v", err)
+			}
+			var ids []uint16
+			for _, attr := range attributes {
+				ids = append(ids, attr.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("got attributes %#04x, want %#04x", ids, tt.want)
+			}
+		})
+	}
+
+	dev := scsitest.New()
+	dev.Reply(OPCODE_READ_ATTRIBUTE, []byte{0x00, 0x00}, nil)
+	_, err := scsi.NewDevice(dev).ReadAttributes(0)
+	if err == nil {
+		t.Errorf("ReadAttributes accepted a 2 byte response")
+	}
+}
+
+func TestMediumAuxiliaryMemory(t *testing.T) {
+	partitions := map[uint8][]byte{
+		0: attributeList(
+			binaryAttribute(scsi.ATTRIBUTE_REMAINING_CAPACITY, 35000, 8),
+			binaryAttribute(scsi.ATTRIBUTE_MAXIMUM_CAPACITY, 36000, 8),
+			binaryAttribute(scsi.ATTRIBUTE_LOAD_COUNT, 17, 8),
+			binaryAttribute(scsi.ATTRIBUTE_TOTAL_WRITTEN, 1000, 8),
+			binaryAttribute(scsi.ATTRIBUTE_TOTAL_READ, 2000, 8),
+			attribute(scsi.ATTRIBUTE_MEDIUM_MANUFACTURER, "FUJIFILM"),
+			attribute(scsi.ATTRIBUTE_MEDIUM_SERIAL_NUMBER, "0123456789  "),
+			binaryAttribute(scsi.ATTRIBUTE_MEDIUM_DENSITY_CODE, 0x5A, 1),
+			attribute(scsi.ATTRIBUTE_MEDIUM_MANUFACTURE_DATE, "20240131"),
+			attribute(scsi.ATTRIBUTE_APPLICATION_VENDOR, "IBM     "),
+			attribute(scsi.ATTRIBUTE_APPLICATION_NAME, "LTFS"),
+			attribute(scsi.ATTRIBUTE_APPLICATION_VERSION, "2.4.5.1"),
+			attribute(scsi.ATTRIBUTE_USER_MEDIUM_TEXT_LABEL, "ABC123L8"),
+			attribute(scsi.ATTRIBUTE_BARCODE, "ABC123L8"),
+			lastLoad(0, "IBM", "1013000101"),
+			lastLoad(1, "HPE", "HU1908"),
+			attribute(scsi.ATTRIBUTE_LAST_LOAD_DEVICE+2, "\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"),
+			lastLoad(3, "IBM", "1013000100"),
+			// Past the last load device entries
+			lastLoad(4, "IBM", "1013000199"),
+		),
+		1: attributeList(
+			binaryAttribute(scsi.ATTRIBUTE_REMAINING_CAPACITY, 17000000, 8),
+			binaryAttribute(scsi.ATTRIBUTE_MAXIMUM_CAPACITY, 17500000, 8),
+			// Medium attributes are only taken from the first partition
+			binaryAttribute(scsi.ATTRIBUTE_LOAD_COUNT, 99, 8),
+			attribute(scsi.ATTRIBUTE_BARCODE, "WRONG"),
+		),
+	}
+
+	dev := scsitest.New()
+	dev.Handle(OPCODE_READ_ATTRIBUTE, func(cdb []byte, todev []byte) ([]byte, error) {
+		if cdb[1] == scsi.READ_ATTRIBUTE_PARTITION_LIST {
+			return []byte{0x00, 0x02, 0x00, 0x02}, nil
+		}
+		return partitions[cdb[7]], nil
+	})
+
+	mam, err := scsi.NewDevice(dev).MediumAuxiliaryMemory()
+	if err != nil {
+		t.Fatalf("MediumAuxiliaryMemory: %v", err)
+	}
+
+	want := scsi.MediumAuxiliaryMemory{
+		Manufacturer:    "FUJIFILM",
+		SerialNumber:    "0123456789",
+		ManufactureDate: "20240131",
+		Barcode:         "ABC123L8",
+		DensityCode:     0x5A,
+		LoadCount:       17,
+		TotalWrittenMiB: 1000,
+		TotalReadMiB:    2000,
+		Partitions: []scsi.PartitionCapacity{
+			{Partition: 0, RemainingMiB: 35000, MaximumMiB: 36000},
+			{Partition: 1, RemainingMiB: 17000000, MaximumMiB: 17500000},
+		},
+		LastLoads:          []string{"IBM 1013000101", "HPE HU1908", "IBM 1013000100"},
+		ApplicationVendor:  "IBM",
+		ApplicationName:    "LTFS",
+		ApplicationVersion: "2.4.5.1",
+		VolumeLabel:        "ABC123L8",
+	}
+	if !reflect.DeepEqual(*mam, want) {
+		t.Errorf("got %+v, want %+v", *mam, want)
+	}
+
+	if remaining := mam.RemainingMiB(); remaining != 17035000 {
+		t.Errorf("RemainingMiB: got %d, want 17035000", remaining)
+	}
+	if maximum := mam.MaximumMiB(); maximum != 17536000 {
+		t.Errorf("MaximumMiB: got %d, want 17536000", maximum)
+	}
+	if application := mam.Application(); application != "IBM LTFS 2.4.5.1" {
+		t.Errorf("Application: got %q", application)
+	}
+}
+
+func TestHasLTFSVolume(t *testing.T) {
+	tests := []struct {
+		application string
+		want        bool
+	}{
+		{"LTFS", true},
+		{"ltfs", true},
+		{"", false},
+		{"LTFS-SDE", false},
+		{"Backup Exec", false},
+	}
+
+	for _, tt := range tests {
+		mam := &scsi.MediumAuxiliaryMemory{ApplicationName: tt.application}
+		if got := mam.HasLTFSVolume(); got != tt.want {
+			t.Errorf("HasLTFSVolume with application %q: got %v, want %v", tt.application, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package scsi
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	REPORT_DENSITY_SUPPORT = 0x44
+
+	REPORT_DENSITY_MAX_LENGTH = 0x2000
+	DENSITY_DESCRIPTOR_LENGTH = 52
+
+	// Bits of the third descriptor byte
+	DENSITY_FLAG_WRITE_OK = 7
+	DENSITY_FLAG_DEFAULT  = 5
+)
+
+// Density is a density support descriptor, capacity is in bytes
+type Density struct {
+	PrimaryCode   uint8  `json:"primary-code"`
+	SecondaryCode uint8  `json:"secondary-code"`
+	Writable      bool   `json:"writable"`
+	Default       bool   `json:"default"`
+	Capacity      int64  `json:"capacity"`
+	Organization  string `json:"organization"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+}
+
+func (d *Density) String() string {
+	access := "read-only"
+	if d.Writable {
+		access = "read-write"
+	}
+	return fmt.Sprintf("%s (%#02x, %s)", d.Name, d.PrimaryCode, access)
+}
+
+// ReportDensitySupport lists the densities the drive supports, or with
+// media set, the densities of the loaded medium
+func (d *SCSIDevice) ReportDensitySupport(media bool) ([]Density, error) {
+	resp, err := d.request([]byte{
+		REPORT_DENSITY_SUPPORT,
+		boolToFlag(media, 0),
+		0x00, 0x00, 0x00, 0x00, 0x00,
+		REPORT_DENSITY_MAX_LENGTH >> 8,
+		REPORT_DENSITY_MAX_LENGTH & 0xFF,
+		0x00,
+	}, REPORT_DENSITY_MAX_LENGTH)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDensitySupport(resp)
+}
+
+func parseDensitySupport(data []byte) ([]Density, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("too short density support response: %d bytes", len(data))
+	}
+
+	end := 2 + (int(data[0])<<8 | int(data[1]))
+	if end > len(data) {
+		end = len(data)
+	}
+
+	// Each descriptor holds the capacity in units of 10^6 bytes, followed by three ASCII fields
+	var densities []Density
+	for pos := 4; pos+DENSITY_DESCRIPTOR_LENGTH <= end; pos += DENSITY_DESCRIPTOR_LENGTH {
+		desc := data[pos : pos+DENSITY_DESCRIPTOR_LENGTH]
+		densities = append(densities, Density{
+			PrimaryCode:   desc[0],
+			SecondaryCode: desc[1],
+			Writable:      flagToBool(desc[2], DENSITY_FLAG_WRITE_OK),
+			Default:       flagToBool(desc[2], DENSITY_FLAG_DEFAULT),
+			Capacity:      int64(uint32(desc[12])<<24|uint32(desc[13])<<16|uint32(desc[14])<<8|uint32(desc[15])) * 1000 * 1000,
+			Organization:  string(bytes.TrimRight(desc[16:24], "\x00 ")),
+			Name:          string(bytes.TrimRight(desc[24:32], "\x00 ")),
+			Description:   string(bytes.TrimRight(desc[32:52], "\x00 ")),
+		})
+	}
+	return densities, nil
+}
@@ -0,0 +1,93 @@
+package scsi_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/scsitest"
+)
+
+// densityDescriptor encodes a density support descriptor with the capacity in units of 10^6 bytes
+func densityDescriptor(primary uint8, flags uint8, capacityMB uint32, name string, description string) []byte {
+	desc := []byte{primary, primary, flags, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x2C, 0x00}
+	desc = binary.BigEndian.AppendUint32(desc, capacityMB)
+	return append(desc, fmt.Sprintf("%-8s%-8s%-20s", "LTO-CVE", name, description)...)
+}
+
+func densityReport(descriptors ...[]byte) []byte {
+	body := bytes.Join(descriptors, nil)
+	resp := binary.BigEndian.AppendUint16(nil, uint16(len(body)+2))
+	return append(append(resp, 0x00, 0x00), body...)
+}
+
+func TestReportDensitySupport(t *testing.T) {
+	lto7 := densityDescriptor(0x5C, 0x80, 6000000, "U-732", "Ultrium 7/16T")
+	typeM := densityDescriptor(0x5D, 0x80, 9000000, "U-732M", "Ultrium M8/16T")
+	lto8 := densityDescriptor(0x5E, 0xA0, 12000000, "U-832", "Ultrium 8/32T")
+	lto6 := densityDescriptor(0x5A, 0x00, 2500000, "U-632", "Ultrium 6/16T")
+
+	tests := []struct {
+		name    string
+		resp    []byte
+		want    []scsi.Density
+		wantErr bool
+	}{
+		{
+			name: "LTO-8 drive",
+			resp: densityReport(lto6, lto7, typeM, lto8),
+			want: []scsi.Density{
+				{PrimaryCode: 0x5A, SecondaryCode: 0x5A, Capacity: 2500 * 1000 * 1000 * 1000, Organization: "LTO-CVE", Name: "U-632", Description: "Ultrium 6/16T"},
+				{PrimaryCode: 0x5C, SecondaryCode: 0x5C, Writable: true, Capacity: 6000 * 1000 * 1000 * 1000, Organization: "LTO-CVE", Name: "U-732", Description: "Ultrium 7/16T"},
+				{PrimaryCode: 0x5D, SecondaryCode: 0x5D, Writable: true, Capacity: 9000 * 1000 * 1000 * 1000, Organization: "LTO-CVE", Name: "U-732M", Description: "Ultrium M8/16T"},
+				{PrimaryCode: 0x5E, SecondaryCode: 0x5E, Writable: true, Default: true, Capacity: 12000 * 1000 * 1000 * 1000, Organization: "LTO-CVE", Name: "U-832", Description: "Ultrium 8/32T"},
+			},
+		},
+		{
+			// The allocation length cut the second descriptor off
+			name: "truncated descriptor",
+			resp: densityReport(lto8, lto7)[:4+52+20],
+			want: []scsi.Density{
+				{PrimaryCode: 0x5E, SecondaryCode: 0x5E, Writable: true, Default: true, Capacity: 12000 * 1000 * 1000 * 1000, Organization: "LTO-CVE", Name: "U-832", Description: "Ultrium 8/32T"},
+			},
+		},
+		{
+			name: "no densities",
+			resp: densityReport(),
+		},
+		{
+			name:    "too short",
+			resp:    []byte{0x00, 0x02},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := scsitest.New()
+			dev.Reply(scsi.REPORT_DENSITY_SUPPORT, tt.resp, nil)
+
+			densities, err := scsi.NewDevice(dev).ReportDensitySupport(true)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %+v, want an error", densities)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReportDensitySupport: %v", err)
+			}
+			if !slices.Equal(densities, tt.want) {
+				t.Errorf("got %+v, want %+v", densities, tt.want)
+			}
+
+			// MEDIA set for the densities of the loaded cartridge
+			if cdb := dev.Requests()[0].CDB; cdb[1] != 0x01 {
+				t.Errorf("got CDB % x, want the MEDIA bit set", cdb)
+			}
+		})
+	}
+}
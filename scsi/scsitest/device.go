@@ -0,0 +1,156 @@
+// Package scsitest provides a scriptable scsi.Transport that records the CDBs
+// it receives and replays canned response buffers.
+package scsitest
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+)
+
+type Handler func(cdb []byte, todev []byte) ([]byte, error)
+
+type Request struct {
+	CDB     []byte
+	Data    []byte
+	Timeout time.Duration
+}
+
+type Device struct {
+	lock     sync.Mutex
+	requests []Request
+	queued   map[uint8][]Handler
+	handlers map[uint8]Handler
+	closed   bool
+	residual int
+}
+
+var _ scsi.Transport = &Device{}
+var _ scsi.ResidualReporter = &Device{}
+
+func New() *Device {
+	return &Device{
+		queued:   make(map[uint8][]Handler),
+		handlers: make(map[uint8]Handler),
+	}
+}
+
+// Open returns an opener handing out the same fake device every time, for
+// consumers that open and close the device per operation
+func (d *Device) Open() scsi.Opener {
+	return func(path string) (*scsi.SCSIDevice, error) {
+		return scsi.NewDevice(d), nil
+	}
+}
+
+// Reply queues a one-shot response for the next command with the given opcode
+func (d *Device) Reply(opcode uint8, response []byte, err error) {
+	d.ReplyFunc(opcode, func(cdb []byte, todev []byte) ([]byte, error) {
+		return response, err
+	})
+}
+
+// ReplyFunc queues a one-shot handler for the next command with the given opcode
+func (d *Device) ReplyFunc(opcode uint8, handler Handler) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.queued[opcode] = append(d.queued[opcode], handler)
+}
+
+// Handle installs a handler used for every command with the given opcode once its queue is empty
+func (d *Device) Handle(opcode uint8, handler Handler) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.handlers[opcode] = handler
+}
+
+// Requests returns every command received so far
+func (d *Device) Requests() []Request {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return append([]Request(nil), d.requests...)
+}
+
+func (d *Device) Close() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.closed = true
+	return nil
+}
+
+func (d *Device) Closed() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.closed
+}
+
+func (d *Device) Request(cdb, fromdev, todev []byte) error {
+	return d.RequestWithTimeout(cdb, fromdev, todev, scsi.DEFAULT_TIMEOUT)
+}
+
+func (d *Device) RequestWithTimeout(cdb, fromdev, todev []byte, timeout time.Duration) error {
+	d.lock.Lock()
+	d.residual = 0
+	d.requests = append(d.requests, Request{
+		CDB:     append([]byte(nil), cdb...),
+		Data:    append([]byte(nil), todev...),
+		Timeout: timeout,
+	})
+
+	opcode := cdb[0]
+	handler := d.handlers[opcode]
+	if queue := d.queued[opcode]; len(queue) > 0 {
+		handler = queue[0]
+		d.queued[opcode] = queue[1:]
+	}
+	d.lock.Unlock()
+
+	if handler == nil {
+		return fmt.Errorf("scsitest: no response scripted for opcode %#02x", opcode)
+	}
+
+	resp, err := handler(cdb, todev)
+	if err != nil {
+		return err
+	}
+
+	// Like a real device, never transfer more than the allocation length
+	transferred := copy(fromdev, resp)
+	d.lock.Lock()
+	d.residual = len(fromdev) - transferred
+	d.lock.Unlock()
+	return nil
+}
+
+func (d *Device) Residual() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.residual
+}
+
+// CheckCondition builds the error a real transport returns for a fixed format sense response
+func CheckCondition(opcode uint8, key scsi.SenseKey, asc uint8, ascq uint8) error {
+	sense, err := scsi.ParseSense([]byte{
+		0x70, 0x00, byte(key), 0x00, 0x00, 0x00, 0x00, 0x0A,
+		0x00, 0x00, 0x00, 0x00, asc, ascq, 0x00, 0x00, 0x00, 0x00,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return &scsi.SenseError{
+		Opcode: opcode,
+		Sense:  sense,
+	}
+}
+
+func mustDecodeHex(str string) []byte {
+	data, err := hex.DecodeString(strings.ReplaceAll(str, " ", ""))
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
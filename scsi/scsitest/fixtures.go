@@ -0,0 +1,118 @@
+package scsitest
+
+// These response buffers are synthetic: they were assembled by hand following the
+// element status and mode page layouts in the vendors' SCSI reference manuals, not
+// captured from real devices. Serial numbers and barcodes are made up. The names say
+// which library a layout imitates, they do not vouch for its firmware behaving like it.
+// Swap in real captures (e.g. from sg_raw -r) as they become available.
+
+var (
+	// READ ELEMENT STATUS (data transfer, DVCID) of a two drive IBM TS4300 layout: T10 vendor identifiers "IBM     ULT3580-TD8     <serial>", drive 0x100 loaded from slot 0x1002
+	IBM_TS4300_DATA_TRANSFER = mustDecodeHex(
+		"01000002000000b404800056000000ac01000900000000000081100249424d31" +
+			"30324c3820202020202020202020202020202020202020202020202000000000" +
+			"0201002249424d2020202020554c54333538302d544438202020202031303133" +
+			"3030303130300101080000000000000000000000000000000000000000000000" +
+			"000000000000000000000000000000000000000000000201002249424d202020" +
+			"2020554c54333538302d544438202020202031303133303030313031",
+	)
+
+	// READ ELEMENT STATUS (data transfer, DVCID) of three drives identified differently: a binary NAA designator at 0x100, a vendor specific ASCII serial at 0x101 and a T10 vendor identifier truncated to its first two bytes at 0x102
+	MIXED_IDENTIFIER_DATA_TRANSFER = mustDecodeHex(
+		"01000003000000d404800044000000cc01000800000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"0103000850050763124b0a110000000000000000010108000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"000000000200000a4855313930383746344b0000000000000102080000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000201000248500000000000000000000000000000",
+	)
+
+	// READ ELEMENT STATUS (all types, PVolTag) of the same IBM TS4300 layout: one transport, eight storage slots at 0x1000, two I/E slots at 0x300 and two drives
+	IBM_TS4300_ALL = mustDecodeHex(
+		"0001000d000002c4018000340000003400010800000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000002800034000001a010000900000000000001000049424d3130304c38" +
+			"2020202020202020202020202020202020202020202020200000000000000000" +
+			"10010900000000000001000049424d3130314c38202020202020202020202020" +
+			"2020202020202020202020200000000000000000100208000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"000000000000000010030900000000000001000049424d3130334c3820202020" +
+			"2020202020202020202020202020202020202020000000000000000010040800" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000010050900000000000001000049424d31" +
+			"30354c3820202020202020202020202020202020202020202020202000000000" +
+			"0000000010060800000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000001007080000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"000000000000000000000000038000340000006803003b000000000000010000" +
+			"49424d3930304c37202020202020202020202020202020202020202020202020" +
+			"000000000000000003013a000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000004800034" +
+			"0000006801000900000000000081100249424d3130324c382020202020202020" +
+			"2020202020202020202020202020202000000000000000000101080000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"000000000000000000000000",
+	)
+
+	// READ ELEMENT STATUS (data transfer, DVCID) of an HPE MSL3040 layout with 88 byte descriptors, drives at 0x1F4
+	HPE_MSL3040_DATA_TRANSFER = mustDecodeHex(
+		"01f40002000000b804800058000000b001f40800000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"020100224850452020202020556c747269756d20392d53435349202044454339" +
+			"313233343030000001f5090000000000008103e94850453031374c3920202020" +
+			"2020202020202020202020202020202020202020000000000201002248504520" +
+			"20202020556c747269756d20392d534353492020444543393132333430310000",
+	)
+
+	// READ ELEMENT STATUS (all types, PVolTag) of the HPE MSL3040 layout, reporting the I/E page before the storage page; the second mailslot holds a cleaning cartridge
+	HPE_MSL3040_ALL = mustDecodeHex(
+		"00100008000001b0038000340000006800103a00000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000113b000000000000020000434c4e30303143552020202020202020" +
+			"2020202020202020202020202020202000000000000000000280003400000138" +
+			"03e8090000000000000100004850453031364c39202020202020202020202020" +
+			"202020202020202020202020000000000000000003e908000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"000000000000000003ea090000000000000100004850453031384c3920202020" +
+			"2020202020202020202020202020202020202020000000000000000003eb0800" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000003ec0900000000000001000048504530" +
+			"32304c3920202020202020202020202020202020202020202020202000000000" +
+			"0000000003ed0800000000000000000000000000000000000000000000000000" +
+			"000000000000000000000000000000000000000000000000",
+	)
+
+	// READ ELEMENT STATUS (all types, PVolTag, DVCID) of a Quantum Scalar i3 layout, which returns an empty identification header for storage elements; drive 0x101 reports an exception (magazine removed)
+	QUANTUM_SCALAR_I3_ALL = mustDecodeHex(
+		"01000006000001a004800058000000b001000900000000000081100251544d30" +
+			"30324c3820202020202020202020202020202020202020202020202000000000" +
+			"020100225155414e54554d20554c545249554d2d484838202020202031305754" +
+			"303435363132000001010c003b12000000000000000000000000000000000000" +
+			"000000000000000000000000000000000000000000000000020100225155414e" +
+			"54554d20554c545249554d2d4848382020202020313057543034353631330000" +
+			"02800038000000e010000900000000000001000051544d3030304c3820202020" +
+			"2020202020202020202020202020202020202020000000000000000000000000" +
+			"10010900000000000001000051544d3030314c38202020202020202020202020" +
+			"2020202020202020202020200000000000000000000000001002080000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000010030900000000000001000051544d30" +
+			"30334c3820202020202020202020202020202020202020202020202000000000" +
+			"0000000000000000",
+	)
+
+	// QUANTUM_SCALAR_I3_ALL cut off in the middle of the storage page, as returned when the allocation length is too small
+	QUANTUM_SCALAR_I3_TRUNCATED = mustDecodeHex(
+		"01000006000001a004800058000000b001000900000000000081100251544d30" +
+			"30324c3820202020202020202020202020202020202020202020202000000000" +
+			"020100225155414e54554d20554c545249554d2d484838202020202031305754" +
+			"303435363132000001010c003b12000000000000000000000000000000000000" +
+			"00000000000000000000000000000000",
+	)
+
+	// MODE SENSE(6) page 0x1D (element address assignment) of the IBM TS4300 layout
+	IBM_TS4300_ELEMENT_ADDRESS_ASSIGNMENT = mustDecodeHex(
+		"17000000" +
+			"9d12" + "00010001" + "10000008" + "03000002" + "01000002" + "0000",
+	)
+)
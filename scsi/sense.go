@@ -0,0 +1,289 @@
+package scsi
+
+import (
+	"errors"
+	"fmt"
+)
+
+type SenseKey uint8
+
+const (
+	SENSE_KEY_NO_SENSE        SenseKey = 0x00
+	SENSE_KEY_RECOVERED_ERROR SenseKey = 0x01
+	SENSE_KEY_NOT_READY       SenseKey = 0x02
+	SENSE_KEY_MEDIUM_ERROR    SenseKey = 0x03
+	SENSE_KEY_HARDWARE_ERROR  SenseKey = 0x04
+	SENSE_KEY_ILLEGAL_REQUEST SenseKey = 0x05
+	SENSE_KEY_UNIT_ATTENTION  SenseKey = 0x06
+	SENSE_KEY_DATA_PROTECT    SenseKey = 0x07
+	SENSE_KEY_BLANK_CHECK     SenseKey = 0x08
+	SENSE_KEY_VENDOR_SPECIFIC SenseKey = 0x09
+	SENSE_KEY_COPY_ABORTED    SenseKey = 0x0A
+	SENSE_KEY_ABORTED_COMMAND SenseKey = 0x0B
+	SENSE_KEY_VOLUME_OVERFLOW SenseKey = 0x0D
+	SENSE_KEY_MISCOMPARE      SenseKey = 0x0E
+	SENSE_KEY_COMPLETED       SenseKey = 0x0F
+)
+
+func (k SenseKey) String() string {
+	switch k {
+	case SENSE_KEY_NO_SENSE:
+		return "No Sense"
+	case SENSE_KEY_RECOVERED_ERROR:
+		return "Recovered Error"
+	case SENSE_KEY_NOT_READY:
+		return "Not Ready"
+	case SENSE_KEY_MEDIUM_ERROR:
+		return "Medium Error"
+	case SENSE_KEY_HARDWARE_ERROR:
+		return "Hardware Error"
+	case SENSE_KEY_ILLEGAL_REQUEST:
+		return "Illegal Request"
+	case SENSE_KEY_UNIT_ATTENTION:
+		return "Unit Attention"
+	case SENSE_KEY_DATA_PROTECT:
+		return "Data Protect"
+	case SENSE_KEY_BLANK_CHECK:
+		return "Blank Check"
+	case SENSE_KEY_VENDOR_SPECIFIC:
+		return "Vendor Specific"
+	case SENSE_KEY_COPY_ABORTED:
+		return "Copy Aborted"
+	case SENSE_KEY_ABORTED_COMMAND:
+		return "Aborted Command"
+	case SENSE_KEY_VOLUME_OVERFLOW:
+		return "Volume Overflow"
+	case SENSE_KEY_MISCOMPARE:
+		return "Miscompare"
+	case SENSE_KEY_COMPLETED:
+		return "Completed"
+	default:
+		return "Unknown"
+	}
+}
+
+var (
+	ErrNoSense        = errors.New("scsi: no sense")
+	ErrRecoveredError = errors.New("scsi: recovered error")
+	ErrNotReady       = errors.New("scsi: not ready")
+	ErrMediumError    = errors.New("scsi: medium error")
+	ErrHardwareError  = errors.New("scsi: hardware error")
+	ErrIllegalRequest = errors.New("scsi: illegal request")
+	ErrUnitAttention  = errors.New("scsi: unit attention")
+	ErrDataProtect    = errors.New("scsi: data protect")
+	ErrBlankCheck     = errors.New("scsi: blank check")
+	ErrAbortedCommand = errors.New("scsi: aborted command")
+	ErrVolumeOverflow = errors.New("scsi: volume overflow")
+
+	// Conditions identified by ASC/ASCQ, independent of the sense key they are reported with
+	ErrMediumNotPresent       = errors.New("scsi: medium not present")
+	ErrBecomingReady          = errors.New("scsi: logical unit is in process of becoming ready")
+	ErrDoorOpen               = errors.New("scsi: library door or magazine open")
+	ErrSourceElementEmpty     = errors.New("scsi: medium source element empty")
+	ErrDestinationElementFull = errors.New("scsi: medium destination element full")
+	ErrIncompatibleMedium     = errors.New("scsi: incompatible medium installed")
+	ErrCleaningCartridge      = errors.New("scsi: cleaning cartridge installed")
+	ErrCleaningFailure        = errors.New("scsi: cleaning failure")
+	ErrMediumRemovalPrevented = errors.New("scsi: medium removal prevented")
+)
+
+var senseKeyErrors = map[SenseKey]error{
+	SENSE_KEY_NO_SENSE:        ErrNoSense,
+	SENSE_KEY_RECOVERED_ERROR: ErrRecoveredError,
+	SENSE_KEY_NOT_READY:       ErrNotReady,
+	SENSE_KEY_MEDIUM_ERROR:    ErrMediumError,
+	SENSE_KEY_HARDWARE_ERROR:  ErrHardwareError,
+	SENSE_KEY_ILLEGAL_REQUEST: ErrIllegalRequest,
+	SENSE_KEY_UNIT_ATTENTION:  ErrUnitAttention,
+	SENSE_KEY_DATA_PROTECT:    ErrDataProtect,
+	SENSE_KEY_BLANK_CHECK:     ErrBlankCheck,
+	SENSE_KEY_ABORTED_COMMAND: ErrAbortedCommand,
+	SENSE_KEY_VOLUME_OVERFLOW: ErrVolumeOverflow,
+}
+
+type Sense struct {
+	ResponseCode uint8
+	Deferred     bool
+	Key          SenseKey
+	ASC          uint8
+	ASCQ         uint8
+
+	InformationValid bool
+	Information      uint64
+
+	FileMark         bool
+	EndOfMedium      bool
+	IncorrectLength  bool
+	SenseKeySpecific []byte
+}
+
+func ParseSense(data []byte) (*Sense, error) {
+	if len(data) < 1 {
+		return nil, errors.New("empty sense data")
+	}
+
+	responseCode := data[0] & 0x7F
+	switch responseCode {
+	case 0x70, 0x71:
+		return parseFixedSense(data)
+	case 0x72, 0x73:
+		return parseDescriptorSense(data)
+	default:
+		return nil, fmt.Errorf("unknown sense response code %#02x", responseCode)
+	}
+}
+
+func parseFixedSense(data []byte) (*Sense, error) {
+	if len(data) < 3 {
+		return nil, fmt.Errorf("too small fixed format sense data: expected >= 3, got %d", len(data))
+	}
+
+	sense := &Sense{
+		ResponseCode:     data[0] & 0x7F,
+		Deferred:         data[0]&0x7F == 0x71,
+		Key:              SenseKey(data[2] & 0x0F),
+		FileMark:         flagToBool(data[2], 7),
+		EndOfMedium:      flagToBool(data[2], 6),
+		IncorrectLength:  flagToBool(data[2], 5),
+		InformationValid: flagToBool(data[0], 7),
+	}
+
+	if len(data) >= 7 {
+		sense.Information = uint64(data[3])<<24 | uint64(data[4])<<16 | uint64(data[5])<<8 | uint64(data[6])
+	} else {
+		sense.InformationValid = false
+	}
+
+	if len(data) >= 14 {
+		sense.ASC = data[12]
+		sense.ASCQ = data[13]
+	}
+
+	if len(data) >= 18 && flagToBool(data[15], 7) {
+		sense.SenseKeySpecific = data[15:18]
+	}
+
+	return sense, nil
+}
+
+func parseDescriptorSense(data []byte) (*Sense, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("too small descriptor format sense data: expected >= 8, got %d", len(data))
+	}
+
+	sense := &Sense{
+		ResponseCode: data[0] & 0x7F,
+		Deferred:     data[0]&0x7F == 0x73,
+		Key:          SenseKey(data[1] & 0x0F),
+		ASC:          data[2],
+		ASCQ:         data[3],
+	}
+
+	end := 8 + int(data[7])
+	if end > len(data) {
+		end = len(data)
+	}
+
+	pos := 8
+	for pos+2 <= end {
+		descType := data[pos]
+		descEnd := pos + 2 + int(data[pos+1])
+		if descEnd > end {
+			break
+		}
+		desc := data[pos:descEnd]
+
+		switch descType {
+		case 0x00: // Information
+			if len(desc) >= 12 {
+				sense.InformationValid = flagToBool(desc[2], 7)
+				for _, b := range desc[4:12] {
+					sense.Information = sense.Information<<8 | uint64(b)
+				}
+			}
+		case 0x02: // Sense key specific
+			if len(desc) >= 7 && flagToBool(desc[4], 7) {
+				sense.SenseKeySpecific = desc[4:7]
+			}
+		case 0x04: // Stream commands
+			if len(desc) >= 4 {
+				sense.FileMark = flagToBool(desc[3], 7)
+				sense.EndOfMedium = flagToBool(desc[3], 6)
+				sense.IncorrectLength = flagToBool(desc[3], 5)
+			}
+		}
+
+		pos = descEnd
+	}
+
+	return sense, nil
+}
+
+func (s *Sense) Description() string {
+	return DescribeASC(s.ASC, s.ASCQ)
+}
+
+// Condition returns the sentinel error for well-known ASC/ASCQ combinations, or nil
+func (s *Sense) Condition() error {
+	switch {
+	case s.ASC == 0x3A:
+		return ErrMediumNotPresent
+	case s.ASC == 0x04 && s.ASCQ == 0x01:
+		return ErrBecomingReady
+	case s.ASC == 0x3B && (s.ASCQ == 0x12 || s.ASCQ == 0x13 || s.ASCQ == 0x14 || s.ASCQ == 0x15):
+		return ErrDoorOpen
+	case s.ASC == 0x04 && s.ASCQ == 0x83: // Vendor specific, used by IBM and Quantum libraries
+		return ErrDoorOpen
+	case s.ASC == 0x3B && s.ASCQ == 0x0E:
+		return ErrSourceElementEmpty
+	case s.ASC == 0x3B && s.ASCQ == 0x0D:
+		return ErrDestinationElementFull
+	case s.ASC == 0x30 && s.ASCQ <= 0x02:
+		return ErrIncompatibleMedium
+	case s.ASC == 0x30 && s.ASCQ == 0x03:
+		return ErrCleaningCartridge
+	case s.ASC == 0x30 && (s.ASCQ == 0x07 || s.ASCQ == 0x0A):
+		return ErrCleaningFailure
+	case s.ASC == 0x53 && (s.ASCQ == 0x02 || s.ASCQ == 0x03):
+		return ErrMediumRemovalPrevented
+	default:
+		return nil
+	}
+}
+
+func (s *Sense) String() string {
+	str := fmt.Sprintf("%s: %s (ASC %#02x, ASCQ %#02x)", s.Key, s.Description(), s.ASC, s.ASCQ)
+	if s.InformationValid {
+		str += fmt.Sprintf(", information %d", s.Information)
+	}
+	if s.Deferred {
+		str += " [deferred]"
+	}
+	return str
+}
+
+type SenseError struct {
+	Opcode uint8
+	Sense  *Sense
+}
+
+func (e *SenseError) Error() string {
+	return fmt.Sprintf("scsi error: opcode %#02x: %s", e.Opcode, e.Sense)
+}
+
+func (e *SenseError) Is(target error) bool {
+	if senseKeyErrors[e.Sense.Key] == target {
+		return true
+	}
+	cond := e.Sense.Condition()
+	return cond != nil && cond == target
+}
+
+// GetSense returns the decoded sense data carried by err, if any
+func GetSense(err error) *Sense {
+	var senseErr *SenseError
+	if errors.As(err, &senseErr) {
+		return senseErr.Sense
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package scsi_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+)
+
+func TestParseSense(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want scsi.Sense
+	}{
+		{
+			name: "fixed illegal request",
+			data: []byte{0x70, 0x00, 0x05, 0x00, 0x00, 0x00, 0x00, 0x0A, 0x00, 0x00, 0x00, 0x00, 0x3B, 0x0E, 0x00, 0x00, 0x00, 0x00},
+			want: scsi.Sense{ResponseCode: 0x70, Key: scsi.SENSE_KEY_ILLEGAL_REQUEST, ASC: 0x3B, ASCQ: 0x0E},
+		},
+		{
+			name: "fixed filemark with information",
+			data: []byte{0xF0, 0x00, 0x80, 0x00, 0x00, 0x01, 0x02, 0x0A, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00},
+			want: scsi.Sense{ResponseCode: 0x70, Key: scsi.SENSE_KEY_NO_SENSE, ASCQ: 0x01, FileMark: true, InformationValid: true, Information: 0x0102},
+		},
+		{
+			name: "fixed deferred with sense key specific",
+			data: []byte{0x71, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00, 0x0A, 0x00, 0x00, 0x00, 0x00, 0x44, 0x00, 0x00, 0x80, 0x12, 0x34},
+			want: scsi.Sense{ResponseCode: 0x71, Deferred: true, Key: scsi.SENSE_KEY_HARDWARE_ERROR, ASC: 0x44, SenseKeySpecific: []byte{0x80, 0x12, 0x34}},
+		},
+		{
+			name: "fixed without additional sense bytes",
+			data: []byte{0xF0, 0x00, 0x06, 0x00, 0x00},
+			want: scsi.Sense{ResponseCode: 0x70, Key: scsi.SENSE_KEY_UNIT_ATTENTION},
+		},
+		{
+			name: "descriptor with information",
+			data: []byte{
+				0x72, 0x02, 0x3A, 0x00, 0x00, 0x00, 0x00, 0x0C,
+				0x00, 0x0A, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x07,
+			},
+			want: scsi.Sense{ResponseCode: 0x72, Key: scsi.SENSE_KEY_NOT_READY, ASC: 0x3A, InformationValid: true, Information: 7},
+		},
+		{
+			name: "descriptor with stream commands and sense key specific",
+			data: []byte{
+				0x73, 0x03, 0x00, 0x02, 0x00, 0x00, 0x00, 0x0C,
+				0x04, 0x02, 0x00, 0xC0,
+				0x02, 0x06, 0x00, 0x00, 0x80, 0x00, 0x10, 0x00,
+			},
+			want: scsi.Sense{ResponseCode: 0x73, Deferred: true, Key: scsi.SENSE_KEY_MEDIUM_ERROR, ASCQ: 0x02, FileMark: true, EndOfMedium: true, SenseKeySpecific: []byte{0x80, 0x00, 0x10}},
+		},
+		{
+			name: "descriptor cut off within a descriptor",
+			data: []byte{
+				0x72, 0x05, 0x24, 0x00, 0x00, 0x00, 0x00, 0x0C,
+				0x00, 0x0A, 0x80, 0x00,
+			},
+			want: scsi.Sense{ResponseCode: 0x72, Key: scsi.SENSE_KEY_ILLEGAL_REQUEST, ASC: 0x24},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sense, err := scsi.ParseSense(test.data)
+			if err != nil {
+				t.Fatalf("ParseSense: %v", err)
+			}
+			if !bytes.Equal(sense.SenseKeySpecific, test.want.SenseKeySpecific) {
+				t.Errorf("sense key specific %x, want %x", sense.SenseKeySpecific, test.want.SenseKeySpecific)
+			}
+			sense.SenseKeySpecific = nil
+			test.want.SenseKeySpecific = nil
+			if !reflect.DeepEqual(*sense, test.want) {
+				t.Errorf("got %+v, want %+v", *sense, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSenseInvalid(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"empty":              {},
+		"unknown code":       {0x7F, 0x00, 0x05},
+		"short fixed":        {0x70, 0x00},
+		"short descriptor":   {0x72, 0x05, 0x24, 0x00},
+		"vendor format code": {0x7E},
+	} {
+		_, err := scsi.ParseSense(data)
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSenseErrorIs(t *testing.T) {
+	tests := []struct {
+		key       scsi.SenseKey
+		asc       uint8
+		ascq      uint8
+		keyErr    error
+		condition error
+	}{
+		{scsi.SENSE_KEY_NOT_READY, 0x3A, 0x00, scsi.ErrNotReady, scsi.ErrMediumNotPresent},
+		{scsi.SENSE_KEY_NOT_READY, 0x3A, 0x02, scsi.ErrNotReady, scsi.ErrMediumNotPresent},
+		{scsi.SENSE_KEY_NOT_READY, 0x04, 0x01, scsi.ErrNotReady, scsi.ErrBecomingReady},
+		{scsi.SENSE_KEY_NOT_READY, 0x04, 0x83, scsi.ErrNotReady, scsi.ErrDoorOpen},
+		{scsi.SENSE_KEY_NOT_READY, 0x3B, 0x12, scsi.ErrNotReady, scsi.ErrDoorOpen},
+		{scsi.SENSE_KEY_ILLEGAL_REQUEST, 0x3B, 0x0E, scsi.ErrIllegalRequest, scsi.ErrSourceElementEmpty},
+		{scsi.SENSE_KEY_ILLEGAL_REQUEST, 0x3B, 0x0D, scsi.ErrIllegalRequest, scsi.ErrDestinationElementFull},
+		{scsi.SENSE_KEY_ILLEGAL_REQUEST, 0x53, 0x02, scsi.ErrIllegalRequest, scsi.ErrMediumRemovalPrevented},
+		{scsi.SENSE_KEY_MEDIUM_ERROR, 0x30, 0x00, scsi.ErrMediumError, scsi.ErrIncompatibleMedium},
+		{scsi.SENSE_KEY_MEDIUM_ERROR, 0x30, 0x03, scsi.ErrMediumError, scsi.ErrCleaningCartridge},
+		{scsi.SENSE_KEY_MEDIUM_ERROR, 0x30, 0x07, scsi.ErrMediumError, scsi.ErrCleaningFailure},
+		{scsi.SENSE_KEY_DATA_PROTECT, 0x27, 0x00, scsi.ErrDataProtect, nil},
+		{scsi.SENSE_KEY_UNIT_ATTENTION, 0x28, 0x00, scsi.ErrUnitAttention, nil},
+	}
+
+	for _, test := range tests {
+		err := &scsi.SenseError{
+			Opcode: 0xA5,
+			Sense:  &scsi.Sense{Key: test.key, ASC: test.asc, ASCQ: test.ascq},
+		}
+		if !errors.Is(err, test.keyErr) {
+			t.Errorf("%v: not %v", err, test.keyErr)
+		}
+		if got := err.Sense.Condition(); got != test.condition {
+			t.Errorf("%v: condition %v, want %v", err, got, test.condition)
+		}
+		if test.condition != nil && !errors.Is(err, test.condition) {
+			t.Errorf("%v: not %v", err, test.condition)
+		}
+		if errors.Is(err, scsi.ErrHardwareError) {
+			t.Errorf("%v: matches an unrelated sense key", err)
+		}
+		if scsi.GetSense(errors.Join(errors.New("wrapped"), err)) != err.Sense {
+			t.Errorf("%v: GetSense does not unwrap it", err)
+		}
+	}
+}
+
+func TestDescribeASC(t *testing.T) {
+	tests := []struct {
+		asc  uint8
+		ascq uint8
+		want string
+	}{
+		{0x3A, 0x00, "Medium not present"},
+		{0x3B, 0x0E, "Medium source element empty"},
+		{0x04, 0x83, "Logical unit not ready, door open"},
+		{0x04, 0x8F, "Logical unit not ready, cause not reportable (vendor specific ASCQ 0x8f)"},
+		{0x84, 0x01, "Vendor specific ASC 0x84, ASCQ 0x01"},
+		{0x7F, 0x7F, "Unknown ASC 0x7f, ASCQ 0x7f"},
+	}
+
+	for _, test := range tests {
+		if got := scsi.DescribeASC(test.asc, test.ascq); got != test.want {
+			t.Errorf("DescribeASC(%#02x, %#02x) = %q, want %q", test.asc, test.ascq, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package scsi
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+	"unsafe"
+
+	"github.com/FoxDenHome/goscsi/godefs/sg"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	SENSE_BUFFER_LENGTH = 96
+	// DEFAULT_TIMEOUT is for commands answered from the device's memory, slow commands pass their own
+	DEFAULT_TIMEOUT = time.Second * 5
+
+	STATUS_CHECK_CONDITION = 0x02
+)
+
+// sgDevice is a minimal SG_IO (v3 header) transport.
+// Unlike goscsi's own transport it keeps the sense data of failed commands
+// and hands it back as a *SenseError.
+type sgDevice struct {
+	fd       int
+	residual int
+}
+
+func openSG(path string) (*sgDevice, error) {
+	fd, err := unix.Open(path, unix.O_RDWR|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &sgDevice{fd: fd}, nil
+}
+
+func (d *sgDevice) Close() error {
+	return unix.Close(d.fd)
+}
+
+func (d *sgDevice) Residual() int {
+	return d.residual
+}
+
+func (d *sgDevice) Request(cdb, fromdev, todev []byte) error {
+	return d.RequestWithTimeout(cdb, fromdev, todev, DEFAULT_TIMEOUT)
+}
+
+func (d *sgDevice) RequestWithTimeout(cdb, fromdev, todev []byte, timeout time.Duration) error {
+	sense := make([]byte, SENSE_BUFFER_LENGTH)
+
+	hdr := sg.SgIoHdr{
+		InterfaceId:    'S',
+		DxferDirection: sg.SG_DXFER_NONE,
+		CmdLen:         uint8(len(cdb)),
+		MxSbLen:        uint8(len(sense)),
+		Cmdp:           &cdb[0],
+		Sbp:            &sense[0],
+		Timeout:        uint32(timeout.Milliseconds()),
+	}
+
+	if len(todev) > 0 {
+		hdr.DxferDirection = sg.SG_DXFER_TO_DEV
+		hdr.DxferLen = uint32(len(todev))
+		hdr.Dxferp = &todev[0]
+	} else if len(fromdev) > 0 {
+		hdr.DxferDirection = sg.SG_DXFER_FROM_DEV
+		hdr.DxferLen = uint32(len(fromdev))
+		hdr.Dxferp = &fromdev[0]
+	}
+
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(d.fd), sg.SG_IO, uintptr(unsafe.Pointer(&hdr)))
+	runtime.KeepAlive(cdb)
+	runtime.KeepAlive(fromdev)
+	runtime.KeepAlive(todev)
+	runtime.KeepAlive(sense)
+	if errno != 0 {
+		return errno
+	}
+	d.residual = int(hdr.Resid)
+
+	if hdr.Info&sg.SG_INFO_OK_MASK == sg.SG_INFO_OK {
+		return nil
+	}
+
+	if hdr.Status == STATUS_CHECK_CONDITION && hdr.SbLenWr > 0 {
+		parsed, err := ParseSense(sense[:hdr.SbLenWr])
+		if err != nil {
+			return fmt.Errorf("scsi error: opcode %#02x: unparseable sense %x: %w", cdb[0], sense[:hdr.SbLenWr], err)
+		}
+		return &SenseError{
+			Opcode: cdb[0],
+			Sense:  parsed,
+		}
+	}
+
+	if hdr.HostStatus != 0 {
+		return fmt.Errorf("scsi error: opcode %#02x: host status=%#x", cdb[0], hdr.HostStatus)
+	}
+	if hdr.DriverStatus != 0 {
+		return fmt.Errorf("scsi error: opcode %#02x: driver status=%#x", cdb[0], hdr.DriverStatus)
+	}
+	return fmt.Errorf("scsi error: opcode %#02x: scsi status=%#x", cdb[0], hdr.Status)
+}
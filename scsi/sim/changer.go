@@ -0,0 +1,169 @@
+package sim
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+)
+
+func (l *Library) DriveAddress(ident *scsi.DeviceIdentification) (uint16, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for _, drive := range l.state.Drives {
+		if drive.Serial == ident.Serial {
+			return drive.Address, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no drive in loader matches drive %s", ident)
+}
+
+func (l *Library) MoveTapeToDrive(driveAddress uint16, volumeTag string) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	drive, err := l.driveByAddress(driveAddress)
+	if err != nil {
+		return err
+	}
+
+	if drive.VolumeTag == volumeTag {
+		log.Printf("tape %s already in drive %d", volumeTag, driveAddress)
+		return nil
+	}
+
+	for _, elem := range l.slots() {
+		if elem.VolumeTag != volumeTag {
+			continue
+		}
+
+		err = l.moveDriveTapeToStorage(drive)
+		if err != nil {
+			return fmt.Errorf("failed to move previous tape from drive %d to storage: %v", driveAddress, err)
+		}
+
+		log.Printf("moving tape %s from address %d to drive %d", volumeTag, elem.Address, driveAddress)
+		drive.VolumeTag = elem.VolumeTag
+		drive.Source = elem.Address
+		elem.VolumeTag = ""
+		l.recordLoad(drive)
+		return l.save()
+	}
+
+	return fmt.Errorf("no tape found with volume tag %s", volumeTag)
+}
+
+func (l *Library) MoveDriveTapeToStorage(driveAddress uint16) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	drive, err := l.driveByAddress(driveAddress)
+	if err != nil {
+		return err
+	}
+
+	return l.moveDriveTapeToStorage(drive)
+}
+
+func (l *Library) moveDriveTapeToStorage(drive *driveSlot) error {
+	if drive.VolumeTag == "" {
+		return nil
+	}
+
+	err := l.checkRemovable(drive)
+	if err != nil {
+		return err
+	}
+
+	var dest *slot
+	for _, elem := range l.state.Storage {
+		if elem.VolumeTag != "" {
+			continue
+		}
+		if elem.Address == drive.Source {
+			dest = elem
+			break
+		}
+		if dest == nil {
+			dest = elem
+		}
+	}
+
+	if dest == nil {
+		return fmt.Errorf("no free slot found for tape in drive %d", drive.Address)
+	}
+
+	if dest.Address == drive.Source {
+		log.Printf("Moving tape from drive %d back to source %d", drive.Address, dest.Address)
+	} else {
+		log.Printf("Moving tape from drive %d to free slot %d", drive.Address, dest.Address)
+	}
+
+	dest.VolumeTag = drive.VolumeTag
+	drive.VolumeTag = ""
+	drive.Source = 0
+	// Like a real drive with clear key on demount set
+	drive.Encryption = nil
+	return l.save()
+}
+
+// checkRemovable refuses to take the tape out of a locked or mounted drive, like a real library
+func (l *Library) checkRemovable(drive *driveSlot) error {
+	if drive.Locked {
+		return fmt.Errorf("cannot remove tape %s from drive %d: %w", drive.VolumeTag, drive.Address, scsi.ErrMediumRemovalPrevented)
+	}
+
+	for i, d := range l.state.Drives {
+		if d == drive && l.drives[i].mounted {
+			return fmt.Errorf("cannot remove tape %s from drive %d while it is mounted", drive.VolumeTag, drive.Address)
+		}
+	}
+	return nil
+}
+
+// PreventMediumRemoval only records the lock, the simulated library has no magazines to open
+func (l *Library) PreventMediumRemoval(prevent bool) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.state.Locked = prevent
+	return l.save()
+}
+
+func (l *Library) GetVolumeTags() ([]string, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var barcodes []string
+	for _, elem := range l.slots() {
+		if elem.VolumeTag != "" && !element.IsCleaningVolumeTag(elem.VolumeTag) {
+			barcodes = append(barcodes, elem.VolumeTag)
+		}
+	}
+	for _, drive := range l.state.Drives {
+		if drive.VolumeTag != "" && !element.IsCleaningVolumeTag(drive.VolumeTag) {
+			barcodes = append(barcodes, drive.VolumeTag)
+		}
+	}
+
+	return barcodes, nil
+}
+
+func (l *Library) slots() []*slot {
+	slots := make([]*slot, 0, len(l.state.ImportExport)+len(l.state.Storage))
+	slots = append(slots, l.state.ImportExport...)
+	slots = append(slots, l.state.Storage...)
+	return slots
+}
+
+func (l *Library) driveByAddress(address uint16) (*driveSlot, error) {
+	for _, drive := range l.state.Drives {
+		if drive.Address == address {
+			return drive, nil
+		}
+	}
+	return nil, fmt.Errorf("no drive with address %d", address)
+}
@@ -0,0 +1,70 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/lto"
+)
+
+func (d *Drive) generation() lto.Generation {
+	d.library.lock.Lock()
+	defer d.library.lock.Unlock()
+
+	generation := d.slot().Generation
+	if generation == lto.GENERATION_UNKNOWN {
+		generation = DEFAULT_DRIVE_GENERATION
+	}
+	return generation
+}
+
+func mediaDensity(media lto.Media, writable bool) scsi.Density {
+	return scsi.Density{
+		PrimaryCode:   media.DensityCode(),
+		SecondaryCode: media.DensityCode(),
+		Writable:      writable,
+		Capacity:      media.NativeCapacity(),
+		Organization:  "LTO-CVE",
+		Name:          media.Type(),
+		Description:   media.String(),
+	}
+}
+
+// SupportedDensities follows the LTO compatibility rules for the drive's generation
+func (d *Drive) SupportedDensities() ([]scsi.Density, error) {
+	writable, readOnly := lto.DriveSupport(d.generation())
+
+	var densities []scsi.Density
+	for _, media := range writable {
+		densities = append(densities, mediaDensity(media, true))
+	}
+	for _, media := range readOnly {
+		densities = append(densities, mediaDensity(media, false))
+	}
+	return densities, nil
+}
+
+// MediumDensities goes by the cartridge type in the loaded tape's volume tag
+func (d *Drive) MediumDensities(ctx context.Context) ([]scsi.Density, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	volumeTag := d.loadedTape()
+	if volumeTag == "" {
+		return nil, ErrNoTape
+	}
+
+	_, media, err := lto.ParseVolumeTag(volumeTag)
+	if err != nil {
+		return nil, err
+	}
+	if !media.Known() {
+		return nil, fmt.Errorf("simulated tape %s has no cartridge type", volumeTag)
+	}
+	writable, _ := lto.DriveSupport(d.generation())
+	return []scsi.Density{mediaDensity(media, slices.Contains(writable, media))}, nil
+}
@@ -0,0 +1,247 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/drive"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+)
+
+var ErrNoTape = errors.New("no tape in simulated drive")
+
+type Drive struct {
+	library *Library
+	index   int
+
+	mounted   bool
+	unmounted chan struct{}
+}
+
+var _ drive.Drive = &Drive{}
+
+func (d *Drive) slot() *driveSlot {
+	return d.library.state.Drives[d.index]
+}
+
+func (d *Drive) loadedTape() string {
+	d.library.lock.Lock()
+	defer d.library.lock.Unlock()
+	return d.slot().VolumeTag
+}
+
+func (d *Drive) SerialNumber() (string, error) {
+	d.library.lock.Lock()
+	defer d.library.lock.Unlock()
+	return d.slot().Serial, nil
+}
+
+func (d *Drive) Identification() (*scsi.DeviceIdentification, error) {
+	serial, err := d.SerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	return &scsi.DeviceIdentification{Serial: serial}, nil
+}
+
+// PreventMediumRemoval makes the simulated library refuse to take the tape out of the drive, like a real one
+func (d *Drive) PreventMediumRemoval(prevent bool) error {
+	d.library.lock.Lock()
+	defer d.library.lock.Unlock()
+
+	d.slot().Locked = prevent
+	return d.library.save()
+}
+
+// Eject puts the tape back into its storage slot, standing in for an operator taking it out of a standalone drive
+func (d *Drive) Eject() error {
+	d.library.lock.Lock()
+	defer d.library.lock.Unlock()
+
+	return d.library.moveDriveTapeToStorage(d.slot())
+}
+
+func (d *Drive) MediumPresent() (bool, error) {
+	return d.loadedTape() != "", nil
+}
+
+func (d *Drive) MountPoint() string {
+	return d.library.volumePath(d.loadedTape())
+}
+
+func (d *Drive) isMounted() bool {
+	d.library.lock.Lock()
+	defer d.library.lock.Unlock()
+	return d.mounted
+}
+
+func (d *Drive) Mount(ctx context.Context) error {
+	if d.isMounted() {
+		return nil
+	}
+
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	volumeTag := d.loadedTape()
+	if volumeTag == "" {
+		return ErrNoTape
+	}
+
+	_, err = os.Stat(d.library.volumePath(volumeTag))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("tape %s has no LTFS volume: %w", volumeTag, drive.ErrLTFSExited)
+	} else if err != nil {
+		return err
+	}
+
+	d.library.lock.Lock()
+	d.mounted = true
+	d.unmounted = make(chan struct{})
+	d.library.lock.Unlock()
+	return nil
+}
+
+func (d *Drive) Unmount() error {
+	d.library.lock.Lock()
+	defer d.library.lock.Unlock()
+
+	if !d.mounted {
+		return nil
+	}
+	d.mounted = false
+	close(d.unmounted)
+	return nil
+}
+
+func (d *Drive) WaitForUnmount() {
+	d.library.lock.Lock()
+	unmounted := d.unmounted
+	d.library.lock.Unlock()
+
+	if unmounted != nil {
+		<-unmounted
+	}
+}
+
+func (d *Drive) Format(barcode string) error {
+	if d.isMounted() {
+		return drive.ErrAlreadyMounted
+	}
+
+	volumeTag := d.loadedTape()
+	if volumeTag == "" {
+		return ErrNoTape
+	}
+	if d.writeProtected(volumeTag) {
+		return fmt.Errorf("cannot format tape %s: %w", volumeTag, scsi.ErrDataProtect)
+	}
+
+	err := os.RemoveAll(d.library.tapePath(volumeTag))
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(d.library.volumePath(volumeTag), 0o755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(d.library.tapePath(volumeTag), LABEL_FILE), []byte(barcode), 0o644)
+}
+
+func (d *Drive) Stats() (int64, int64, error) {
+	capacity := d.library.state.Capacity
+
+	var used int64
+	err := filepath.WalkDir(d.MountPoint(), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		used += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	free := capacity - used
+	if free < 0 {
+		free = 0
+	}
+	return capacity, free, nil
+}
+
+// FileLocation orders files by inode, which roughly follows the order they were written in
+func (d *Drive) FileLocation(path string) (string, int, error) {
+	info, err := os.Stat(filepath.Join(d.MountPoint(), path))
+	if err != nil {
+		return "", 0, err
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "b", 0, nil
+	}
+	return "b", int(stat.Ino), nil
+}
+
+func (d *Drive) TapeAlerts() ([]scsi.TapeAlertFlag, error) {
+	d.library.lock.Lock()
+	defer d.library.lock.Unlock()
+
+	flags := d.slot().TapeAlerts
+	if len(flags) == 0 {
+		return nil, nil
+	}
+	d.slot().TapeAlerts = nil
+	return flags, d.library.save()
+}
+
+func (d *Drive) Health() (*drive.Health, error) {
+	flags, err := d.TapeAlerts()
+	if err != nil {
+		return nil, err
+	}
+	return &drive.Health{TapeAlerts: flags}, nil
+}
+
+// WaitForCleaning finishes instantly and resolves any cleaning TapeAlert flags
+func (d *Drive) WaitForCleaning(ctx context.Context) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	d.library.lock.Lock()
+	defer d.library.lock.Unlock()
+
+	slot := d.slot()
+	if !element.IsCleaningVolumeTag(slot.VolumeTag) {
+		return fmt.Errorf("tape %q in simulated drive is not a cleaning cartridge", slot.VolumeTag)
+	}
+
+	flags := slot.TapeAlerts[:0]
+	for _, flag := range slot.TapeAlerts {
+		if flag != scsi.TAPE_ALERT_CLEAN_NOW && flag != scsi.TAPE_ALERT_CLEAN_PERIODIC {
+			flags = append(flags, flag)
+		}
+	}
+	slot.TapeAlerts = flags
+	return d.library.save()
+}
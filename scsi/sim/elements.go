@@ -0,0 +1,53 @@
+package sim
+
+import "github.com/FoxDenHome/tapemgr/scsi/element"
+
+func (s *slot) descriptor(elementType element.Type, flags element.Flag) *element.Descriptor {
+	flags |= element.FLAG_ACCESS
+	if s.VolumeTag != "" {
+		flags |= element.FLAG_FULL
+	}
+	if s.Source != 0 {
+		flags |= element.FLAG_SOURCE_INVERT_VALID
+	}
+
+	mediumType := element.MEDIUM_TYPE_UNSPECIFIED
+	if s.VolumeTag != "" {
+		mediumType = element.MEDIUM_TYPE_DATA
+		if element.IsCleaningVolumeTag(s.VolumeTag) {
+			mediumType = element.MEDIUM_TYPE_CLEANING
+		}
+	}
+
+	return &element.Descriptor{
+		Address:              s.Address,
+		MediumType:           mediumType,
+		ElementType:          elementType,
+		Flags:                uint16(flags),
+		SourceElementAddress: s.Source,
+		VolumeTag:            s.VolumeTag,
+		PrimaryVolumeTag:     s.VolumeTag,
+	}
+}
+
+func (l *Library) GetElements() ([]*element.Descriptor, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	elements := []*element.Descriptor{
+		(&slot{Address: ADDRESS_TRANSPORT}).descriptor(element.ELEMENT_TYPE_MEDIUM_TRANSPORT, 0),
+	}
+	for _, elem := range l.state.Storage {
+		elements = append(elements, elem.descriptor(element.ELEMENT_TYPE_STORAGE, 0))
+	}
+	for _, elem := range l.state.ImportExport {
+		elements = append(elements, elem.descriptor(element.ELEMENT_TYPE_IMPORT_EXPORT, element.FLAG_IMPORT_ENABLED|element.FLAG_EXPORT_ENABLED))
+	}
+	for _, drive := range l.state.Drives {
+		desc := drive.descriptor(element.ELEMENT_TYPE_DATA_TRANSFER, 0)
+		desc.Identifier = drive.Serial
+		elements = append(elements, desc)
+	}
+
+	return elements, nil
+}
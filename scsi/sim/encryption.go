@@ -0,0 +1,72 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+)
+
+// The only algorithm of the simulated drives, at the index LTO drives use for it
+const SIM_ENCRYPTION_ALGORITHM_INDEX = 1
+
+func (d *Drive) EncryptionAlgorithms() ([]scsi.EncryptionAlgorithm, error) {
+	return []scsi.EncryptionAlgorithm{{
+		Index:      SIM_ENCRYPTION_ALGORITHM_INDEX,
+		Code:       scsi.SECURITY_ALGORITHM_AES_256_GCM,
+		KeyLength:  32,
+		CanEncrypt: true,
+		CanDecrypt: true,
+	}}, nil
+}
+
+func (d *Drive) EncryptionStatus() (*scsi.EncryptionStatus, error) {
+	d.library.lock.Lock()
+	defer d.library.lock.Unlock()
+
+	status := d.slot().Encryption
+	if status == nil {
+		return &scsi.EncryptionStatus{}, nil
+	}
+	copied := *status
+	return &copied, nil
+}
+
+func (d *Drive) SetEncryption(ctx context.Context, settings *scsi.EncryptionSettings) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	d.library.lock.Lock()
+	defer d.library.lock.Unlock()
+
+	slot := d.slot()
+	if slot.VolumeTag == "" {
+		return ErrNoTape
+	}
+	if settings.EncryptionMode != scsi.ENCRYPTION_MODE_DISABLE || settings.DecryptionMode != scsi.DECRYPTION_MODE_DISABLE {
+		if settings.AlgorithmIndex != SIM_ENCRYPTION_ALGORITHM_INDEX {
+			return fmt.Errorf("unknown algorithm index %d: %w", settings.AlgorithmIndex, scsi.ErrIllegalRequest)
+		}
+		if len(settings.Key) != 32 {
+			return fmt.Errorf("invalid key length %d: %w", len(settings.Key), scsi.ErrIllegalRequest)
+		}
+	}
+	if slot.IgnoreEncryption {
+		return nil
+	}
+
+	var counter uint32
+	if slot.Encryption != nil {
+		counter = slot.Encryption.KeyInstanceCounter
+	}
+	slot.Encryption = &scsi.EncryptionStatus{
+		EncryptionMode:     settings.EncryptionMode,
+		DecryptionMode:     settings.DecryptionMode,
+		AlgorithmIndex:     settings.AlgorithmIndex,
+		KeyInstanceCounter: counter + 1,
+		KeyID:              settings.KeyID,
+	}
+	return d.library.save()
+}
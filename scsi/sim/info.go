@@ -0,0 +1,48 @@
+package sim
+
+import (
+	"github.com/FoxDenHome/tapemgr/scsi"
+)
+
+const (
+	SIM_VENDOR     = "TAPEMGR"
+	LIBRARY_SERIAL = "SIMLIB00"
+)
+
+func (l *Library) DeviceInfo() (*scsi.DeviceInfo, error) {
+	return &scsi.DeviceInfo{
+		Inquiry: &scsi.InquiryData{
+			DeviceType: scsi.DEVICE_TYPE_MEDIUM_CHANGER,
+			Vendor:     SIM_VENDOR,
+			Product:    "Simulated Library",
+			Revision:   DEFAULT_FIRMWARE,
+		},
+		VPDPages: []scsi.VPDPage{scsi.VPD_PAGE_SUPPORTED, scsi.VPD_PAGE_UNIT_SERIAL_NUMBER},
+		Serial:   LIBRARY_SERIAL,
+	}, nil
+}
+
+func (d *Drive) DeviceInfo() (*scsi.DeviceInfo, error) {
+	generation := d.generation()
+
+	d.library.lock.Lock()
+	defer d.library.lock.Unlock()
+
+	firmware := d.slot().Firmware
+	if firmware == "" {
+		firmware = DEFAULT_FIRMWARE
+	}
+
+	return &scsi.DeviceInfo{
+		Inquiry: &scsi.InquiryData{
+			DeviceType: scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS,
+			Removable:  true,
+			Vendor:     SIM_VENDOR,
+			Product:    "Simulated " + generation.String(),
+			Revision:   firmware,
+		},
+		VPDPages:         []scsi.VPDPage{scsi.VPD_PAGE_SUPPORTED, scsi.VPD_PAGE_UNIT_SERIAL_NUMBER, scsi.VPD_PAGE_SEQUENTIAL_ACCESS_CAPABILITIES},
+		Serial:           d.slot().Serial,
+		SequentialAccess: &scsi.SequentialAccessCapabilities{},
+	}, nil
+}
@@ -0,0 +1,301 @@
+package sim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/lto"
+)
+
+// Simulated tape library, selected with a loader device of the form
+// sim:///path/to/library?slots=24&mailslots=2&drives=1&tapes=12&cleaning=1&capacity=16G
+// Query parameters are only used when the library is first created, afterwards
+// the state is read from library.json in the given directory.
+// Each tape is a directory below tapes/ and is only mountable after being formatted.
+
+const (
+	URL_SCHEME = "sim"
+
+	STATE_FILE = "library.json"
+	TAPES_DIR  = "tapes"
+	VOLUME_DIR = "volume"
+	LABEL_FILE = "label" // The host label of the cartridge memory
+
+	DEFAULT_SLOTS     = 24
+	DEFAULT_MAILSLOTS = 2
+	DEFAULT_DRIVES    = 1
+	DEFAULT_TAPES     = 12
+	DEFAULT_CAPACITY  = 16 * 1024 * 1024 * 1024 // 16 GB
+
+	// Matches the L8 suffix of the simulated tapes
+	DEFAULT_DRIVE_GENERATION lto.Generation = 8
+	DEFAULT_FIRMWARE                        = "S001"
+
+	ADDRESS_TRANSPORT     = 0x0000
+	ADDRESS_IMPORT_EXPORT = 0x0010
+	ADDRESS_DRIVE         = 0x0100
+	ADDRESS_STORAGE       = 0x1000
+)
+
+var ErrNotSimulated = errors.New("not a simulated library URL")
+
+type slot struct {
+	Address   uint16 `json:"address"`
+	VolumeTag string `json:"volume-tag,omitempty"`
+	Source    uint16 `json:"source,omitempty"`
+}
+
+type driveSlot struct {
+	slot
+	Serial string `json:"serial"`
+	Locked bool   `json:"locked,omitempty"`
+
+	// Generation is the LTO generation of the drive, DEFAULT_DRIVE_GENERATION if unset
+	Generation lto.Generation `json:"generation,omitempty"`
+	// Firmware is the revision reported by INQUIRY, DEFAULT_FIRMWARE if unset, edit library.json to simulate an update
+	Firmware string `json:"firmware,omitempty"`
+
+	// Encryption is the data encryption state, cleared when the tape is unloaded
+	Encryption *scsi.EncryptionStatus `json:"encryption,omitempty"`
+	// IgnoreEncryption accepts encryption settings without applying them, edit library.json to set it
+	IgnoreEncryption bool `json:"ignore-encryption,omitempty"`
+
+	// TapeAlerts are reported (and cleared) on the next TapeAlert check, edit library.json to inject them
+	TapeAlerts []scsi.TapeAlertFlag `json:"tape-alerts,omitempty"`
+}
+
+// medium is what the simulated cartridge memory keeps track of
+type medium struct {
+	LoadCount uint64   `json:"load-count"`
+	LastLoads []string `json:"last-loads,omitempty"`
+
+	// WriteProtected is the cartridge's write-protect tab, edit library.json to set it
+	WriteProtected bool `json:"write-protected,omitempty"`
+}
+
+type state struct {
+	Capacity     int64              `json:"capacity"`
+	Locked       bool               `json:"locked,omitempty"`
+	Storage      []*slot            `json:"storage"`
+	ImportExport []*slot            `json:"import-export"`
+	Drives       []*driveSlot       `json:"drives"`
+	Media        map[string]*medium `json:"media,omitempty"`
+}
+
+type Library struct {
+	path string
+
+	lock   sync.Mutex
+	state  state
+	drives []*Drive
+}
+
+func IsSimulated(device string) bool {
+	return strings.HasPrefix(device, URL_SCHEME+"://")
+}
+
+func Open(device string) (*Library, error) {
+	if !IsSimulated(device) {
+		return nil, ErrNotSimulated
+	}
+
+	uri, err := url.Parse(device)
+	if err != nil {
+		return nil, err
+	}
+	if uri.Path == "" {
+		return nil, fmt.Errorf("simulated library URL %s has no path", device)
+	}
+
+	lib := &Library{
+		path: uri.Path,
+	}
+
+	data, err := os.ReadFile(lib.statePath())
+	if err == nil {
+		err = json.Unmarshal(data, &lib.state)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse simulated library state: %w", err)
+		}
+	} else if errors.Is(err, os.ErrNotExist) {
+		err = lib.create(uri.Query())
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	for i := range lib.state.Drives {
+		lib.drives = append(lib.drives, &Drive{
+			library: lib,
+			index:   i,
+		})
+	}
+
+	return lib, nil
+}
+
+func (l *Library) create(query url.Values) error {
+	slots, err := queryInt(query, "slots", DEFAULT_SLOTS)
+	if err != nil {
+		return err
+	}
+	mailslots, err := queryInt(query, "mailslots", DEFAULT_MAILSLOTS)
+	if err != nil {
+		return err
+	}
+	drives, err := queryInt(query, "drives", DEFAULT_DRIVES)
+	if err != nil {
+		return err
+	}
+	tapes, err := queryInt(query, "tapes", DEFAULT_TAPES)
+	if err != nil {
+		return err
+	}
+	cleaning, err := queryInt(query, "cleaning", 0)
+	if err != nil {
+		return err
+	}
+	capacity, err := querySize(query, "capacity", DEFAULT_CAPACITY)
+	if err != nil {
+		return err
+	}
+
+	if tapes+cleaning > slots {
+		return fmt.Errorf("cannot create %d tapes and %d cleaning cartridges in %d slots", tapes, cleaning, slots)
+	}
+
+	l.state.Capacity = capacity
+	for i := range slots {
+		elem := &slot{Address: ADDRESS_STORAGE + uint16(i)}
+		if i < tapes {
+			elem.VolumeTag = fmt.Sprintf("SIM%03dL8", i)
+		} else if i < tapes+cleaning {
+			elem.VolumeTag = fmt.Sprintf("CLN%03dCU", i-tapes)
+		}
+		l.state.Storage = append(l.state.Storage, elem)
+	}
+	for i := range mailslots {
+		l.state.ImportExport = append(l.state.ImportExport, &slot{Address: ADDRESS_IMPORT_EXPORT + uint16(i)})
+	}
+	for i := range drives {
+		l.state.Drives = append(l.state.Drives, &driveSlot{
+			slot:   slot{Address: ADDRESS_DRIVE + uint16(i)},
+			Serial: fmt.Sprintf("SIMDRV%02d", i),
+		})
+	}
+
+	err = os.MkdirAll(filepath.Join(l.path, TAPES_DIR), 0o755)
+	if err != nil {
+		return err
+	}
+
+	return l.save()
+}
+
+func queryInt(query url.Values, key string, def int) (int, error) {
+	str := query.Get(key)
+	if str == "" {
+		return def, nil
+	}
+	val, err := strconv.Atoi(str)
+	if err != nil || val < 0 {
+		return 0, fmt.Errorf("invalid simulated library parameter %s=%s", key, str)
+	}
+	return val, nil
+}
+
+func querySize(query url.Values, key string, def int64) (int64, error) {
+	str := strings.ToUpper(query.Get(key))
+	if str == "" {
+		return def, nil
+	}
+
+	multiplier := int64(1)
+	for i, suffix := range []string{"K", "M", "G", "T"} {
+		if strings.HasSuffix(str, suffix) {
+			multiplier = 1 << (10 * (i + 1))
+			str = strings.TrimSuffix(str, suffix)
+			break
+		}
+	}
+
+	val, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || val <= 0 {
+		return 0, fmt.Errorf("invalid simulated library parameter %s=%s", key, query.Get(key))
+	}
+	return val * multiplier, nil
+}
+
+func (l *Library) statePath() string {
+	return filepath.Join(l.path, STATE_FILE)
+}
+
+func (l *Library) tapePath(volumeTag string) string {
+	return filepath.Join(l.path, TAPES_DIR, volumeTag)
+}
+
+func (l *Library) volumePath(volumeTag string) string {
+	return filepath.Join(l.tapePath(volumeTag), VOLUME_DIR)
+}
+
+func (l *Library) isFormatted(volumeTag string) bool {
+	_, err := os.Stat(l.volumePath(volumeTag))
+	return err == nil
+}
+
+// save must be called with the lock held
+func (l *Library) save() error {
+	data, err := json.MarshalIndent(&l.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := l.statePath() + ".tmp"
+	err = os.WriteFile(tmpPath, data, 0o644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, l.statePath())
+}
+
+// saveInto persists the state after a partially failed operation without masking its error
+func (l *Library) saveInto(err *error) {
+	saveErr := l.save()
+	if *err == nil {
+		*err = saveErr
+	}
+}
+
+// Drive returns the simulated drive with the given serial, or the first one if serial is empty
+func (l *Library) Drive(serial string) (*Drive, error) {
+	serial = strings.TrimPrefix(serial, URL_SCHEME+"://")
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for i, drive := range l.state.Drives {
+		if serial == "" || drive.Serial == serial {
+			return l.drives[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no simulated drive with serial %s", serial)
+}
+
+// Drives returns the simulated drives in element address order
+func (l *Library) Drives() []*Drive {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return slices.Clone(l.drives)
+}
@@ -0,0 +1,88 @@
+package sim
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/FoxDenHome/tapemgr/scsi/loader"
+)
+
+func (l *Library) ExportTapes(volumeTags ...string) (moves []loader.Move, err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	defer l.saveInto(&err)
+
+	for _, volumeTag := range volumeTags {
+		var source *slot
+		for _, elem := range l.state.Storage {
+			if elem.VolumeTag == volumeTag {
+				source = elem
+			}
+		}
+		for _, drive := range l.state.Drives {
+			if drive.VolumeTag == volumeTag {
+				return moves, fmt.Errorf("tape %s is in drive %d, unload it before exporting", volumeTag, drive.Address)
+			}
+		}
+		if source == nil {
+			return moves, fmt.Errorf("no tape found with volume tag %s outside of the mailslots", volumeTag)
+		}
+
+		var dest *slot
+		for _, elem := range l.state.ImportExport {
+			if elem.VolumeTag == "" {
+				dest = elem
+				break
+			}
+		}
+		if dest == nil {
+			return moves, fmt.Errorf("no free export-enabled mailslot left for tape %s", volumeTag)
+		}
+
+		log.Printf("Exporting tape %s from element %d to mailslot %d", volumeTag, source.Address, dest.Address)
+		dest.VolumeTag = volumeTag
+		source.VolumeTag = ""
+		source.Source = 0
+		moves = append(moves, loader.Move{
+			VolumeTag:   volumeTag,
+			Source:      source.Address,
+			Destination: dest.Address,
+		})
+	}
+
+	return moves, nil
+}
+
+func (l *Library) ImportTapes() (moves []loader.Move, err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	defer l.saveInto(&err)
+
+	for _, source := range l.state.ImportExport {
+		if source.VolumeTag == "" {
+			continue
+		}
+
+		var dest *slot
+		for _, elem := range l.state.Storage {
+			if elem.VolumeTag == "" {
+				dest = elem
+				break
+			}
+		}
+		if dest == nil {
+			return moves, fmt.Errorf("no free storage slot left for tape %s in mailslot %d", source.VolumeTag, source.Address)
+		}
+
+		log.Printf("Importing tape %s from mailslot %d to slot %d", source.VolumeTag, source.Address, dest.Address)
+		dest.VolumeTag = source.VolumeTag
+		source.VolumeTag = ""
+		moves = append(moves, loader.Move{
+			VolumeTag:   dest.VolumeTag,
+			Source:      source.Address,
+			Destination: dest.Address,
+		})
+	}
+
+	return moves, nil
+}
@@ -0,0 +1,144 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+)
+
+const LAST_LOADS_KEPT = scsi.ATTRIBUTE_LAST_LOAD_DEVICE_COUNT
+
+func (l *Library) recordLoad(drive *driveSlot) {
+	if l.state.Media == nil {
+		l.state.Media = make(map[string]*medium)
+	}
+	med := l.state.Media[drive.VolumeTag]
+	if med == nil {
+		med = &medium{}
+		l.state.Media[drive.VolumeTag] = med
+	}
+
+	med.LoadCount++
+	med.LastLoads = append([]string{"SIM " + drive.Serial}, med.LastLoads...)
+	if len(med.LastLoads) > LAST_LOADS_KEPT {
+		med.LastLoads = med.LastLoads[:LAST_LOADS_KEPT]
+	}
+}
+
+func (d *Drive) WriteProtected(ctx context.Context) (bool, error) {
+	err := ctx.Err()
+	if err != nil {
+		return false, err
+	}
+
+	d.library.lock.Lock()
+	defer d.library.lock.Unlock()
+
+	volumeTag := d.slot().VolumeTag
+	if volumeTag == "" {
+		return false, ErrNoTape
+	}
+	return d.library.isWriteProtected(volumeTag), nil
+}
+
+func (d *Drive) writeProtected(volumeTag string) bool {
+	d.library.lock.Lock()
+	defer d.library.lock.Unlock()
+	return d.library.isWriteProtected(volumeTag)
+}
+
+// isWriteProtected must be called with the lock held
+func (l *Library) isWriteProtected(volumeTag string) bool {
+	med := l.state.Media[volumeTag]
+	return med != nil && med.WriteProtected
+}
+
+func (d *Drive) MediumAuxiliaryMemory(ctx context.Context) (*scsi.MediumAuxiliaryMemory, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	volumeTag := d.loadedTape()
+	if volumeTag == "" {
+		return nil, ErrNoTape
+	}
+
+	const mib = 1024 * 1024
+	capacity := d.library.state.Capacity
+	free := capacity
+	formatted := d.library.isFormatted(volumeTag)
+	if formatted {
+		_, free, err = d.Stats()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	d.library.lock.Lock()
+	defer d.library.lock.Unlock()
+
+	mam := &scsi.MediumAuxiliaryMemory{
+		Manufacturer: "SIM",
+		SerialNumber: volumeTag,
+		Barcode:      volumeTag,
+		Partitions: []scsi.PartitionCapacity{{
+			RemainingMiB: uint64(free / mib),
+			MaximumMiB:   uint64(capacity / mib),
+		}},
+	}
+	if formatted {
+		mam.ApplicationVendor = SIM_VENDOR
+		mam.ApplicationName = scsi.LTFS_APPLICATION_NAME
+		// tapemgr formats with the barcode as the volume name
+		mam.VolumeLabel = volumeTag
+	}
+	if med := d.library.state.Media[volumeTag]; med != nil {
+		mam.LoadCount = med.LoadCount
+		mam.LastLoads = med.LastLoads
+	}
+	return mam, nil
+}
+
+func (d *Drive) HostLabel(ctx context.Context) (string, error) {
+	err := ctx.Err()
+	if err != nil {
+		return "", err
+	}
+
+	volumeTag := d.loadedTape()
+	if volumeTag == "" {
+		return "", ErrNoTape
+	}
+
+	label, err := os.ReadFile(filepath.Join(d.library.tapePath(volumeTag), LABEL_FILE))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	return string(label), err
+}
+
+func (d *Drive) SetHostLabel(ctx context.Context, label string) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	volumeTag := d.loadedTape()
+	if volumeTag == "" {
+		return ErrNoTape
+	}
+	if d.writeProtected(volumeTag) {
+		return fmt.Errorf("cannot write cartridge memory of tape %s: %w", volumeTag, scsi.ErrDataProtect)
+	}
+
+	err = os.MkdirAll(d.library.tapePath(volumeTag), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(d.library.tapePath(volumeTag), LABEL_FILE), []byte(label), 0o644)
+}
@@ -0,0 +1,112 @@
+package sim
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+)
+
+// element returns the slot with the given address, and the drive if it is a data transfer element
+func (l *Library) element(address uint16) (*slot, *driveSlot, error) {
+	for _, elem := range l.slots() {
+		if elem.Address == address {
+			return elem, nil, nil
+		}
+	}
+	for _, drive := range l.state.Drives {
+		if drive.Address == address {
+			return &drive.slot, drive, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no element with address %d: %w", address, scsi.ErrIllegalRequest)
+}
+
+// checkTake checks that the tape in the source element may be moved
+func (l *Library) checkTake(source *slot, sourceDrive *driveSlot) error {
+	if source.VolumeTag == "" {
+		return fmt.Errorf("source element %d is empty: %w", source.Address, scsi.ErrSourceElementEmpty)
+	}
+	if sourceDrive != nil {
+		return l.checkRemovable(sourceDrive)
+	}
+	return nil
+}
+
+// take empties the source element for a move, after checkTake
+func (l *Library) take(source *slot, sourceDrive *driveSlot) string {
+	if sourceDrive != nil {
+		// Like a real drive with clear key on demount set
+		sourceDrive.Encryption = nil
+	}
+
+	volumeTag := source.VolumeTag
+	source.VolumeTag = ""
+	source.Source = 0
+	return volumeTag
+}
+
+// put stores a tape taken from sourceAddress in the destination element
+func (l *Library) put(dest *slot, destDrive *driveSlot, volumeTag string, sourceAddress uint16) {
+	dest.VolumeTag = volumeTag
+	if destDrive != nil {
+		dest.Source = sourceAddress
+		l.recordLoad(destDrive)
+	}
+}
+
+func (l *Library) MoveMedium(sourceAddress uint16, destAddress uint16) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	source, sourceDrive, err := l.element(sourceAddress)
+	if err != nil {
+		return err
+	}
+	dest, destDrive, err := l.element(destAddress)
+	if err != nil {
+		return err
+	}
+	if dest.VolumeTag != "" {
+		return fmt.Errorf("destination element %d is full: %w", destAddress, scsi.ErrDestinationElementFull)
+	}
+
+	err = l.checkTake(source, sourceDrive)
+	if err != nil {
+		return err
+	}
+	volumeTag := l.take(source, sourceDrive)
+	log.Printf("Moving tape %s from element %d to element %d", volumeTag, sourceAddress, destAddress)
+	l.put(dest, destDrive, volumeTag, sourceAddress)
+	return l.save()
+}
+
+func (l *Library) ExchangeMedium(firstAddress uint16, secondAddress uint16) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	first, firstDrive, err := l.element(firstAddress)
+	if err != nil {
+		return err
+	}
+	second, secondDrive, err := l.element(secondAddress)
+	if err != nil {
+		return err
+	}
+	err = l.checkTake(first, firstDrive)
+	if err != nil {
+		return err
+	}
+	err = l.checkTake(second, secondDrive)
+	if err != nil {
+		return err
+	}
+
+	firstTag := l.take(first, firstDrive)
+	secondTag := l.take(second, secondDrive)
+
+	log.Printf("Exchanging tape %s in element %d with tape %s in element %d", firstTag, firstAddress, secondTag, secondAddress)
+	l.put(second, secondDrive, firstTag, firstAddress)
+	l.put(first, firstDrive, secondTag, secondAddress)
+	return l.save()
+}
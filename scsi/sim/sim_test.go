@@ -0,0 +1,214 @@
+package sim_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/drive"
+	"github.com/FoxDenHome/tapemgr/scsi/sim"
+)
+
+func openLibrary(t *testing.T, query string) (*sim.Library, string) {
+	t.Helper()
+	url := "sim://" + filepath.Join(t.TempDir(), "lib") + "?" + query
+	library, err := sim.Open(url)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return library, url
+}
+
+func TestOpenCreatesLibrary(t *testing.T) {
+	library, _ := openLibrary(t, "tapes=3&drives=2&slots=4")
+
+	tags, err := library.GetVolumeTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"SIM000L8", "SIM001L8", "SIM002L8"}
+	if !slices.Equal(tags, want) {
+		t.Errorf("volume tags %v, want %v", tags, want)
+	}
+
+	for _, serial := range []string{"SIMDRV00", "SIMDRV01"} {
+		_, err = library.Drive(serial)
+		if err != nil {
+			t.Errorf("Drive(%s): %v", serial, err)
+		}
+	}
+	_, err = library.Drive("SIMDRV02")
+	if err == nil {
+		t.Errorf("found a third drive")
+	}
+}
+
+func TestOpenRejectsInvalidURL(t *testing.T) {
+	for _, url := range []string{
+		"/dev/sg0",
+		"sim://",
+		"sim:///tmp/x?tapes=many",
+		"sim:///tmp/x?capacity=12Q",
+	} {
+		_, err := sim.Open(url)
+		if err == nil {
+			t.Errorf("Open(%q) succeeded", url)
+		}
+	}
+}
+
+func TestStatePersists(t *testing.T) {
+	library, url := openLibrary(t, "tapes=2")
+	drv, err := library.Drive("SIMDRV00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := library.DriveAddress(&scsi.DeviceIdentification{Serial: "SIMDRV00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = library.MoveTapeToDrive(address, "SIM001L8")
+	if err != nil {
+		t.Fatalf("MoveTapeToDrive: %v", err)
+	}
+	err = drv.Format("SIM001L8")
+	if err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+
+	reopened, err := sim.Open(url)
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	reopenedDrive, err := reopened.Drive("SIMDRV00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	present, err := reopenedDrive.MediumPresent()
+	if err != nil || !present {
+		t.Fatalf("tape is not in the drive after reopening: %v", err)
+	}
+	mam, err := reopenedDrive.MediumAuxiliaryMemory(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mam.Barcode != "SIM001L8" || !mam.HasLTFSVolume() || mam.LoadCount != 1 {
+		t.Errorf("cartridge memory %+v, want a formatted SIM001L8 loaded once", mam)
+	}
+}
+
+func TestDriveMountWriteAndUnload(t *testing.T) {
+	library, _ := openLibrary(t, "tapes=2&capacity=10M")
+	drv, err := library.Drive("SIMDRV00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := library.DriveAddress(&scsi.DeviceIdentification{Serial: "SIMDRV00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	err = drv.Mount(ctx)
+	if !errors.Is(err, sim.ErrNoTape) {
+		t.Errorf("mounting an empty drive: got %v, want %v", err, sim.ErrNoTape)
+	}
+
+	err = library.MoveTapeToDrive(address, "SIM000L8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = drv.Mount(ctx)
+	if !errors.Is(err, drive.ErrLTFSExited) {
+		t.Errorf("mounting a blank tape: got %v, want %v", err, drive.ErrLTFSExited)
+	}
+
+	err = drv.Format("SIM000L8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = drv.Mount(ctx)
+	if err != nil {
+		t.Fatalf("Mount: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(drv.MountPoint(), "file"), make([]byte, 1024*1024), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size, free, err := drv.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 10*1024*1024 || free != 9*1024*1024 {
+		t.Errorf("Stats: size %d free %d", size, free)
+	}
+
+	err = library.MoveDriveTapeToStorage(address)
+	if err == nil {
+		t.Errorf("the library took the tape out of a mounted drive")
+	}
+	err = drv.PreventMediumRemoval(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = drv.Unmount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = library.MoveDriveTapeToStorage(address)
+	if !errors.Is(err, scsi.ErrMediumRemovalPrevented) {
+		t.Errorf("unloading a locked drive: got %v, want %v", err, scsi.ErrMediumRemovalPrevented)
+	}
+	err = drv.PreventMediumRemoval(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = library.MoveDriveTapeToStorage(address)
+	if err != nil {
+		t.Fatalf("MoveDriveTapeToStorage: %v", err)
+	}
+
+	present, err := drv.MediumPresent()
+	if err != nil || present {
+		t.Errorf("drive still holds a tape: %v", err)
+	}
+	elements, err := library.GetElements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, elem := range elements {
+		if elem.VolumeTag == "SIM000L8" && elem.Address != sim.ADDRESS_STORAGE {
+			t.Errorf("tape went back to element %d, not its home slot %d", elem.Address, sim.ADDRESS_STORAGE)
+		}
+	}
+}
+
+func TestHostLabel(t *testing.T) {
+	library, _ := openLibrary(t, "tapes=1")
+	drv, err := library.Drive("SIMDRV00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := library.DriveAddress(&scsi.DeviceIdentification{Serial: "SIMDRV00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = library.MoveTapeToDrive(address, "SIM000L8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = drv.SetHostLabel(context.Background(), "tapemgr barcode=SIM000L8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	label, err := drv.HostLabel(context.Background())
+	if err != nil || label != "tapemgr barcode=SIM000L8" {
+		t.Errorf("HostLabel: %q, %v", label, err)
+	}
+}
@@ -0,0 +1,145 @@
+package scsi
+
+import "fmt"
+
+type TapeAlertFlag uint8
+
+type TapeAlertSeverity uint8
+
+const (
+	TAPE_ALERT_INFORMATION TapeAlertSeverity = iota
+	TAPE_ALERT_WARNING
+	TAPE_ALERT_CRITICAL
+)
+
+func (s TapeAlertSeverity) String() string {
+	switch s {
+	case TAPE_ALERT_INFORMATION:
+		return "information"
+	case TAPE_ALERT_WARNING:
+		return "warning"
+	case TAPE_ALERT_CRITICAL:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	TAPE_ALERT_READ_WARNING       TapeAlertFlag = 0x01
+	TAPE_ALERT_WRITE_WARNING      TapeAlertFlag = 0x02
+	TAPE_ALERT_HARD_ERROR         TapeAlertFlag = 0x03
+	TAPE_ALERT_MEDIA              TapeAlertFlag = 0x04
+	TAPE_ALERT_READ_FAILURE       TapeAlertFlag = 0x05
+	TAPE_ALERT_WRITE_FAILURE      TapeAlertFlag = 0x06
+	TAPE_ALERT_MEDIA_LIFE         TapeAlertFlag = 0x07
+	TAPE_ALERT_WRITE_PROTECT      TapeAlertFlag = 0x09
+	TAPE_ALERT_CLEANING_MEDIA     TapeAlertFlag = 0x0B
+	TAPE_ALERT_NEARING_MEDIA_LIFE TapeAlertFlag = 0x13
+	TAPE_ALERT_CLEAN_NOW          TapeAlertFlag = 0x14
+	TAPE_ALERT_CLEAN_PERIODIC     TapeAlertFlag = 0x15
+	TAPE_ALERT_EXPIRED_CLEANING   TapeAlertFlag = 0x16
+	TAPE_ALERT_INVALID_CLEANING   TapeAlertFlag = 0x17
+
+	TAPE_ALERT_FLAG_COUNT = 64
+)
+
+type tapeAlertInfo struct {
+	name     string
+	severity TapeAlertSeverity
+}
+
+// TapeAlert flags as assigned by SSC, flags 40-48 and 61-64 are obsolete or reserved
+var tapeAlertFlags = map[TapeAlertFlag]tapeAlertInfo{
+	0x01: {"Read warning", TAPE_ALERT_WARNING},
+	0x02: {"Write warning", TAPE_ALERT_WARNING},
+	0x03: {"Hard error", TAPE_ALERT_WARNING},
+	0x04: {"Media error", TAPE_ALERT_CRITICAL},
+	0x05: {"Read failure", TAPE_ALERT_CRITICAL},
+	0x06: {"Write failure", TAPE_ALERT_CRITICAL},
+	0x07: {"Media life expired", TAPE_ALERT_WARNING},
+	0x08: {"Not data grade", TAPE_ALERT_WARNING},
+	0x09: {"Write protect", TAPE_ALERT_CRITICAL},
+	0x0A: {"No removal", TAPE_ALERT_INFORMATION},
+	0x0B: {"Cleaning media", TAPE_ALERT_INFORMATION},
+	0x0C: {"Unsupported format", TAPE_ALERT_INFORMATION},
+	0x0D: {"Recoverable mechanical cartridge failure", TAPE_ALERT_CRITICAL},
+	0x0E: {"Unrecoverable mechanical cartridge failure", TAPE_ALERT_CRITICAL},
+	0x0F: {"Memory chip in cartridge failure", TAPE_ALERT_WARNING},
+	0x10: {"Forced eject", TAPE_ALERT_CRITICAL},
+	0x11: {"Read only format", TAPE_ALERT_WARNING},
+	0x12: {"Tape directory corrupted on load", TAPE_ALERT_WARNING},
+	0x13: {"Nearing media life", TAPE_ALERT_INFORMATION},
+	0x14: {"Cleaning required", TAPE_ALERT_CRITICAL},
+	0x15: {"Cleaning requested periodically", TAPE_ALERT_WARNING},
+	0x16: {"Expired cleaning media", TAPE_ALERT_CRITICAL},
+	0x17: {"Invalid cleaning tape", TAPE_ALERT_CRITICAL},
+	0x18: {"Retension requested", TAPE_ALERT_WARNING},
+	0x19: {"Dual-port interface error", TAPE_ALERT_WARNING},
+	0x1A: {"Cooling fan failure", TAPE_ALERT_WARNING},
+	0x1B: {"Power supply failure", TAPE_ALERT_WARNING},
+	0x1C: {"Power consumption", TAPE_ALERT_WARNING},
+	0x1D: {"Drive maintenance", TAPE_ALERT_WARNING},
+	0x1E: {"Hardware A", TAPE_ALERT_CRITICAL},
+	0x1F: {"Hardware B", TAPE_ALERT_CRITICAL},
+	0x20: {"Interface", TAPE_ALERT_WARNING},
+	0x21: {"Eject media", TAPE_ALERT_CRITICAL},
+	0x22: {"Microcode update failure", TAPE_ALERT_WARNING},
+	0x23: {"Drive humidity", TAPE_ALERT_WARNING},
+	0x24: {"Drive temperature", TAPE_ALERT_WARNING},
+	0x25: {"Drive voltage", TAPE_ALERT_WARNING},
+	0x26: {"Predictive failure", TAPE_ALERT_CRITICAL},
+	0x27: {"Diagnostics required", TAPE_ALERT_WARNING},
+	0x31: {"Diminished native capacity", TAPE_ALERT_WARNING},
+	0x32: {"Lost statistics", TAPE_ALERT_WARNING},
+	0x33: {"Tape directory invalid at unload", TAPE_ALERT_WARNING},
+	0x34: {"Tape system area write failure", TAPE_ALERT_CRITICAL},
+	0x35: {"Tape system area read failure", TAPE_ALERT_CRITICAL},
+	0x36: {"No start of data", TAPE_ALERT_CRITICAL},
+	0x37: {"Loading or threading failure", TAPE_ALERT_CRITICAL},
+	0x38: {"Unrecoverable unload failure", TAPE_ALERT_CRITICAL},
+	0x39: {"Automation interface failure", TAPE_ALERT_CRITICAL},
+	0x3A: {"Microcode failure", TAPE_ALERT_WARNING},
+	0x3B: {"WORM medium integrity check failed", TAPE_ALERT_WARNING},
+	0x3C: {"WORM medium overwrite attempted", TAPE_ALERT_WARNING},
+}
+
+func (f TapeAlertFlag) Name() string {
+	info, ok := tapeAlertFlags[f]
+	if !ok {
+		return fmt.Sprintf("Flag %d", uint8(f))
+	}
+	return info.name
+}
+
+func (f TapeAlertFlag) Severity() TapeAlertSeverity {
+	info, ok := tapeAlertFlags[f]
+	if !ok {
+		return TAPE_ALERT_INFORMATION
+	}
+	return info.severity
+}
+
+func (f TapeAlertFlag) String() string {
+	return fmt.Sprintf("%s (flag %d, %s)", f.Name(), uint8(f), f.Severity())
+}
+
+// TapeAlerts returns the TapeAlert flags currently set on the device
+// Reading the TapeAlert page clears the flags on the device
+func (d *SCSIDevice) TapeAlerts() ([]TapeAlertFlag, error) {
+	params, err := d.LogSense(LOG_PAGE_TAPE_ALERT, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var flags []TapeAlertFlag
+	for _, param := range params {
+		if param.Code == 0 || param.Code > TAPE_ALERT_FLAG_COUNT || len(param.Value) < 1 {
+			continue
+		}
+		if flagToBool(param.Value[0], 0) {
+			flags = append(flags, TapeAlertFlag(param.Code))
+		}
+	}
+	return flags, nil
+}
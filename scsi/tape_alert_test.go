@@ -0,0 +1,65 @@
+package scsi_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/scsitest"
+)
+
+func TestTapeAlerts(t *testing.T) {
+	params := [][]byte{}
+	for code := uint16(1); code <= scsi.TAPE_ALERT_FLAG_COUNT; code++ {
+		value := byte(0x00)
+		if code == 0x04 || code == 0x14 {
+			value = 0x01
+		}
+		params = append(params, logParameter(code, value))
+	}
+	// Only bit 0 carries the flag, code 0 and codes past the 64 flags are not flags
+	params = append(params,
+		logParameter(0x0000, 0x01),
+		logParameter(0x0041, 0x01),
+	)
+	params[0x15-1] = logParameter(0x0015, 0xFE)
+
+	dev := scsitest.New()
+	dev.Reply(OPCODE_LOG_SENSE, logPage(scsi.LOG_PAGE_TAPE_ALERT, params...), nil)
+
+	flags, err := scsi.NewDevice(dev).TapeAlerts()
+	if err != nil {
+		t.Fatalf("TapeAlerts: %v", err)
+	}
+	want := []scsi.TapeAlertFlag{scsi.TAPE_ALERT_MEDIA, scsi.TAPE_ALERT_CLEAN_NOW}
+	if !slices.Equal(flags, want) {
+		t.Errorf("got flags %v, want %v", flags, want)
+	}
+}
+
+func TestTapeAlertFlag(t *testing.T) {
+	tests := []struct {
+		flag     scsi.TapeAlertFlag
+		name     string
+		severity scsi.TapeAlertSeverity
+		str      string
+	}{
+		{scsi.TAPE_ALERT_MEDIA, "Media error", scsi.TAPE_ALERT_CRITICAL, "Media error (flag 4, critical)"},
+		{scsi.TAPE_ALERT_CLEAN_NOW, "Cleaning required", scsi.TAPE_ALERT_CRITICAL, "Cleaning required (flag 20, critical)"},
+		{scsi.TAPE_ALERT_CLEAN_PERIODIC, "Cleaning requested periodically", scsi.TAPE_ALERT_WARNING, "Cleaning requested periodically (flag 21, warning)"},
+		{scsi.TAPE_ALERT_NEARING_MEDIA_LIFE, "Nearing media life", scsi.TAPE_ALERT_INFORMATION, "Nearing media life (flag 19, information)"},
+		{0x28, "Flag 40", scsi.TAPE_ALERT_INFORMATION, "Flag 40 (flag 40, information)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.flag.Name(); got != tt.name {
+			t.Errorf("flag %d: got name %q, want %q", uint8(tt.flag), got, tt.name)
+		}
+		if got := tt.flag.Severity(); got != tt.severity {
+			t.Errorf("flag %d: got severity %s, want %s", uint8(tt.flag), got, tt.severity)
+		}
+		if got := tt.flag.String(); got != tt.str {
+			t.Errorf("flag %d: got %q, want %q", uint8(tt.flag), got, tt.str)
+		}
+	}
+}
@@ -1,28 +1,67 @@
 package scsi
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	scsidefs "github.com/FoxDenHome/goscsi/godefs/scsi"
+	"github.com/FoxDenHome/tapemgr/util"
 )
 
+// TestUnitReady returns false without error for transient conditions
+// (UNIT ATTENTION, becoming ready) and an error for everything else
 func (d *SCSIDevice) TestUnitReady() (bool, error) {
-	resp, err := d.request([]byte{
+	_, err := d.testUnitReady()
+	return err == nil, ignoreTransient(err)
+}
+
+func (d *SCSIDevice) testUnitReady() ([]byte, error) {
+	return d.request([]byte{
 		scsidefs.TEST_UNIT_READY, 0x00, 0x00, 0x00, 0x00, 0x00,
-	}, 6)
-	if err != nil {
+	}, 0)
+}
+
+// MediumPresent reports whether a cartridge is in the drive, even if it is not ready yet
+func (d *SCSIDevice) MediumPresent() (bool, error) {
+	_, err := d.testUnitReady()
+	switch {
+	case err == nil, errors.Is(err, ErrBecomingReady):
+		return true, nil
+	case errors.Is(err, ErrMediumNotPresent), errors.Is(err, ErrUnitAttention):
+		// A unit attention reports the medium change, the next poll tells if it is there
+		return false, nil
+	default:
 		return false, err
 	}
+}
 
-	return resp[5] == 0x00, nil
+func ignoreTransient(err error) error {
+	if errors.Is(err, ErrUnitAttention) || errors.Is(err, ErrBecomingReady) {
+		return nil
+	}
+	return err
 }
 
-func (d *SCSIDevice) WaitForReady() error {
-	for {
-		ready, err := d.TestUnitReady()
-		if err != nil {
-			return err
-		}
-		if ready {
-			return nil
+// WaitForReady polls TEST UNIT READY until the device is ready, retrying
+// UNIT ATTENTION and "becoming ready", and failing on any other condition
+// (for example medium not present) or once timeout has elapsed
+func (d *SCSIDevice) WaitForReady(ctx context.Context, timeout time.Duration) error {
+	var lastErr error
+	err := util.Poll(ctx, timeout, func() (bool, error) {
+		_, lastErr = d.testUnitReady()
+		if lastErr == nil {
+			return true, nil
 		}
+		return false, ignoreTransient(lastErr)
+	})
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) && lastErr != nil {
+		return fmt.Errorf("device not ready after %v, last status: %w", timeout, lastErr)
 	}
+	return fmt.Errorf("waiting for device to become ready: %w", err)
 }
@@ -0,0 +1,219 @@
+package scsi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+)
+
+type Association uint8
+type VPDPage uint8
+
+const (
+	VPD_PAGE_SUPPORTED                      VPDPage = 0x00
+	VPD_PAGE_UNIT_SERIAL_NUMBER             VPDPage = 0x80
+	VPD_PAGE_DEVICE_IDENTIFICATION          VPDPage = 0x83
+	VPD_PAGE_SEQUENTIAL_ACCESS_CAPABILITIES VPDPage = 0xB0
+
+	VPD_MAX_LENGTH    = 0x1000
+	VPD_HEADER_LENGTH = 4
+
+	ASSOCIATION_LOGICAL_UNIT  Association = 0x00
+	ASSOCIATION_TARGET_PORT   Association = 0x01
+	ASSOCIATION_TARGET_DEVICE Association = 0x02
+
+	// Bits of the fifth byte of the sequential-access device capabilities page
+	SEQUENTIAL_FLAG_WORM = 0
+	SEQUENTIAL_FLAG_TSMC = 1
+)
+
+func (p VPDPage) String() string {
+	return fmt.Sprintf("%#02x", uint8(p))
+}
+
+func (p VPDPage) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (a Association) String() string {
+	switch a {
+	case ASSOCIATION_LOGICAL_UNIT:
+		return "logical unit"
+	case ASSOCIATION_TARGET_PORT:
+		return "target port"
+	case ASSOCIATION_TARGET_DEVICE:
+		return "target device"
+	default:
+		return fmt.Sprintf("association %#02x", uint8(a))
+	}
+}
+
+// Identifier is a designator of VPD page 0x83, along with what it identifies
+type Identifier struct {
+	element.Designator
+	Association Association
+}
+
+func (i Identifier) String() string {
+	return fmt.Sprintf("%s (%s)", i.Designator, i.Association)
+}
+
+func (i Identifier) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{
+		"association": i.Association.String(),
+		"designator":  i.Designator.String(),
+	})
+}
+
+// SequentialAccessCapabilities is VPD page 0xB0 of tape drives
+type SequentialAccessCapabilities struct {
+	// WORM reports support for write once, read many cartridges
+	WORM bool `json:"worm"`
+	// TSMC reports support for tape stream mirroring
+	TSMC bool `json:"tsmc"`
+}
+
+// DeviceInfo is what a device reports about itself through INQUIRY and its VPD pages
+type DeviceInfo struct {
+	Inquiry          *InquiryData                  `json:"inquiry"`
+	VPDPages         []VPDPage                     `json:"vpd-pages"`
+	Serial           string                        `json:"serial,omitempty"`
+	Identifiers      []Identifier                  `json:"identifiers,omitempty"`
+	SequentialAccess *SequentialAccessCapabilities `json:"sequential-access,omitempty"`
+}
+
+func (d *SCSIDevice) vpdPage(page VPDPage) ([]byte, error) {
+	resp, err := d.inquiry(true, uint8(page), VPD_MAX_LENGTH)
+	if err != nil {
+		return nil, err
+	}
+	return parseVPDPage(resp, page)
+}
+
+// parseVPDPage checks the header of a VPD page and returns the page without it
+func parseVPDPage(data []byte, page VPDPage) ([]byte, error) {
+	if len(data) < VPD_HEADER_LENGTH {
+		return nil, fmt.Errorf("too short VPD page %s: %d bytes", page, len(data))
+	}
+	if VPDPage(data[1]) != page {
+		return nil, fmt.Errorf("requested VPD page %s, but got page %s", page, VPDPage(data[1]))
+	}
+
+	end := VPD_HEADER_LENGTH + (int(data[2])<<8 | int(data[3]))
+	if end > len(data) {
+		end = len(data)
+	}
+	return data[VPD_HEADER_LENGTH:end], nil
+}
+
+// SupportedVPDPages lists the VPD pages the device implements (VPD page 0x00)
+func (d *SCSIDevice) SupportedVPDPages() ([]VPDPage, error) {
+	page, err := d.vpdPage(VPD_PAGE_SUPPORTED)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]VPDPage, 0, len(page))
+	for _, code := range page {
+		pages = append(pages, VPDPage(code))
+	}
+	return pages, nil
+}
+
+// UnitSerialNumber reads the serial number of VPD page 0x80
+func (d *SCSIDevice) UnitSerialNumber() (string, error) {
+	page, err := d.vpdPage(VPD_PAGE_UNIT_SERIAL_NUMBER)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.Trim(page, "\x00 ")), nil
+}
+
+// Identifiers reads the designators of VPD page 0x83
+func (d *SCSIDevice) Identifiers() ([]Identifier, error) {
+	page, err := d.vpdPage(VPD_PAGE_DEVICE_IDENTIFICATION)
+	if err != nil {
+		return nil, err
+	}
+	return parseIdentifiers(page), nil
+}
+
+func parseIdentifiers(page []byte) []Identifier {
+	var identifiers []Identifier
+	for pos := 0; pos+4 <= len(page); {
+		length := int(page[pos+3])
+		if pos+4+length > len(page) {
+			break
+		}
+		identifiers = append(identifiers, Identifier{
+			Designator: element.Designator{
+				CodeSet: element.CodeSet(page[pos] & 0x0F),
+				Type:    element.IdentifierType(page[pos+1] & 0x0F),
+				Value:   bytes.Clone(page[pos+4 : pos+4+length]),
+			},
+			Association: Association((page[pos+1] >> 4) & 0x03),
+		})
+		pos += 4 + length
+	}
+	return identifiers
+}
+
+// SequentialAccessCapabilities reads VPD page 0xB0 of tape drives
+func (d *SCSIDevice) SequentialAccessCapabilities() (*SequentialAccessCapabilities, error) {
+	page, err := d.vpdPage(VPD_PAGE_SEQUENTIAL_ACCESS_CAPABILITIES)
+	if err != nil {
+		return nil, err
+	}
+	if len(page) < 1 {
+		return nil, fmt.Errorf("too short sequential-access device capabilities page: %d bytes", len(page))
+	}
+
+	return &SequentialAccessCapabilities{
+		WORM: flagToBool(page[0], SEQUENTIAL_FLAG_WORM),
+		TSMC: flagToBool(page[0], SEQUENTIAL_FLAG_TSMC),
+	}, nil
+}
+
+// DeviceInfo reads the standard INQUIRY data and every VPD page the device supports of 0x80, 0x83 and 0xB0
+func (d *SCSIDevice) DeviceInfo() (*DeviceInfo, error) {
+	inquiry, err := d.Inquiry()
+	if err != nil {
+		return nil, fmt.Errorf("inquiry: %w", err)
+	}
+
+	info := &DeviceInfo{
+		Inquiry: inquiry,
+	}
+
+	info.VPDPages, err = d.SupportedVPDPages()
+	if err != nil {
+		return nil, fmt.Errorf("supported VPD pages: %w", err)
+	}
+
+	if slices.Contains(info.VPDPages, VPD_PAGE_UNIT_SERIAL_NUMBER) {
+		info.Serial, err = d.UnitSerialNumber()
+		if err != nil {
+			return nil, fmt.Errorf("unit serial number: %w", err)
+		}
+	}
+
+	if slices.Contains(info.VPDPages, VPD_PAGE_DEVICE_IDENTIFICATION) {
+		info.Identifiers, err = d.Identifiers()
+		if err != nil {
+			return nil, fmt.Errorf("device identification: %w", err)
+		}
+	}
+
+	// Page 0xB0 means something else for other device types
+	if inquiry.DeviceType == DEVICE_TYPE_SEQUENTIAL_ACCESS && slices.Contains(info.VPDPages, VPD_PAGE_SEQUENTIAL_ACCESS_CAPABILITIES) {
+		info.SequentialAccess, err = d.SequentialAccessCapabilities()
+		if err != nil {
+			return nil, fmt.Errorf("sequential-access device capabilities: %w", err)
+		}
+	}
+
+	return info, nil
+}
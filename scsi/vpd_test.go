@@ -0,0 +1,361 @@
+package scsi_test
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+	"github.com/FoxDenHome/tapemgr/scsi/scsitest"
+)
+
+// vpdPage encodes a VPD page with the given contents
+func vpdPage(page scsi.VPDPage, data []byte) []byte {
+	return append([]byte{scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS, uint8(page), uint8(len(data) >> 8), uint8(len(data))}, data...)
+}
+
+// identificationDescriptor encodes a designation descriptor of VPD page 0x83
+func identificationDescriptor(codeSet element.CodeSet, association scsi.Association, idType element.IdentifierType, value string) []byte {
+	desc := []byte{uint8(codeSet), uint8(association)<<4 | uint8(idType), 0x00, uint8(len(value))}
+	return append(desc, value...)
+}
+
+// vpdDevice answers INQUIRY with the given standard data and VPD pages, other pages are an illegal request
+func vpdDevice(inquiry []byte, pages map[scsi.VPDPage][]byte) *scsitest.Device {
+	dev := scsitest.New()
+	dev.Handle(scsi.INQUIRY, func(cdb []byte, todev []byte) ([]byte, error) {
+		if cdb[1]&0x01 == 0 {
+			return inquiry, nil
+		}
+		if page, ok := pages[scsi.VPDPage(cdb[2])]; ok {
+			return page, nil
+		}
+		return nil, scsitest.CheckCondition(scsi.INQUIRY, scsi.SENSE_KEY_ILLEGAL_REQUEST, 0x24, 0x00)
+	})
+	return dev
+}
+
+func TestVPDPageCDB(t *testing.T) {
+	dev := scsitest.New()
+	dev.Reply(scsi.INQUIRY, vpdPage(scsi.VPD_PAGE_UNIT_SERIAL_NUMBER, []byte("1013000100")), nil)
+
+	_, err := scsi.NewDevice(dev).UnitSerialNumber()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// EVPD set, the largest page the device may return
+	want := []byte{scsi.INQUIRY, 0x01, 0x80, 0x10, 0x00, 0x00}
+	requests := dev.Requests()
+	if len(requests) != 1 || !bytes.Equal(requests[0].CDB, want) {
+		t.Errorf("got requests %+v, want CDB % x", requests, want)
+	}
+}
+
+func TestSupportedVPDPages(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    []byte
+		want    []scsi.VPDPage
+		wantErr string
+	}{
+		{
+			name: "pages",
+			resp: vpdPage(scsi.VPD_PAGE_SUPPORTED, []byte{0x00, 0x80, 0x83, 0xB0, 0xC0}),
+			want: []scsi.VPDPage{0x00, 0x80, 0x83, 0xB0, 0xC0},
+		},
+		{
+			name: "no pages",
+			resp: vpdPage(scsi.VPD_PAGE_SUPPORTED, nil),
+			want: []scsi.VPDPage{},
+		},
+		{
+			name: "page longer than the response",
+			resp: vpdPage(scsi.VPD_PAGE_SUPPORTED, []byte{0x00, 0x80, 0x83})[:6],
+			want: []scsi.VPDPage{0x00, 0x80},
+		},
+		{
+			name:    "wrong page code",
+			resp:    vpdPage(scsi.VPD_PAGE_UNIT_SERIAL_NUMBER, []byte("1013000100")),
+			wantErr: "requested VPD page 0x00, but got page 0x80",
+		},
+		{
+			name:    "too short",
+			resp:    []byte{0x01, 0x00, 0x00},
+			wantErr: "too short VPD page 0x00: 3 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := scsitest.New()
+			dev.Reply(scsi.INQUIRY, tt.resp, nil)
+
+			pages, err := scsi.NewDevice(dev).SupportedVPDPages()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SupportedVPDPages: %v", err)
+			}
+			if !slices.Equal(pages, tt.want) {
+				t.Errorf("got pages %v, want %v", pages, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnitSerialNumber(t *testing.T) {
+	tests := []struct {
+		name string
+		resp []byte
+		want string
+	}{
+		{"plain", vpdPage(scsi.VPD_PAGE_UNIT_SERIAL_NUMBER, []byte("1013000100")), "1013000100"},
+		{"space padded", vpdPage(scsi.VPD_PAGE_UNIT_SERIAL_NUMBER, []byte("  1013000100  ")), "1013000100"},
+		{"NUL terminated", vpdPage(scsi.VPD_PAGE_UNIT_SERIAL_NUMBER, []byte("HU19087F4K\x00\x00")), "HU19087F4K"},
+		{"empty", vpdPage(scsi.VPD_PAGE_UNIT_SERIAL_NUMBER, nil), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := scsitest.New()
+			dev.Reply(scsi.INQUIRY, tt.resp, nil)
+
+			serial, err := scsi.NewDevice(dev).UnitSerialNumber()
+			if err != nil {
+				t.Fatalf("UnitSerialNumber: %v", err)
+			}
+			if serial != tt.want {
+				t.Errorf("got serial %q, want %q", serial, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdentifiers(t *testing.T) {
+	naa := "\x50\x05\x07\x63\x12\x4b\x0a\x11"
+
+	tests := []struct {
+		name string
+		resp []byte
+		want []scsi.Identifier
+	}{
+		{
+			name: "designators",
+			resp: vpdPage(scsi.VPD_PAGE_DEVICE_IDENTIFICATION, slices.Concat(
+				identificationDescriptor(element.CODE_SET_ASCII, scsi.ASSOCIATION_LOGICAL_UNIT, element.IDENTIFIER_TYPE_T10, "IBM     ULT3580-HH8     1013000100"),
+				identificationDescriptor(element.CODE_SET_BINARY, scsi.ASSOCIATION_TARGET_PORT, element.IDENTIFIER_TYPE_NAA, naa),
+				identificationDescriptor(element.CODE_SET_UTF8, scsi.ASSOCIATION_TARGET_DEVICE, element.IDENTIFIER_TYPE_VENDOR, "HU19087F4K"),
+			)),
+			want: []scsi.Identifier{
+				{
+					Designator:  element.Designator{CodeSet: element.CODE_SET_ASCII, Type: element.IDENTIFIER_TYPE_T10, Value: []byte("IBM     ULT3580-HH8     1013000100")},
+					Association: scsi.ASSOCIATION_LOGICAL_UNIT,
+				},
+				{
+					Designator:  element.Designator{CodeSet: element.CODE_SET_BINARY, Type: element.IDENTIFIER_TYPE_NAA, Value: []byte(naa)},
+					Association: scsi.ASSOCIATION_TARGET_PORT,
+				},
+				{
+					Designator:  element.Designator{CodeSet: element.CODE_SET_UTF8, Type: element.IDENTIFIER_TYPE_VENDOR, Value: []byte("HU19087F4K")},
+					Association: scsi.ASSOCIATION_TARGET_DEVICE,
+				},
+			},
+		},
+		{
+			// Protocol identifier and PIV bits are not part of the code set and type
+			name: "protocol identifier and PIV",
+			resp: vpdPage(scsi.VPD_PAGE_DEVICE_IDENTIFICATION, []byte{0x61, 0x93, 0x00, 0x08, 0x50, 0x05, 0x07, 0x63, 0x12, 0x4b, 0x0a, 0x11}),
+			want: []scsi.Identifier{
+				{
+					Designator:  element.Designator{CodeSet: element.CODE_SET_BINARY, Type: element.IDENTIFIER_TYPE_NAA, Value: []byte(naa)},
+					Association: scsi.ASSOCIATION_TARGET_PORT,
+				},
+			},
+		},
+		{
+			name: "no designators",
+			resp: vpdPage(scsi.VPD_PAGE_DEVICE_IDENTIFICATION, nil),
+		},
+		{
+			// The last designator claims more bytes than the page has
+			name: "truncated designator",
+			resp: vpdPage(scsi.VPD_PAGE_DEVICE_IDENTIFICATION, slices.Concat(
+				identificationDescriptor(element.CODE_SET_BINARY, scsi.ASSOCIATION_LOGICAL_UNIT, element.IDENTIFIER_TYPE_NAA, naa),
+				identificationDescriptor(element.CODE_SET_ASCII, scsi.ASSOCIATION_LOGICAL_UNIT, element.IDENTIFIER_TYPE_T10, "IBM     ULT3580-HH8")[:10],
+			)),
+			want: []scsi.Identifier{
+				{
+					Designator:  element.Designator{CodeSet: element.CODE_SET_BINARY, Type: element.IDENTIFIER_TYPE_NAA, Value: []byte(naa)},
+					Association: scsi.ASSOCIATION_LOGICAL_UNIT,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := scsitest.New()
+			dev.Reply(scsi.INQUIRY, tt.resp, nil)
+
+			identifiers, err := scsi.NewDevice(dev).Identifiers()
+			if err != nil {
+				t.Fatalf("Identifiers: %v", err)
+			}
+			if !reflect.DeepEqual(identifiers, tt.want) {
+				t.Errorf("got identifiers %v, want %v", identifiers, tt.want)
+			}
+		})
+	}
+}
+
+func TestSequentialAccessCapabilities(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    []byte
+		want    scsi.SequentialAccessCapabilities
+		wantErr string
+	}{
+		{"none", vpdPage(scsi.VPD_PAGE_SEQUENTIAL_ACCESS_CAPABILITIES, []byte{0x00, 0x00}), scsi.SequentialAccessCapabilities{}, ""},
+		{"WORM", vpdPage(scsi.VPD_PAGE_SEQUENTIAL_ACCESS_CAPABILITIES, []byte{0x01, 0x00}), scsi.SequentialAccessCapabilities{WORM: true}, ""},
+		{"TSMC", vpdPage(scsi.VPD_PAGE_SEQUENTIAL_ACCESS_CAPABILITIES, []byte{0x02}), scsi.SequentialAccessCapabilities{TSMC: true}, ""},
+		{"both with reserved bits", vpdPage(scsi.VPD_PAGE_SEQUENTIAL_ACCESS_CAPABILITIES, []byte{0xF3}), scsi.SequentialAccessCapabilities{WORM: true, TSMC: true}, ""},
+		{"empty", vpdPage(scsi.VPD_PAGE_SEQUENTIAL_ACCESS_CAPABILITIES, nil), scsi.SequentialAccessCapabilities{}, "too short sequential-access device capabilities page: 0 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := scsitest.New()
+			dev.Reply(scsi.INQUIRY, tt.resp, nil)
+
+			capabilities, err := scsi.NewDevice(dev).SequentialAccessCapabilities()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SequentialAccessCapabilities: %v", err)
+			}
+			if *capabilities != tt.want {
+				t.Errorf("got %+v, want %+v", *capabilities, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeviceInfo(t *testing.T) {
+	serial := vpdPage(scsi.VPD_PAGE_UNIT_SERIAL_NUMBER, []byte("1013000100"))
+	identification := vpdPage(scsi.VPD_PAGE_DEVICE_IDENTIFICATION,
+		identificationDescriptor(element.CODE_SET_ASCII, scsi.ASSOCIATION_LOGICAL_UNIT, element.IDENTIFIER_TYPE_T10, "IBM     ULT3580-HH8     1013000100"))
+	sequential := vpdPage(scsi.VPD_PAGE_SEQUENTIAL_ACCESS_CAPABILITIES, []byte{0x01})
+
+	tests := []struct {
+		name           string
+		deviceType     uint8
+		pages          map[scsi.VPDPage][]byte
+		wantPages      []scsi.VPDPage
+		wantSerial     string
+		wantIDs        int
+		wantSequential *scsi.SequentialAccessCapabilities
+		wantRequests   int
+		wantErr        string
+	}{
+		{
+			name:       "tape drive",
+			deviceType: scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS,
+			pages: map[scsi.VPDPage][]byte{
+				scsi.VPD_PAGE_SUPPORTED:                      vpdPage(scsi.VPD_PAGE_SUPPORTED, []byte{0x00, 0x80, 0x83, 0xB0}),
+				scsi.VPD_PAGE_UNIT_SERIAL_NUMBER:             serial,
+				scsi.VPD_PAGE_DEVICE_IDENTIFICATION:          identification,
+				scsi.VPD_PAGE_SEQUENTIAL_ACCESS_CAPABILITIES: sequential,
+			},
+			wantPages:      []scsi.VPDPage{0x00, 0x80, 0x83, 0xB0},
+			wantSerial:     "1013000100",
+			wantIDs:        1,
+			wantSequential: &scsi.SequentialAccessCapabilities{WORM: true},
+			wantRequests:   5,
+		},
+		{
+			// Page 0xB0 of a medium changer is not the sequential-access capabilities page
+			name:       "medium changer",
+			deviceType: scsi.DEVICE_TYPE_MEDIUM_CHANGER,
+			pages: map[scsi.VPDPage][]byte{
+				scsi.VPD_PAGE_SUPPORTED:                      vpdPage(scsi.VPD_PAGE_SUPPORTED, []byte{0x00, 0x80, 0xB0}),
+				scsi.VPD_PAGE_UNIT_SERIAL_NUMBER:             serial,
+				scsi.VPD_PAGE_SEQUENTIAL_ACCESS_CAPABILITIES: sequential,
+			},
+			wantPages:    []scsi.VPDPage{0x00, 0x80, 0xB0},
+			wantSerial:   "1013000100",
+			wantRequests: 3,
+		},
+		{
+			// Unsupported pages are not requested
+			name:       "only supported pages",
+			deviceType: scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS,
+			pages: map[scsi.VPDPage][]byte{
+				scsi.VPD_PAGE_SUPPORTED: vpdPage(scsi.VPD_PAGE_SUPPORTED, []byte{0x00}),
+			},
+			wantPages:    []scsi.VPDPage{0x00},
+			wantRequests: 2,
+		},
+		{
+			name:       "failing supported page",
+			deviceType: scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS,
+			pages:      map[scsi.VPDPage][]byte{},
+			wantErr:    "supported VPD pages",
+		},
+		{
+			name:       "failing serial number page",
+			deviceType: scsi.DEVICE_TYPE_SEQUENTIAL_ACCESS,
+			pages: map[scsi.VPDPage][]byte{
+				scsi.VPD_PAGE_SUPPORTED: vpdPage(scsi.VPD_PAGE_SUPPORTED, []byte{0x00, 0x80}),
+			},
+			wantErr: "unit serial number",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := vpdDevice(standardInquiry(tt.deviceType, true, "IBM", "ULT3580-HH8", "N9M1"), tt.pages)
+
+			info, err := scsi.NewDevice(dev).DeviceInfo()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DeviceInfo: %v", err)
+			}
+
+			if info.Inquiry.DeviceType != tt.deviceType || info.Inquiry.Product != "ULT3580-HH8" {
+				t.Errorf("got inquiry %+v", *info.Inquiry)
+			}
+			if !slices.Equal(info.VPDPages, tt.wantPages) {
+				t.Errorf("got pages %v, want %v", info.VPDPages, tt.wantPages)
+			}
+			if info.Serial != tt.wantSerial {
+				t.Errorf("got serial %q, want %q", info.Serial, tt.wantSerial)
+			}
+			if len(info.Identifiers) != tt.wantIDs {
+				t.Errorf("got identifiers %v, want %d", info.Identifiers, tt.wantIDs)
+			}
+			if !reflect.DeepEqual(info.SequentialAccess, tt.wantSequential) {
+				t.Errorf("got sequential-access capabilities %+v, want %+v", info.SequentialAccess, tt.wantSequential)
+			}
+			if requests := dev.Requests(); len(requests) != tt.wantRequests {
+				t.Errorf("sent %d inquiries, want %d", len(requests), tt.wantRequests)
+			}
+		})
+	}
+}
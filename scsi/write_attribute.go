@@ -0,0 +1,86 @@
+package scsi
+
+import (
+	"fmt"
+)
+
+const (
+	WRITE_ATTRIBUTE = 0x8D
+
+	// First of the vendor specific host type attributes, LTFS owns the user medium text label
+	// and of this range only uses 0x1623 to lock volumes
+	ATTRIBUTE_HOST_LABEL        = 0x1400
+	ATTRIBUTE_HOST_LABEL_LENGTH = 160
+)
+
+// WriteAttributes stores attributes in the medium auxiliary memory of the loaded cartridge
+func (d *SCSIDevice) WriteAttributes(partition uint8, attributes ...Attribute) error {
+	data := make([]byte, 4)
+	for _, attr := range attributes {
+		if len(attr.Value) > 0xFFFF {
+			return fmt.Errorf("attribute %#04x too long: %d bytes", attr.ID, len(attr.Value))
+		}
+		data = append(data,
+			uint8(attr.ID>>8),
+			uint8(attr.ID),
+			uint8(attr.Format&0x03),
+			uint8(len(attr.Value)>>8),
+			uint8(len(attr.Value)),
+		)
+		data = append(data, attr.Value...)
+	}
+
+	dataLen := len(data) - 4
+	data[0] = uint8(dataLen >> 24)
+	data[1] = uint8(dataLen >> 16)
+	data[2] = uint8(dataLen >> 8)
+	data[3] = uint8(dataLen)
+
+	return d.sendWithTimeout([]byte{
+		WRITE_ATTRIBUTE,
+		0x01,             // Write-through cache, so the attribute survives an unexpected power loss
+		0x00, 0x00, 0x00, // Restricted
+		0x00, // Logical volume number
+		0x00,
+		partition,
+		0x00, 0x00,
+		uint8(len(data) >> 24),
+		uint8(len(data) >> 16),
+		uint8(len(data) >> 8),
+		uint8(len(data)),
+		0x00,
+		0x00,
+	}, data, ATTRIBUTE_TIMEOUT)
+}
+
+// HostLabel returns the label tapemgr keeps in the cartridge memory, or an empty string if none is set
+func (d *SCSIDevice) HostLabel() (string, error) {
+	attributes, err := d.ReadAttributes(0)
+	if err != nil {
+		return "", err
+	}
+
+	for _, attr := range attributes {
+		if attr.ID == ATTRIBUTE_HOST_LABEL {
+			return attr.String(), nil
+		}
+	}
+	return "", nil
+}
+
+// SetHostLabel replaces the label tapemgr keeps in the cartridge memory
+func (d *SCSIDevice) SetHostLabel(label string) error {
+	if len(label) > ATTRIBUTE_HOST_LABEL_LENGTH {
+		return fmt.Errorf("host label too long: %d bytes, at most %d allowed", len(label), ATTRIBUTE_HOST_LABEL_LENGTH)
+	}
+
+	// Padded with NULs to a fixed length like the user medium text label, so rewriting it never needs more space
+	value := make([]byte, ATTRIBUTE_HOST_LABEL_LENGTH)
+	copy(value, label)
+
+	return d.WriteAttributes(0, Attribute{
+		ID:     ATTRIBUTE_HOST_LABEL,
+		Format: ATTRIBUTE_FORMAT_TEXT,
+		Value:  value,
+	})
+}
@@ -0,0 +1,71 @@
+package scsi_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/scsitest"
+)
+
+const (
+	OPCODE_READ_ATTRIBUTE  = 0x8C
+	OPCODE_WRITE_ATTRIBUTE = 0x8D
+)
+
+func attribute(id uint16, value string) []byte {
+	header := []byte{0, 0, uint8(scsi.ATTRIBUTE_FORMAT_TEXT), 0, 0}
+	binary.BigEndian.PutUint16(header[0:2], id)
+	binary.BigEndian.PutUint16(header[3:5], uint16(len(value)))
+	return append(header, value...)
+}
+
+func TestSetHostLabel(t *testing.T) {
+	dev := scsitest.New()
+	dev.Reply(OPCODE_WRITE_ATTRIBUTE, nil, nil)
+
+	err := scsi.NewDevice(dev).SetHostLabel("tapemgr barcode=ABC123L8")
+	if err != nil {
+		t.Fatalf("SetHostLabel: %v", err)
+	}
+
+	data := dev.Requests()[0].Data
+	if id := binary.BigEndian.Uint16(data[4:6]); id != scsi.ATTRIBUTE_HOST_LABEL {
+		t.Errorf("wrote attribute %#04x, want %#04x", id, scsi.ATTRIBUTE_HOST_LABEL)
+	}
+	if length := binary.BigEndian.Uint16(data[7:9]); length != scsi.ATTRIBUTE_HOST_LABEL_LENGTH {
+		t.Errorf("wrote %d bytes, want %d", length, scsi.ATTRIBUTE_HOST_LABEL_LENGTH)
+	}
+	if !bytes.HasPrefix(data[9:], []byte("tapemgr barcode=ABC123L8\x00")) {
+		t.Errorf("wrote %q", data[9:])
+	}
+}
+
+func TestHostLabelKeepsVolumeName(t *testing.T) {
+	attributes := append(attribute(scsi.ATTRIBUTE_USER_MEDIUM_TEXT_LABEL, "ABC123L8"), attribute(scsi.ATTRIBUTE_HOST_LABEL, "tapemgr barcode=ABC123L8\x00\x00")...)
+	resp := binary.BigEndian.AppendUint32(nil, uint32(len(attributes)))
+	resp = append(resp, attributes...)
+
+	dev := scsitest.New()
+	dev.Handle(OPCODE_READ_ATTRIBUTE, func(cdb []byte, todev []byte) ([]byte, error) {
+		return resp, nil
+	})
+	scsiDev := scsi.NewDevice(dev)
+
+	label, err := scsiDev.HostLabel()
+	if err != nil {
+		t.Fatalf("HostLabel: %v", err)
+	}
+	if label != "tapemgr barcode=ABC123L8" {
+		t.Errorf("host label %q", label)
+	}
+
+	mam, err := scsiDev.MediumAuxiliaryMemory()
+	if err != nil {
+		t.Fatalf("MediumAuxiliaryMemory: %v", err)
+	}
+	if mam.VolumeLabel != "ABC123L8" {
+		t.Errorf("volume label %q, want the LTFS volume name", mam.VolumeLabel)
+	}
+}
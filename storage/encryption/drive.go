@@ -0,0 +1,35 @@
+package encryption
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+
+	"filippo.io/age"
+)
+
+// AES-256 keys for drive-native tape encryption
+const DRIVE_KEY_LENGTH = 32
+
+// DriveKeys derives the keys for drive-native tape encryption from the file encryption
+// identity, so there is no second secret to keep. Every key ID derives a different key.
+type DriveKeys struct {
+	// KeyID is used for tapes that were not written with a drive key yet
+	KeyID string
+
+	secret []byte
+}
+
+func NewDriveKeys(identityStr string, keyID string) (*DriveKeys, error) {
+	identity, err := age.ParseX25519Identity(identityStr)
+	if err != nil {
+		return nil, err
+	}
+	return &DriveKeys{
+		KeyID:  keyID,
+		secret: []byte(identity.String()),
+	}, nil
+}
+
+func (k *DriveKeys) Key(keyID string) ([]byte, error) {
+	return hkdf.Key(sha256.New, k.secret, nil, "tapemgr drive encryption key "+keyID, DRIVE_KEY_LENGTH)
+}
@@ -1,6 +1,7 @@
 package encryption
 
 import (
+	"context"
 	"errors"
 	"io"
 	"os"
@@ -35,8 +36,8 @@ func NewFileCryptorEncryptOnly(recipientStr string) (*FileCryptor, error) {
 	}, nil
 }
 
-func (c *FileCryptor) Encrypt(src, dest string) error {
-	err := c.encrypt(src, dest)
+func (c *FileCryptor) Encrypt(ctx context.Context, src, dest string) error {
+	err := c.encrypt(ctx, src, dest)
 	if err != nil {
 		_ = os.Remove(dest)
 		return err
@@ -45,15 +46,42 @@ func (c *FileCryptor) Encrypt(src, dest string) error {
 	return generateXattr(src, dest)
 }
 
-func (c *FileCryptor) EncryptMkdirAll(src, dest string) error {
+func (c *FileCryptor) EncryptMkdirAll(ctx context.Context, src, dest string) error {
 	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
 		return err
 	}
-	return c.Encrypt(src, dest)
+	return c.Encrypt(ctx, src, dest)
 }
 
-func (c *FileCryptor) Decrypt(src, dest string) error {
-	err := c.decrypt(src, dest)
+// Copy stores a file without age encryption, for tapes the drive encrypts itself.
+// The file is marked, so Decrypt restores it by copying it back.
+func (c *FileCryptor) Copy(ctx context.Context, src, dest string) error {
+	err := copyFile(ctx, src, dest)
+	if err == nil {
+		err = markDriveEncrypted(dest)
+	}
+	if err != nil {
+		_ = os.Remove(dest)
+		return err
+	}
+
+	return generateXattr(src, dest)
+}
+
+func (c *FileCryptor) CopyMkdirAll(ctx context.Context, src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	return c.Copy(ctx, src, dest)
+}
+
+func (c *FileCryptor) Decrypt(ctx context.Context, src, dest string) error {
+	var err error
+	if isDriveEncrypted(src) {
+		err = copyFile(ctx, src, dest)
+	} else {
+		err = c.decrypt(ctx, src, dest)
+	}
 	if err != nil {
 		_ = os.Remove(dest)
 		return err
@@ -62,14 +90,14 @@ func (c *FileCryptor) Decrypt(src, dest string) error {
 	return retrieveXattr(src, dest)
 }
 
-func (c *FileCryptor) DecryptMkdirAll(src, dest string) error {
+func (c *FileCryptor) DecryptMkdirAll(ctx context.Context, src, dest string) error {
 	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
 		return err
 	}
-	return c.Decrypt(src, dest)
+	return c.Decrypt(ctx, src, dest)
 }
 
-func (c *FileCryptor) encrypt(src, dest string) error {
+func (c *FileCryptor) encrypt(ctx context.Context, src, dest string) error {
 	srcFile, err := os.Open(src)
 	if err != nil {
 		return err
@@ -88,11 +116,11 @@ func (c *FileCryptor) encrypt(src, dest string) error {
 	}
 	defer func() { _ = writer.Close() }()
 
-	_, err = io.Copy(writer, srcFile)
+	_, err = io.Copy(writer, &contextReader{ctx: ctx, reader: srcFile})
 	return err
 }
 
-func (c *FileCryptor) decrypt(src, dest string) error {
+func (c *FileCryptor) decrypt(ctx context.Context, src, dest string) error {
 	if c.identity == nil {
 		return errors.New("this FileCryptor instance is not configured for decryption")
 	}
@@ -114,6 +142,26 @@ func (c *FileCryptor) decrypt(src, dest string) error {
 		return err
 	}
 
-	_, err = io.Copy(destFile, reader)
+	_, err = io.Copy(destFile, &contextReader{ctx: ctx, reader: reader})
 	return err
 }
+
+func copyFile(ctx context.Context, src, dest string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = srcFile.Close() }()
+
+	destFile, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(destFile, &contextReader{ctx: ctx, reader: srcFile})
+	if err != nil {
+		_ = destFile.Close()
+		return err
+	}
+	return destFile.Close()
+}
@@ -1,7 +1,9 @@
 package encryption
 
 import (
+	"context"
 	"crypto/aes"
+	"io"
 )
 
 func padToAESBlockSize(len int) int {
@@ -11,3 +13,17 @@ func padToAESBlockSize(len int) int {
 	len += aes.BlockSize - (len % aes.BlockSize)
 	return len
 }
+
+// contextReader aborts a copy once ctx is done, so long file copies can be interrupted
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	err := r.ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
@@ -13,6 +13,10 @@ import (
 const (
 	XATTR_MOD_TIME = "user.tapemgr.modtime"
 	XATTR_MODE     = "user.tapemgr.mode"
+	XATTR_CIPHER   = "user.tapemgr.cipher"
+
+	// CIPHER_DRIVE marks files stored without age encryption on tapes the drive encrypts
+	CIPHER_DRIVE = "drive"
 )
 
 func copyModTimes(src, dest string) error {
@@ -61,6 +65,21 @@ func copyModeXattr(src, dest string) error {
 	return os.Chmod(dest, os.FileMode(mode))
 }
 
+func markDriveEncrypted(dest string) error {
+	return xattr.Set(dest, XATTR_CIPHER, []byte(CIPHER_DRIVE))
+}
+
+func isDriveEncrypted(src string) bool {
+	cipherBytes, err := xattr.Get(src, XATTR_CIPHER)
+	if err != nil {
+		if !errors.Is(err, xattr.ENOATTR) {
+			log.Printf("Failed to get "+XATTR_CIPHER+" xattr: %v", err)
+		}
+		return false
+	}
+	return string(cipherBytes) == CIPHER_DRIVE
+}
+
 // TODO: Implement more generic xattr loaders and storers
 
 func generateXattr(src, dest string) error {
@@ -0,0 +1,40 @@
+package inventory
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// BLANK_DIR keeps what was recorded about tapes that are not formatted yet, like their
+// write-protect tab, cartridge memory and TapeAlerts, outside of the inventory proper
+const BLANK_DIR = "blank"
+
+func blankFilename(barcode string) string {
+	return filepath.Join(BLANK_DIR, barcode+".proto")
+}
+
+// loadBlank restores what was recorded about an unformatted tape into tp
+func (i *Inventory) loadBlank(tp *tape) {
+	blank, err := loadFromFileProto(i, blankFilename(tp.Barcode))
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	} else if err != nil {
+		log.Printf("Failed to load recorded state of blank tape %s: %v", tp.Barcode, err)
+		return
+	}
+
+	proto.Merge(&tp.ProtoTape, &blank.ProtoTape)
+}
+
+// removeBlank drops the recorded state of a tape once it is in the inventory proper
+func (i *Inventory) removeBlank(barcode string) error {
+	err := os.Remove(filepath.Join(i.path, blankFilename(barcode)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const CLEANING_FILE = "cleaning.json"
+
+type CleaningCartridge struct {
+	Uses     int       `json:"uses"`
+	LastUsed time.Time `json:"last-used"`
+	Expired  bool      `json:"expired,omitempty"`
+}
+
+func (i *Inventory) loadCleaning() error {
+	i.cleaning = make(map[string]*CleaningCartridge)
+
+	data, err := os.ReadFile(filepath.Join(i.path, CLEANING_FILE))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &i.cleaning)
+}
+
+func (i *Inventory) saveCleaning() error {
+	data, err := json.MarshalIndent(i.cleaning, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(i.path, CLEANING_FILE), data, 0o644)
+}
+
+// CleaningCartridge returns how often a cleaning cartridge has been used so far
+func (i *Inventory) CleaningCartridge(barcode string) CleaningCartridge {
+	cartridge := i.cleaning[barcode]
+	if cartridge == nil {
+		return CleaningCartridge{}
+	}
+	return *cartridge
+}
+
+// RecordCleaning counts a use of a cleaning cartridge, expired marks it as used up regardless of its count
+func (i *Inventory) RecordCleaning(barcode string, expired bool) error {
+	cartridge := i.cleaning[barcode]
+	if cartridge == nil {
+		cartridge = &CleaningCartridge{}
+		i.cleaning[barcode] = cartridge
+	}
+
+	cartridge.Uses++
+	cartridge.LastUsed = time.Now().UTC()
+	cartridge.Expired = cartridge.Expired || expired
+	return i.saveCleaning()
+}
@@ -1,12 +1,9 @@
 package inventory
 
 import (
-	"path/filepath"
-	"strconv"
 	"time"
 
 	"github.com/FoxDenHome/tapemgr/scsi/drive"
-	"github.com/pkg/xattr"
 )
 
 func (f *ProtoFile) IsBetterThan(other *ProtoFile) bool {
@@ -18,7 +15,7 @@ type File interface {
 	GetPath() string
 	GetSize() int64
 	GetModifiedTime() time.Time
-	GetLTFSInfo(drive *drive.TapeDrive) (*FileLTFSInfo, error)
+	GetLTFSInfo(drive drive.Drive) (*FileLTFSInfo, error)
 }
 
 type file struct {
@@ -45,22 +42,14 @@ type FileLTFSInfo struct {
 	Partition  string
 }
 
-func (f *file) GetLTFSInfo(drive *drive.TapeDrive) (*FileLTFSInfo, error) {
-	partitionXattr, err := xattr.Get(filepath.Join(drive.MountPoint(), f.path), "user.ltfs.partition")
-	if err != nil {
-		return nil, err
-	}
-	startBlockXattr, err := xattr.Get(filepath.Join(drive.MountPoint(), f.path), "user.ltfs.startblock")
-	if err != nil {
-		return nil, err
-	}
-	startBlockNum, err := strconv.ParseInt(string(startBlockXattr), 10, 64)
+func (f *file) GetLTFSInfo(drive drive.Drive) (*FileLTFSInfo, error) {
+	partition, startBlock, err := drive.FileLocation(f.path)
 	if err != nil {
 		return nil, err
 	}
 
 	return &FileLTFSInfo{
-		StartBlock: int(startBlockNum),
-		Partition:  string(partitionXattr),
+		StartBlock: startBlock,
+		Partition:  partition,
 	}, nil
 }
@@ -5,6 +5,7 @@ import (
 	"os"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/FoxDenHome/tapemgr/storage/encryption"
 )
@@ -13,8 +14,13 @@ import (
 //go:generate protoc --go_out=. --go_opt=paths=source_relative inventory.proto
 
 type Inventory struct {
-	path  string
-	tapes map[string]*tape
+	path     string
+	cleaning map[string]*CleaningCartridge
+
+	// lock guards the tapes and scratch maps, which drives working in parallel share
+	lock    sync.RWMutex
+	tapes   map[string]*tape
+	scratch map[string]*ScratchTape
 }
 
 func New(path string) (*Inventory, error) {
@@ -67,6 +73,9 @@ func (i *Inventory) loadTapeList(suffix string, files []os.DirEntry, resave bool
 }
 
 func (i *Inventory) Reload() error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
 	i.tapes = make(map[string]*tape)
 	files, err := os.ReadDir(i.path)
 	if err != nil {
@@ -75,35 +84,81 @@ func (i *Inventory) Reload() error {
 
 	i.loadTapeList(".proto", files, false, loadFromFileProto)
 
-	return nil
+	err = i.loadScratch()
+	if err != nil {
+		return err
+	}
+	return i.loadCleaning()
 }
 
 func (i *Inventory) GetOrCreateTape(barcode string) Tape {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	tp := i.tapes[barcode]
+	if tp != nil {
+		return tp
+	}
+
+	tp = i.newTape(barcode)
+	i.tapes[barcode] = tp
+	return tp
+}
+
+// GetOrNewTape returns the tape from the inventory, or for an unknown barcode one that only
+// joins the inventory through AddTape, as done once a blank tape is formatted
+func (i *Inventory) GetOrNewTape(barcode string) Tape {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
 	tp := i.tapes[barcode]
 	if tp != nil {
 		return tp
 	}
+	return i.newTape(barcode)
+}
 
-	tp = &tape{
+// AddTape adds a tape returned by GetOrNewTape, tapes already in the inventory are kept
+func (i *Inventory) AddTape(tp Tape) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	barcode := tp.GetBarcode()
+	if i.tapes[barcode] == nil {
+		i.tapes[barcode] = tp.(*tape)
+	}
+}
+
+func (i *Inventory) newTape(barcode string) *tape {
+	tp := &tape{
 		inventory: i,
 		ProtoTape: ProtoTape{
 			Barcode: barcode,
 			Files:   make(map[string]*ProtoFile),
 		},
 	}
-	i.tapes[barcode] = tp
+	i.loadBlank(tp)
 	return tp
 }
 
 func (i *Inventory) HasTape(barcode string) bool {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
 	return i.tapes[barcode] != nil
 }
 
 func (i *Inventory) TapeCount() int {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
 	return len(i.tapes)
 }
 
 func (i *Inventory) GetTapesSortByFreeDesc() []Tape {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
 	tapes := make([]Tape, 0, len(i.tapes))
 	for _, tape := range i.tapes {
 		tapes = append(tapes, tape)
@@ -115,6 +170,9 @@ func (i *Inventory) GetTapesSortByFreeDesc() []Tape {
 }
 
 func (i *Inventory) GetBestFiles(pathCryptor *encryption.PathCryptor) map[string]File {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
 	files := make(map[string]File)
 	for _, tape := range i.tapes {
 		for path, protoFile := range tape.Files {
@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.36.9
+// 	protoc-gen-go v1.36.10
 // 	protoc        v6.32.0
 // source: inventory.proto
 
@@ -75,20 +75,278 @@ func (x *ProtoFile) GetModifiedTime() *timestamppb.Timestamp {
 	return nil
 }
 
+type ProtoTapeAlert struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Flag          uint32                 `protobuf:"varint,1,opt,name=flag,proto3" json:"flag,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProtoTapeAlert) Reset() {
+	*x = ProtoTapeAlert{}
+	mi := &file_inventory_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProtoTapeAlert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProtoTapeAlert) ProtoMessage() {}
+
+func (x *ProtoTapeAlert) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProtoTapeAlert.ProtoReflect.Descriptor instead.
+func (*ProtoTapeAlert) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ProtoTapeAlert) GetFlag() uint32 {
+	if x != nil {
+		return x.Flag
+	}
+	return 0
+}
+
+func (x *ProtoTapeAlert) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+type ProtoMedium struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Manufacturer    string                 `protobuf:"bytes,1,opt,name=manufacturer,proto3" json:"manufacturer,omitempty"`
+	SerialNumber    string                 `protobuf:"bytes,2,opt,name=serial_number,json=serialNumber,proto3" json:"serial_number,omitempty"`
+	ManufactureDate string                 `protobuf:"bytes,3,opt,name=manufacture_date,json=manufactureDate,proto3" json:"manufacture_date,omitempty"`
+	LoadCount       uint64                 `protobuf:"varint,4,opt,name=load_count,json=loadCount,proto3" json:"load_count,omitempty"`
+	TotalWrittenMib uint64                 `protobuf:"varint,5,opt,name=total_written_mib,json=totalWrittenMib,proto3" json:"total_written_mib,omitempty"`
+	TotalReadMib    uint64                 `protobuf:"varint,6,opt,name=total_read_mib,json=totalReadMib,proto3" json:"total_read_mib,omitempty"`
+	RemainingMib    uint64                 `protobuf:"varint,7,opt,name=remaining_mib,json=remainingMib,proto3" json:"remaining_mib,omitempty"`
+	MaximumMib      uint64                 `protobuf:"varint,8,opt,name=maximum_mib,json=maximumMib,proto3" json:"maximum_mib,omitempty"`
+	LastLoads       []string               `protobuf:"bytes,9,rep,name=last_loads,json=lastLoads,proto3" json:"last_loads,omitempty"`
+	Updated         *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated,proto3" json:"updated,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ProtoMedium) Reset() {
+	*x = ProtoMedium{}
+	mi := &file_inventory_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProtoMedium) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProtoMedium) ProtoMessage() {}
+
+func (x *ProtoMedium) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProtoMedium.ProtoReflect.Descriptor instead.
+func (*ProtoMedium) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ProtoMedium) GetManufacturer() string {
+	if x != nil {
+		return x.Manufacturer
+	}
+	return ""
+}
+
+func (x *ProtoMedium) GetSerialNumber() string {
+	if x != nil {
+		return x.SerialNumber
+	}
+	return ""
+}
+
+func (x *ProtoMedium) GetManufactureDate() string {
+	if x != nil {
+		return x.ManufactureDate
+	}
+	return ""
+}
+
+func (x *ProtoMedium) GetLoadCount() uint64 {
+	if x != nil {
+		return x.LoadCount
+	}
+	return 0
+}
+
+func (x *ProtoMedium) GetTotalWrittenMib() uint64 {
+	if x != nil {
+		return x.TotalWrittenMib
+	}
+	return 0
+}
+
+func (x *ProtoMedium) GetTotalReadMib() uint64 {
+	if x != nil {
+		return x.TotalReadMib
+	}
+	return 0
+}
+
+func (x *ProtoMedium) GetRemainingMib() uint64 {
+	if x != nil {
+		return x.RemainingMib
+	}
+	return 0
+}
+
+func (x *ProtoMedium) GetMaximumMib() uint64 {
+	if x != nil {
+		return x.MaximumMib
+	}
+	return 0
+}
+
+func (x *ProtoMedium) GetLastLoads() []string {
+	if x != nil {
+		return x.LastLoads
+	}
+	return nil
+}
+
+func (x *ProtoMedium) GetUpdated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+type ProtoDriveWrite struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Vendor        string                 `protobuf:"bytes,1,opt,name=vendor,proto3" json:"vendor,omitempty"`
+	Product       string                 `protobuf:"bytes,2,opt,name=product,proto3" json:"product,omitempty"`
+	SerialNumber  string                 `protobuf:"bytes,3,opt,name=serial_number,json=serialNumber,proto3" json:"serial_number,omitempty"`
+	Firmware      string                 `protobuf:"bytes,4,opt,name=firmware,proto3" json:"firmware,omitempty"`
+	FirstWrite    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=first_write,json=firstWrite,proto3" json:"first_write,omitempty"`
+	LastWrite     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=last_write,json=lastWrite,proto3" json:"last_write,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProtoDriveWrite) Reset() {
+	*x = ProtoDriveWrite{}
+	mi := &file_inventory_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProtoDriveWrite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProtoDriveWrite) ProtoMessage() {}
+
+func (x *ProtoDriveWrite) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProtoDriveWrite.ProtoReflect.Descriptor instead.
+func (*ProtoDriveWrite) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ProtoDriveWrite) GetVendor() string {
+	if x != nil {
+		return x.Vendor
+	}
+	return ""
+}
+
+func (x *ProtoDriveWrite) GetProduct() string {
+	if x != nil {
+		return x.Product
+	}
+	return ""
+}
+
+func (x *ProtoDriveWrite) GetSerialNumber() string {
+	if x != nil {
+		return x.SerialNumber
+	}
+	return ""
+}
+
+func (x *ProtoDriveWrite) GetFirmware() string {
+	if x != nil {
+		return x.Firmware
+	}
+	return ""
+}
+
+func (x *ProtoDriveWrite) GetFirstWrite() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FirstWrite
+	}
+	return nil
+}
+
+func (x *ProtoDriveWrite) GetLastWrite() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastWrite
+	}
+	return nil
+}
+
 type ProtoTape struct {
 	state   protoimpl.MessageState `protogen:"open.v1"`
 	Barcode string                 `protobuf:"bytes,1,opt,name=barcode,proto3" json:"barcode,omitempty"`
 	Size    int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
 	Free    int64                  `protobuf:"varint,3,opt,name=free,proto3" json:"free,omitempty"`
 	// 4
-	Files         map[string]*ProtoFile `protobuf:"bytes,5,rep,name=files,proto3" json:"files,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	Files           map[string]*ProtoFile `protobuf:"bytes,5,rep,name=files,proto3" json:"files,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Alerts          []*ProtoTapeAlert     `protobuf:"bytes,6,rep,name=alerts,proto3" json:"alerts,omitempty"`
+	Medium          *ProtoMedium          `protobuf:"bytes,7,opt,name=medium,proto3" json:"medium,omitempty"`
+	WriteProtected  bool                  `protobuf:"varint,8,opt,name=write_protected,json=writeProtected,proto3" json:"write_protected,omitempty"`
+	MediaType       string                `protobuf:"bytes,9,opt,name=media_type,json=mediaType,proto3" json:"media_type,omitempty"`
+	EncryptionKeyId string                `protobuf:"bytes,10,opt,name=encryption_key_id,json=encryptionKeyId,proto3" json:"encryption_key_id,omitempty"`
+	DriveWrites     []*ProtoDriveWrite    `protobuf:"bytes,11,rep,name=drive_writes,json=driveWrites,proto3" json:"drive_writes,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *ProtoTape) Reset() {
 	*x = ProtoTape{}
-	mi := &file_inventory_proto_msgTypes[1]
+	mi := &file_inventory_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -100,7 +358,7 @@ func (x *ProtoTape) String() string {
 func (*ProtoTape) ProtoMessage() {}
 
 func (x *ProtoTape) ProtoReflect() protoreflect.Message {
-	mi := &file_inventory_proto_msgTypes[1]
+	mi := &file_inventory_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -113,7 +371,7 @@ func (x *ProtoTape) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProtoTape.ProtoReflect.Descriptor instead.
 func (*ProtoTape) Descriptor() ([]byte, []int) {
-	return file_inventory_proto_rawDescGZIP(), []int{1}
+	return file_inventory_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *ProtoTape) GetBarcode() string {
@@ -144,6 +402,48 @@ func (x *ProtoTape) GetFiles() map[string]*ProtoFile {
 	return nil
 }
 
+func (x *ProtoTape) GetAlerts() []*ProtoTapeAlert {
+	if x != nil {
+		return x.Alerts
+	}
+	return nil
+}
+
+func (x *ProtoTape) GetMedium() *ProtoMedium {
+	if x != nil {
+		return x.Medium
+	}
+	return nil
+}
+
+func (x *ProtoTape) GetWriteProtected() bool {
+	if x != nil {
+		return x.WriteProtected
+	}
+	return false
+}
+
+func (x *ProtoTape) GetMediaType() string {
+	if x != nil {
+		return x.MediaType
+	}
+	return ""
+}
+
+func (x *ProtoTape) GetEncryptionKeyId() string {
+	if x != nil {
+		return x.EncryptionKeyId
+	}
+	return ""
+}
+
+func (x *ProtoTape) GetDriveWrites() []*ProtoDriveWrite {
+	if x != nil {
+		return x.DriveWrites
+	}
+	return nil
+}
+
 var File_inventory_proto protoreflect.FileDescriptor
 
 const file_inventory_proto_rawDesc = "" +
@@ -151,12 +451,47 @@ const file_inventory_proto_rawDesc = "" +
 	"\x0finventory.proto\x12 network.foxden.tapemgr.inventory\x1a\x1fgoogle/protobuf/timestamp.proto\"`\n" +
 	"\tProtoFile\x12\x12\n" +
 	"\x04size\x18\x02 \x01(\x03R\x04size\x12?\n" +
-	"\rmodified_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\fmodifiedTime\"\x82\x02\n" +
+	"\rmodified_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\fmodifiedTime\"T\n" +
+	"\x0eProtoTapeAlert\x12\x12\n" +
+	"\x04flag\x18\x01 \x01(\rR\x04flag\x12.\n" +
+	"\x04time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\"\x8d\x03\n" +
+	"\vProtoMedium\x12\"\n" +
+	"\fmanufacturer\x18\x01 \x01(\tR\fmanufacturer\x12#\n" +
+	"\rserial_number\x18\x02 \x01(\tR\fserialNumber\x12)\n" +
+	"\x10manufacture_date\x18\x03 \x01(\tR\x0fmanufactureDate\x12\x1d\n" +
+	"\n" +
+	"load_count\x18\x04 \x01(\x04R\tloadCount\x12*\n" +
+	"\x11total_written_mib\x18\x05 \x01(\x04R\x0ftotalWrittenMib\x12$\n" +
+	"\x0etotal_read_mib\x18\x06 \x01(\x04R\ftotalReadMib\x12#\n" +
+	"\rremaining_mib\x18\a \x01(\x04R\fremainingMib\x12\x1f\n" +
+	"\vmaximum_mib\x18\b \x01(\x04R\n" +
+	"maximumMib\x12\x1d\n" +
+	"\n" +
+	"last_loads\x18\t \x03(\tR\tlastLoads\x124\n" +
+	"\aupdated\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\aupdated\"\xfc\x01\n" +
+	"\x0fProtoDriveWrite\x12\x16\n" +
+	"\x06vendor\x18\x01 \x01(\tR\x06vendor\x12\x18\n" +
+	"\aproduct\x18\x02 \x01(\tR\aproduct\x12#\n" +
+	"\rserial_number\x18\x03 \x01(\tR\fserialNumber\x12\x1a\n" +
+	"\bfirmware\x18\x04 \x01(\tR\bfirmware\x12;\n" +
+	"\vfirst_write\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"firstWrite\x129\n" +
+	"\n" +
+	"last_write\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tlastWrite\"\xdd\x04\n" +
 	"\tProtoTape\x12\x18\n" +
 	"\abarcode\x18\x01 \x01(\tR\abarcode\x12\x12\n" +
 	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x12\n" +
 	"\x04free\x18\x03 \x01(\x03R\x04free\x12L\n" +
-	"\x05files\x18\x05 \x03(\v26.network.foxden.tapemgr.inventory.ProtoTape.FilesEntryR\x05files\x1ae\n" +
+	"\x05files\x18\x05 \x03(\v26.network.foxden.tapemgr.inventory.ProtoTape.FilesEntryR\x05files\x12H\n" +
+	"\x06alerts\x18\x06 \x03(\v20.network.foxden.tapemgr.inventory.ProtoTapeAlertR\x06alerts\x12E\n" +
+	"\x06medium\x18\a \x01(\v2-.network.foxden.tapemgr.inventory.ProtoMediumR\x06medium\x12'\n" +
+	"\x0fwrite_protected\x18\b \x01(\bR\x0ewriteProtected\x12\x1d\n" +
+	"\n" +
+	"media_type\x18\t \x01(\tR\tmediaType\x12*\n" +
+	"\x11encryption_key_id\x18\n" +
+	" \x01(\tR\x0fencryptionKeyId\x12T\n" +
+	"\fdrive_writes\x18\v \x03(\v21.network.foxden.tapemgr.inventory.ProtoDriveWriteR\vdriveWrites\x1ae\n" +
 	"\n" +
 	"FilesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12A\n" +
@@ -174,22 +509,32 @@ func file_inventory_proto_rawDescGZIP() []byte {
 	return file_inventory_proto_rawDescData
 }
 
-var file_inventory_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_inventory_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_inventory_proto_goTypes = []any{
 	(*ProtoFile)(nil),             // 0: network.foxden.tapemgr.inventory.ProtoFile
-	(*ProtoTape)(nil),             // 1: network.foxden.tapemgr.inventory.ProtoTape
-	nil,                           // 2: network.foxden.tapemgr.inventory.ProtoTape.FilesEntry
-	(*timestamppb.Timestamp)(nil), // 3: google.protobuf.Timestamp
+	(*ProtoTapeAlert)(nil),        // 1: network.foxden.tapemgr.inventory.ProtoTapeAlert
+	(*ProtoMedium)(nil),           // 2: network.foxden.tapemgr.inventory.ProtoMedium
+	(*ProtoDriveWrite)(nil),       // 3: network.foxden.tapemgr.inventory.ProtoDriveWrite
+	(*ProtoTape)(nil),             // 4: network.foxden.tapemgr.inventory.ProtoTape
+	nil,                           // 5: network.foxden.tapemgr.inventory.ProtoTape.FilesEntry
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
 }
 var file_inventory_proto_depIdxs = []int32{
-	3, // 0: network.foxden.tapemgr.inventory.ProtoFile.modified_time:type_name -> google.protobuf.Timestamp
-	2, // 1: network.foxden.tapemgr.inventory.ProtoTape.files:type_name -> network.foxden.tapemgr.inventory.ProtoTape.FilesEntry
-	0, // 2: network.foxden.tapemgr.inventory.ProtoTape.FilesEntry.value:type_name -> network.foxden.tapemgr.inventory.ProtoFile
-	3, // [3:3] is the sub-list for method output_type
-	3, // [3:3] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	6,  // 0: network.foxden.tapemgr.inventory.ProtoFile.modified_time:type_name -> google.protobuf.Timestamp
+	6,  // 1: network.foxden.tapemgr.inventory.ProtoTapeAlert.time:type_name -> google.protobuf.Timestamp
+	6,  // 2: network.foxden.tapemgr.inventory.ProtoMedium.updated:type_name -> google.protobuf.Timestamp
+	6,  // 3: network.foxden.tapemgr.inventory.ProtoDriveWrite.first_write:type_name -> google.protobuf.Timestamp
+	6,  // 4: network.foxden.tapemgr.inventory.ProtoDriveWrite.last_write:type_name -> google.protobuf.Timestamp
+	5,  // 5: network.foxden.tapemgr.inventory.ProtoTape.files:type_name -> network.foxden.tapemgr.inventory.ProtoTape.FilesEntry
+	1,  // 6: network.foxden.tapemgr.inventory.ProtoTape.alerts:type_name -> network.foxden.tapemgr.inventory.ProtoTapeAlert
+	2,  // 7: network.foxden.tapemgr.inventory.ProtoTape.medium:type_name -> network.foxden.tapemgr.inventory.ProtoMedium
+	3,  // 8: network.foxden.tapemgr.inventory.ProtoTape.drive_writes:type_name -> network.foxden.tapemgr.inventory.ProtoDriveWrite
+	0,  // 9: network.foxden.tapemgr.inventory.ProtoTape.FilesEntry.value:type_name -> network.foxden.tapemgr.inventory.ProtoFile
+	10, // [10:10] is the sub-list for method output_type
+	10, // [10:10] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_inventory_proto_init() }
@@ -203,7 +548,7 @@ func file_inventory_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_inventory_proto_rawDesc), len(file_inventory_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
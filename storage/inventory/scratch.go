@@ -0,0 +1,91 @@
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const SCRATCH_FILE = "scratch.json"
+
+// ScratchTape is a blank tape registered to be formatted once it is needed
+type ScratchTape struct {
+	Added time.Time `json:"added"`
+}
+
+// loadScratch must be called with the lock held
+func (i *Inventory) loadScratch() error {
+	i.scratch = make(map[string]*ScratchTape)
+
+	data, err := os.ReadFile(filepath.Join(i.path, SCRATCH_FILE))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &i.scratch)
+}
+
+// saveScratch must be called with the lock held
+func (i *Inventory) saveScratch() error {
+	data, err := json.MarshalIndent(i.scratch, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(i.path, SCRATCH_FILE), data, 0o644)
+}
+
+// IsScratch reports whether the tape is registered as a scratch tape
+func (i *Inventory) IsScratch(barcode string) bool {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	return i.scratch[barcode] != nil
+}
+
+// ScratchTapes returns the barcodes of the registered scratch tapes
+func (i *Inventory) ScratchTapes() []string {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	barcodes := make([]string, 0, len(i.scratch))
+	for barcode := range i.scratch {
+		barcodes = append(barcodes, barcode)
+	}
+	slices.Sort(barcodes)
+	return barcodes
+}
+
+// AddScratch registers tapes that may be formatted when a new tape is needed
+func (i *Inventory) AddScratch(barcodes ...string) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	for _, barcode := range barcodes {
+		if i.scratch[barcode] == nil {
+			i.scratch[barcode] = &ScratchTape{Added: time.Now().UTC()}
+		}
+	}
+	return i.saveScratch()
+}
+
+// RemoveScratch takes tapes out of the scratch pool, as done once they are formatted
+func (i *Inventory) RemoveScratch(barcodes ...string) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	removed := false
+	for _, barcode := range barcodes {
+		if i.scratch[barcode] != nil {
+			delete(i.scratch, barcode)
+			removed = true
+		}
+	}
+	if !removed {
+		return nil
+	}
+	return i.saveScratch()
+}
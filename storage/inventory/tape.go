@@ -1,24 +1,48 @@
 package inventory
 
 import (
+	"log"
+	"math"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/FoxDenHome/tapemgr/scsi"
 	"github.com/FoxDenHome/tapemgr/scsi/drive"
+	"github.com/FoxDenHome/tapemgr/scsi/lto"
 	"github.com/FoxDenHome/tapemgr/util"
-	"golang.org/x/sys/unix"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+const (
+	MIB = 1024 * 1024
+
+	// Filesystem overhead and the index partition stay well below this
+	CAPACITY_DEVIATION_WARNING = 0.1
+)
+
 type Tape interface {
 	GetBarcode() string
 	GetSize() int64
 	GetFree() int64
 	GetFiles() map[string]*ProtoFile
-	LoadFrom(drive *drive.TapeDrive) error
-	AddFiles(drive *drive.TapeDrive, path ...string) error
-	ReloadStats(drive *drive.TapeDrive) error
+	GetAlerts() []*ProtoTapeAlert
+	GetMedium() *ProtoMedium
+	GetWriteProtected() bool
+	GetMediaType() string
+	GetEncryptionKeyId() string
+	GetDriveWrites() []*ProtoDriveWrite
+	Media() lto.Media
+	LoadFrom(drive drive.Drive) error
+	AddFiles(drive drive.Drive, path ...string) error
+	ReloadStats(drive drive.Drive) error
+	RecordAlerts(flags ...scsi.TapeAlertFlag) error
+	RecordMedium(mam *scsi.MediumAuxiliaryMemory) error
+	RecordWriteProtected(writeProtected bool) error
+	RecordMediaType(media lto.Media) error
+	RecordEncryptionKeyID(keyID string) error
+	RecordDriveWrite(info *scsi.DeviceInfo) error
 	Equals(other Tape) bool
 }
 
@@ -46,7 +70,7 @@ func loadFromFileProto(inv *Inventory, filename string) (*tape, error) {
 	return tp, nil
 }
 
-func (t *tape) addDir(drive *drive.TapeDrive, path string) error {
+func (t *tape) addDir(drive drive.Drive, path string) error {
 	entries, err := os.ReadDir(filepath.Join(drive.MountPoint(), path))
 	if err != nil {
 		return err
@@ -67,7 +91,7 @@ func (t *tape) addDir(drive *drive.TapeDrive, path string) error {
 	return nil
 }
 
-func (t *tape) LoadFrom(drive *drive.TapeDrive) error {
+func (t *tape) LoadFrom(drive drive.Drive) error {
 	t.Files = make(map[string]*ProtoFile)
 	err := t.reloadStats(drive)
 	if err != nil {
@@ -82,7 +106,7 @@ func (t *tape) LoadFrom(drive *drive.TapeDrive) error {
 	return t.save()
 }
 
-func (t *tape) AddFiles(drive *drive.TapeDrive, path ...string) error {
+func (t *tape) AddFiles(drive drive.Drive, path ...string) error {
 	err := t.reloadStats(drive)
 	if err != nil {
 		return err
@@ -98,7 +122,7 @@ func (t *tape) AddFiles(drive *drive.TapeDrive, path ...string) error {
 	return t.save()
 }
 
-func (t *tape) addFile(drive *drive.TapeDrive, path string) error {
+func (t *tape) addFile(drive drive.Drive, path string) error {
 	path = util.StripLeadingSlashes(path)
 
 	stat, err := os.Stat(filepath.Join(drive.MountPoint(), path))
@@ -114,7 +138,7 @@ func (t *tape) addFile(drive *drive.TapeDrive, path string) error {
 	return nil
 }
 
-func (t *tape) ReloadStats(drive *drive.TapeDrive) error {
+func (t *tape) ReloadStats(drive drive.Drive) error {
 	err := t.reloadStats(drive)
 	if err != nil {
 		return err
@@ -123,21 +147,162 @@ func (t *tape) ReloadStats(drive *drive.TapeDrive) error {
 	return t.save()
 }
 
-func (t *tape) reloadStats(drive *drive.TapeDrive) error {
-	var stat unix.Statfs_t
-	err := unix.Statfs(drive.MountPoint(), &stat)
+func (t *tape) reloadStats(drive drive.Drive) error {
+	size, free, err := drive.Stats()
 	if err != nil {
 		return err
 	}
 
-	t.Size = int64(stat.Blocks) * int64(stat.Bsize)
-	t.Free = int64(stat.Bfree) * int64(stat.Bsize)
+	t.Size = size
+	t.Free = free
+	t.checkCapacity()
 
 	return nil
 }
 
+// checkCapacity cross-checks the filesystem's size against the capacity the cartridge memory reported,
+// or the native capacity of the cartridge type if the cartridge memory was not read yet
+func (t *tape) checkCapacity() {
+	if t.Size <= 0 {
+		return
+	}
+
+	var hardwareSize int64
+	if t.Medium != nil && t.Medium.MaximumMib > 0 {
+		hardwareSize = int64(t.Medium.MaximumMib) * MIB
+	} else {
+		hardwareSize = t.Media().NativeCapacity()
+	}
+	if hardwareSize <= 0 {
+		return
+	}
+
+	deviation := math.Abs(float64(t.Size-hardwareSize)) / float64(hardwareSize)
+	if deviation > CAPACITY_DEVIATION_WARNING {
+		log.Printf("Warning: tape %s filesystem size %s deviates %.0f%% from its hardware capacity %s", t.Barcode, util.FormatSize(t.Size), deviation*100, util.FormatSize(hardwareSize))
+	}
+}
+
+func (t *tape) RecordAlerts(flags ...scsi.TapeAlertFlag) error {
+	now := timestamppb.New(time.Now().UTC())
+	for _, flag := range flags {
+		t.Alerts = append(t.Alerts, &ProtoTapeAlert{
+			Flag: uint32(flag),
+			Time: now,
+		})
+	}
+
+	return t.save()
+}
+
+func (t *tape) RecordMedium(mam *scsi.MediumAuxiliaryMemory) error {
+	t.Medium = &ProtoMedium{
+		Manufacturer:    mam.Manufacturer,
+		SerialNumber:    mam.SerialNumber,
+		ManufactureDate: mam.ManufactureDate,
+		LoadCount:       mam.LoadCount,
+		TotalWrittenMib: mam.TotalWrittenMiB,
+		TotalReadMib:    mam.TotalReadMiB,
+		RemainingMib:    mam.RemainingMiB(),
+		MaximumMib:      mam.MaximumMiB(),
+		LastLoads:       mam.LastLoads,
+		Updated:         timestamppb.New(time.Now().UTC()),
+	}
+	t.checkCapacity()
+
+	return t.save()
+}
+
+func (t *tape) RecordWriteProtected(writeProtected bool) error {
+	if t.WriteProtected == writeProtected {
+		return nil
+	}
+	t.WriteProtected = writeProtected
+	return t.save()
+}
+
+// Media returns the recorded cartridge type, or the one in the barcode if none was recorded yet
+func (t *tape) Media() lto.Media {
+	if t.MediaType != "" {
+		media, err := lto.ParseMediaType(t.MediaType)
+		if err == nil {
+			return media
+		}
+	}
+
+	_, media, err := lto.ParseVolumeTag(t.Barcode)
+	if err != nil {
+		return lto.Media{}
+	}
+	return media
+}
+
+func (t *tape) RecordMediaType(media lto.Media) error {
+	if t.MediaType == media.Type() {
+		return nil
+	}
+	t.MediaType = media.Type()
+	return t.save()
+}
+
+// RecordEncryptionKeyID records the drive encryption key the tape is written with
+func (t *tape) RecordEncryptionKeyID(keyID string) error {
+	if t.EncryptionKeyId == keyID {
+		return nil
+	}
+	t.EncryptionKeyId = keyID
+	return t.save()
+}
+
+// RecordDriveWrite notes that the drive wrote to the tape, one entry is kept per drive serial and firmware
+func (t *tape) RecordDriveWrite(info *scsi.DeviceInfo) error {
+	var vendor, product, firmware string
+	if info.Inquiry != nil {
+		vendor = info.Inquiry.Vendor
+		product = info.Inquiry.Product
+		firmware = info.Inquiry.Revision
+	}
+	now := timestamppb.New(time.Now().UTC())
+
+	var write *ProtoDriveWrite
+	for _, existing := range t.DriveWrites {
+		if existing.SerialNumber == info.Serial && existing.Firmware == firmware {
+			write = existing
+			break
+		}
+	}
+	if write == nil {
+		write = &ProtoDriveWrite{
+			Vendor:       vendor,
+			Product:      product,
+			SerialNumber: info.Serial,
+			Firmware:     firmware,
+			FirstWrite:   now,
+		}
+		t.DriveWrites = append(t.DriveWrites, write)
+	}
+	write.LastWrite = now
+	return t.save()
+}
+
+// save writes the tape's inventory file, blank tapes only get one once they are formatted,
+// until then what is recorded about them is kept in BLANK_DIR
 func (t *tape) save() error {
-	fh, err := os.Create(filepath.Join(t.inventory.path, t.Barcode+".proto"))
+	filename := t.Barcode + ".proto"
+	if t.Size == 0 {
+		filename = blankFilename(t.Barcode)
+		err := os.MkdirAll(filepath.Join(t.inventory.path, BLANK_DIR), 0o755)
+		if err != nil {
+			return err
+		}
+	} else {
+		err := t.inventory.removeBlank(t.Barcode)
+		if err != nil {
+			return err
+		}
+	}
+
+	fh, err := os.Create(filepath.Join(t.inventory.path, filename))
 	if err != nil {
 		return err
 	}
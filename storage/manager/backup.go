@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -12,7 +13,13 @@ import (
 	"github.com/FoxDenHome/tapemgr/util"
 )
 
-func (m *Manager) Backup(targets ...string) error {
+// backupJob is a file the walk decided to store, picked up by the next free drive
+type backupJob struct {
+	path string
+	size int64
+}
+
+func (m *Manager) Backup(ctx context.Context, targets ...string) error {
 	bestFiles := m.inventory.GetBestFiles(m.path)
 
 	for _, target := range targets {
@@ -25,16 +32,20 @@ func (m *Manager) Backup(targets ...string) error {
 
 		handledFiles := make(map[string]bool)
 
-		if !info.IsDir() {
-			return m.backupFile(target, handledFiles, bestFiles)
-		}
-
-		err = m.backupDir(target, handledFiles, bestFiles)
-		if err != nil {
+		// The walk runs here, so handledFiles needs no lock, while the drives store files in parallel
+		err = parallel(ctx, m.drives, nil, func(ctx context.Context, send func(job backupJob) error) error {
+			if !info.IsDir() {
+				return m.backupFile(target, handledFiles, bestFiles, send)
+			}
+			return m.backupDir(ctx, target, handledFiles, bestFiles, send)
+		}, func(ctx context.Context, drive *managedDrive, job backupJob) error {
+			return drive.storeFile(ctx, job)
+		})
+		if err != nil || !info.IsDir() {
 			return err
 		}
 
-		err = m.tombstonePath(target, handledFiles, bestFiles)
+		err = m.writeDrive().tombstonePath(ctx, target, handledFiles, bestFiles)
 		if err != nil {
 			return err
 		}
@@ -43,17 +54,22 @@ func (m *Manager) Backup(targets ...string) error {
 	return nil
 }
 
-func (m *Manager) backupDir(target string, handledFiles map[string]bool, bestFiles map[string]inventory.File) error {
+func (m *Manager) backupDir(ctx context.Context, target string, handledFiles map[string]bool, bestFiles map[string]inventory.File, send func(job backupJob) error) error {
 	entries, err := os.ReadDir(target)
 	if err != nil {
 		return err
 	}
 	for _, entry := range entries {
+		err = ctx.Err()
+		if err != nil {
+			return err
+		}
+
 		subTarget := filepath.Join(target, entry.Name())
 		if entry.IsDir() {
-			err = m.backupDir(subTarget, handledFiles, bestFiles)
+			err = m.backupDir(ctx, subTarget, handledFiles, bestFiles, send)
 		} else {
-			err = m.backupFile(subTarget, handledFiles, bestFiles)
+			err = m.backupFile(subTarget, handledFiles, bestFiles, send)
 		}
 		if err != nil {
 			return err
@@ -63,7 +79,7 @@ func (m *Manager) backupDir(target string, handledFiles map[string]bool, bestFil
 	return nil
 }
 
-func (m *Manager) tombstonePath(path string, handledFiles map[string]bool, bestFiles map[string]inventory.File) error {
+func (m *managedDrive) tombstonePath(ctx context.Context, path string, handledFiles map[string]bool, bestFiles map[string]inventory.File) error {
 	path = filepath.Clean(path)
 
 	var err error
@@ -71,7 +87,8 @@ func (m *Manager) tombstonePath(path string, handledFiles map[string]bool, bestF
 		return fmt.Errorf("path %s is not absolute", path)
 	}
 
-	mainPath := m.path.Encrypt(path) + "/"
+	// The inventory lists files by their clear relative path, like handledFiles
+	mainPath := util.StripLeadingSlashes(path) + "/"
 
 	newFiles := make([]string, 0)
 	for clearRelPath := range bestFiles {
@@ -85,7 +102,7 @@ func (m *Manager) tombstonePath(path string, handledFiles map[string]bool, bestF
 		log.Printf("[TOMB] /%s", clearRelPath)
 		encryptedRelPath := m.path.Encrypt(clearRelPath)
 
-		err = m.loadForSize(TOMBSTONE_SIZE_SPARE)
+		err = m.loadForSize(ctx, TOMBSTONE_SIZE_SPARE)
 		if err != nil {
 			return err
 		}
@@ -93,6 +110,11 @@ func (m *Manager) tombstonePath(path string, handledFiles map[string]bool, bestF
 		newFiles = append(newFiles, encryptedRelPath)
 
 		if !DryRun {
+			err = m.prepareWrite()
+			if err != nil {
+				return err
+			}
+
 			tombPath := filepath.Join(m.drive.MountPoint(), encryptedRelPath)
 			tombDir := filepath.Dir(tombPath)
 			err = os.MkdirAll(tombDir, 0o755)
@@ -110,10 +132,12 @@ func (m *Manager) tombstonePath(path string, handledFiles map[string]bool, bestF
 		return nil
 	}
 
-	return m.currentTape.AddFiles(m.drive, newFiles...)
+	return m.updateTape(func() error {
+		return m.currentTape.AddFiles(m.drive, newFiles...)
+	})
 }
 
-func (m *Manager) backupFile(path string, handledFiles map[string]bool, bestFiles map[string]inventory.File) error {
+func (m *Manager) backupFile(path string, handledFiles map[string]bool, bestFiles map[string]inventory.File, send func(job backupJob) error) error {
 	path = filepath.Clean(path)
 
 	if !filepath.IsAbs(path) {
@@ -125,8 +149,6 @@ func (m *Manager) backupFile(path string, handledFiles map[string]bool, bestFile
 		return err
 	}
 
-	encryptedRelPath := m.path.Encrypt(path)
-
 	relPath := util.StripLeadingSlashes(path)
 	existingInfo := bestFiles[relPath]
 
@@ -142,23 +164,46 @@ func (m *Manager) backupFile(path string, handledFiles map[string]bool, bestFile
 		return nil
 	}
 
-	encryptedPath := filepath.Join(m.drive.MountPoint(), encryptedRelPath)
-	log.Printf("[STOR] %s", path)
+	return send(backupJob{
+		path: path,
+		size: candidateInfo.Size(),
+	})
+}
 
-	err = m.loadForSize(candidateInfo.Size())
+func (m *managedDrive) storeFile(ctx context.Context, job backupJob) error {
+	log.Printf("[STOR] %s", job.path)
+
+	err := m.loadForSize(ctx, job.size)
 	if err != nil {
 		return err
 	}
 
+	encryptedRelPath := m.path.Encrypt(job.path)
+	encryptedPath := filepath.Join(m.drive.MountPoint(), encryptedRelPath)
+
 	if !DryRun {
-		err = m.file.EncryptMkdirAll(path, encryptedPath)
+		err = m.prepareWrite()
+		if err != nil {
+			return err
+		}
+
+		if m.DriveEncryption != nil {
+			err = m.file.CopyMkdirAll(ctx, job.path, encryptedPath)
+		} else {
+			err = m.file.EncryptMkdirAll(ctx, job.path, encryptedPath)
+		}
 		if err != nil {
+			log.Printf("[FAIL] %s: %v, removing partial file", job.path, err)
 			_ = os.Remove(encryptedPath)
-			_ = m.currentTape.ReloadStats(m.drive)
+			_ = m.updateTape(func() error {
+				return m.currentTape.ReloadStats(m.drive)
+			})
 			return err
 		}
 
-		return m.currentTape.AddFiles(m.drive, encryptedRelPath)
+		return m.updateTape(func() error {
+			return m.currentTape.AddFiles(m.drive, encryptedRelPath)
+		})
 	}
 
 	return nil
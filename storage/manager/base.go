@@ -2,7 +2,10 @@ package manager
 
 import (
 	"fmt"
+	"regexp"
+	"sync"
 
+	"github.com/FoxDenHome/tapemgr/scsi"
 	"github.com/FoxDenHome/tapemgr/scsi/drive"
 	"github.com/FoxDenHome/tapemgr/scsi/loader"
 	"github.com/FoxDenHome/tapemgr/storage/encryption"
@@ -11,42 +14,167 @@ import (
 
 var DryRun = true
 
+// DRIVE_ADDRESS_AUTO finds the drive element by matching the drive's identifiers
+const DRIVE_ADDRESS_AUTO = -1
+
+// DriveConfig is a tape drive for the manager to use
+type DriveConfig struct {
+	Drive drive.Drive
+	// ElementAddress of the drive in the loader, or DRIVE_ADDRESS_AUTO
+	ElementAddress int
+}
+
 type Manager struct {
+	// PoolID is written into the label of formatted tapes, tapes labelled with another pool are refused
+	PoolID string
+	// AutoClean runs a cleaning cycle after unloading a tape once the drive asks for cleaning
+	AutoClean      bool
+	CleaningCycles int
+	// DriveEncryption has the drives encrypt tapes instead of encrypting files with age
+	DriveEncryption *encryption.DriveKeys
+	// ScratchBarcodes match tapes that may be formatted when a new tape is needed, besides registered scratch tapes
+	ScratchBarcodes []*regexp.Regexp
+
 	file *encryption.FileCryptor
 	path *encryption.PathCryptor
 
-	inventory          *inventory.Inventory
-	loader             *loader.TapeLoader
-	drive              *drive.TapeDrive
+	inventory *inventory.Inventory
+	loader    loader.Changer
+	manual    *ManualChanger
+	drives    []*managedDrive
+	locked    bool
+
+	// lock guards the drives' current tapes and the tape statistics other drives pick backup targets by
+	lock sync.Mutex
+}
+
+// managedDrive is the state of one of the manager's tape drives, work on
+// different drives runs in parallel
+type managedDrive struct {
+	*Manager
+
+	drive              drive.Drive
 	loaderDriveAddress uint16
+	currentTape        inventory.Tape
+
+	densities     []scsi.Density
+	densitiesRead bool
 
-	currentTape inventory.Tape
+	// encryptingKeyID is the key the drive confirmed to encrypt the current tape with
+	encryptingKeyID string
+
+	info *scsi.DeviceInfo
+	// writeRecorded is set once the drive is recorded as a writer of the current tape
+	writeRecorded bool
 }
 
 func New(
 	file *encryption.FileCryptor,
 	path *encryption.PathCryptor,
 	inventory *inventory.Inventory,
-	loader *loader.TapeLoader,
-	drive *drive.TapeDrive,
+	changer loader.Changer,
+	drives ...DriveConfig,
 ) (*Manager, error) {
-	serialNumber, err := drive.SerialNumber()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get tape drive serial number: %v", err)
+	if len(drives) == 0 {
+		return nil, fmt.Errorf("no tape drives configured")
 	}
 
-	address, err := loader.DriveAddressBySerial(serialNumber)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get tape drive address: %v", err)
+	manual, _ := changer.(*ManualChanger)
+	if manual != nil && len(drives) != 1 {
+		return nil, fmt.Errorf("a standalone drive setup takes exactly one drive, not %d", len(drives))
 	}
 
-	return &Manager{
+	m := &Manager{
 		file: file,
 		path: path,
 
-		inventory:          inventory,
-		loader:             loader,
-		drive:              drive,
-		loaderDriveAddress: address,
-	}, nil
+		inventory: inventory,
+		loader:    &serialChanger{Changer: changer},
+		manual:    manual,
+
+		CleaningCycles: DEFAULT_CLEANING_CYCLES,
+	}
+
+	addresses := make(map[uint16]bool)
+	for i, config := range drives {
+		address, err := resolveDriveAddress(changer, config.Drive, config.ElementAddress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get address of tape drive %d: %v", i, err)
+		}
+		if addresses[address] {
+			return nil, fmt.Errorf("tape drive %d is at element address %d like another configured drive", i, address)
+		}
+		addresses[address] = true
+
+		m.drives = append(m.drives, &managedDrive{
+			Manager:            m,
+			drive:              config.Drive,
+			loaderDriveAddress: address,
+		})
+	}
+
+	return m, nil
+}
+
+// driveFor returns the drive holding the tape, or the first drive if none does
+func (m *Manager) driveFor(barcode string) *managedDrive {
+	drive := m.driveWith(barcode)
+	if drive == nil {
+		return m.drives[0]
+	}
+	return drive
+}
+
+// driveWith returns the drive holding the tape, or nil if none does
+func (m *Manager) driveWith(barcode string) *managedDrive {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, drive := range m.drives {
+		if drive.currentTape != nil && drive.currentTape.GetBarcode() == barcode {
+			return drive
+		}
+	}
+	return nil
+}
+
+// writeDrive returns the drive holding the tape with the most free space, or the first drive if none holds a tape
+func (m *Manager) writeDrive() *managedDrive {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	best := m.drives[0]
+	for _, drive := range m.drives {
+		if drive.currentTape == nil {
+			continue
+		}
+		if best.currentTape == nil || drive.currentTape.GetFree() > best.currentTape.GetFree() {
+			best = drive
+		}
+	}
+	return best
+}
+
+// setCurrentTape records which tape is in the drive, it fails if another drive already holds the tape
+func (m *managedDrive) setCurrentTape(tape inventory.Tape) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, other := range m.drives {
+		if other != m && tape != nil && tape.Equals(other.currentTape) {
+			return fmt.Errorf("tape %s is in drive %d: %w", tape.GetBarcode(), other.loaderDriveAddress, ErrTapeInUse)
+		}
+	}
+	if tape == nil || !tape.Equals(m.currentTape) {
+		m.writeRecorded = false
+	}
+	m.currentTape = tape
+	return nil
+}
+
+// updateTape runs fn with the lock held, for changes to tape statistics other drives may be reading
+func (m *Manager) updateTape(fn func() error) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return fn()
 }
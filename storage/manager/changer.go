@@ -0,0 +1,83 @@
+package manager
+
+import (
+	"sync"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+	"github.com/FoxDenHome/tapemgr/scsi/loader"
+)
+
+// serialChanger runs one loader command at a time, so drives working in parallel
+// never have the library move two tapes at once
+type serialChanger struct {
+	loader.Changer
+
+	lock sync.Mutex
+}
+
+func (c *serialChanger) DeviceInfo() (*scsi.DeviceInfo, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.Changer.DeviceInfo()
+}
+
+func (c *serialChanger) DriveAddress(ident *scsi.DeviceIdentification) (uint16, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.Changer.DriveAddress(ident)
+}
+
+func (c *serialChanger) MoveTapeToDrive(driveAddress uint16, volumeTag string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.Changer.MoveTapeToDrive(driveAddress, volumeTag)
+}
+
+func (c *serialChanger) MoveDriveTapeToStorage(driveAddress uint16) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.Changer.MoveDriveTapeToStorage(driveAddress)
+}
+
+func (c *serialChanger) MoveMedium(sourceAddress uint16, destAddress uint16) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.Changer.MoveMedium(sourceAddress, destAddress)
+}
+
+func (c *serialChanger) ExchangeMedium(firstAddress uint16, secondAddress uint16) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.Changer.ExchangeMedium(firstAddress, secondAddress)
+}
+
+func (c *serialChanger) GetVolumeTags() ([]string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.Changer.GetVolumeTags()
+}
+
+func (c *serialChanger) GetElements() ([]*element.Descriptor, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.Changer.GetElements()
+}
+
+func (c *serialChanger) ExportTapes(volumeTags ...string) ([]loader.Move, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.Changer.ExportTapes(volumeTags...)
+}
+
+func (c *serialChanger) ImportTapes() ([]loader.Move, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.Changer.ImportTapes()
+}
+
+func (c *serialChanger) PreventMediumRemoval(prevent bool) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.Changer.PreventMediumRemoval(prevent)
+}
@@ -0,0 +1,124 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+)
+
+// LTO universal cleaning cartridges are rated for 50 cleaning cycles
+const DEFAULT_CLEANING_CYCLES = 50
+
+var ErrNoCleaningCartridge = errors.New("no usable cleaning cartridge in library")
+
+// CleaningUsesLeft returns how many more times a cleaning cartridge can be used
+func (m *Manager) CleaningUsesLeft(barcode string) int {
+	cartridge := m.inventory.CleaningCartridge(barcode)
+	if cartridge.Expired || cartridge.Uses >= m.CleaningCycles {
+		return 0
+	}
+	return m.CleaningCycles - cartridge.Uses
+}
+
+func (m *Manager) findCleaningCartridge() (string, error) {
+	elements, err := m.loader.GetElements()
+	if err != nil {
+		return "", err
+	}
+
+	barcode := ""
+	usesLeft := 0
+	for _, elem := range elements {
+		if elem.ElementType == element.ELEMENT_TYPE_DATA_TRANSFER || !elem.HasFlag(element.FLAG_FULL) || !elem.IsCleaning() {
+			continue
+		}
+
+		left := m.CleaningUsesLeft(elem.VolumeTag)
+		if left <= 0 {
+			log.Printf("Cleaning cartridge %s is used up, replace it", elem.VolumeTag)
+			continue
+		}
+		// Prefer the cartridge with the most uses left, so the others are used up one at a time
+		if left > usesLeft {
+			barcode = elem.VolumeTag
+			usesLeft = left
+		}
+	}
+
+	if barcode == "" {
+		return "", ErrNoCleaningCartridge
+	}
+	return barcode, nil
+}
+
+// CleanDrives runs a cleaning cycle on every drive, each with the cleaning cartridge that has the most uses left
+func (m *Manager) CleanDrives(ctx context.Context) error {
+	for _, drive := range m.drives {
+		err := drive.clean(ctx)
+		if err != nil {
+			return fmt.Errorf("cleaning drive %d: %w", drive.loaderDriveAddress, err)
+		}
+	}
+	return nil
+}
+
+func (m *managedDrive) clean(ctx context.Context) error {
+	barcode, err := m.findCleaningCartridge()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Cleaning drive %d with cleaning cartridge %s (%d uses left)", m.loaderDriveAddress, barcode, m.CleaningUsesLeft(barcode))
+	if DryRun {
+		return nil
+	}
+
+	err = m.drive.Unmount()
+	if err != nil {
+		return fmt.Errorf("failed to unmount drive: %v", err)
+	}
+	// The library swaps out a tape still in the drive, its flags are lost once it is removed
+	m.checkTapeAlerts(m.currentTape)
+	_ = m.setCurrentTape(nil)
+
+	err = m.moveTapeToDrive(ctx, barcode)
+	if err != nil {
+		return err
+	}
+
+	cleanErr := m.drive.WaitForCleaning(ctx)
+
+	expired := false
+	flags, err := m.drive.TapeAlerts()
+	if err != nil {
+		log.Printf("Failed to read TapeAlert flags after cleaning: %v", err)
+	}
+	for _, flag := range flags {
+		log.Printf("[ALRT] %s: %s", barcode, flag)
+	}
+	if slices.Contains(flags, scsi.TAPE_ALERT_EXPIRED_CLEANING) || slices.Contains(flags, scsi.TAPE_ALERT_INVALID_CLEANING) {
+		expired = true
+		cleanErr = errors.Join(cleanErr, fmt.Errorf("drive rejected cleaning cartridge %s as expired or invalid", barcode))
+	}
+
+	err = m.inventory.RecordCleaning(barcode, expired)
+	if err != nil {
+		log.Printf("Failed to record use of cleaning cartridge %s: %v", barcode, err)
+	}
+
+	err = m.loader.MoveDriveTapeToStorage(m.loaderDriveAddress)
+	if err != nil {
+		return errors.Join(cleanErr, fmt.Errorf("moving cleaning cartridge %s back to storage: %w", barcode, err))
+	}
+	if cleanErr != nil {
+		return fmt.Errorf("cleaning with %s failed: %w", barcode, cleanErr)
+	}
+
+	log.Printf("Cleaned drive %d, cleaning cartridge %s has %d uses left", m.loaderDriveAddress, barcode, m.CleaningUsesLeft(barcode))
+	return nil
+}
@@ -0,0 +1,38 @@
+package manager
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/FoxDenHome/tapemgr/scsi/drive"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+	"github.com/FoxDenHome/tapemgr/scsi/loader"
+)
+
+func resolveDriveAddress(changer loader.Changer, tapeDrive drive.Drive, driveAddress int) (uint16, error) {
+	if driveAddress == DRIVE_ADDRESS_AUTO {
+		ident, err := tapeDrive.Identification()
+		if err != nil {
+			return 0, fmt.Errorf("failed to identify tape drive: %v", err)
+		}
+		return changer.DriveAddress(ident)
+	}
+
+	if driveAddress < 0 || driveAddress > math.MaxUint16 {
+		return 0, fmt.Errorf("invalid drive element address %d", driveAddress)
+	}
+
+	elements, err := changer.GetElements()
+	if err != nil {
+		return 0, err
+	}
+	for _, elem := range elements {
+		if elem.ElementType == element.ELEMENT_TYPE_DATA_TRANSFER && int(elem.Address) == driveAddress {
+			log.Printf("Using configured drive element address %d", driveAddress)
+			return elem.Address, nil
+		}
+	}
+
+	return 0, fmt.Errorf("configured drive element address %d is not a drive in the loader", driveAddress)
+}
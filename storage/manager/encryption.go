@@ -0,0 +1,100 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/storage/inventory"
+)
+
+var ErrDriveNotEncrypting = errors.New("drive is not encrypting")
+
+// setupEncryption sets the key of the loaded tape in the drive. Failures only keep the
+// tape from being written, unencrypted data on it can still be read.
+func (m *managedDrive) setupEncryption(ctx context.Context, tape inventory.Tape) {
+	m.encryptingKeyID = ""
+
+	keyID := tape.GetEncryptionKeyId()
+	if m.DriveEncryption == nil {
+		if keyID != "" {
+			log.Printf("Tape %s was written with drive encryption key %s, but drive encryption is not configured", tape.GetBarcode(), keyID)
+		}
+		return
+	}
+	if keyID == "" {
+		keyID = m.DriveEncryption.KeyID
+	}
+
+	err := m.enableEncryption(ctx, keyID)
+	if err != nil {
+		log.Printf("Failed to enable encryption of tape %s in drive %d: %v", tape.GetBarcode(), m.loaderDriveAddress, err)
+		return
+	}
+	m.encryptingKeyID = keyID
+}
+
+func (m *managedDrive) enableEncryption(ctx context.Context, keyID string) error {
+	index, err := m.encryptionAlgorithm()
+	if err != nil {
+		return err
+	}
+
+	key, err := m.DriveEncryption.Key(keyID)
+	if err != nil {
+		return fmt.Errorf("deriving key %s: %w", keyID, err)
+	}
+
+	err = m.drive.SetEncryption(ctx, &scsi.EncryptionSettings{
+		EncryptionMode: scsi.ENCRYPTION_MODE_ENCRYPT,
+		DecryptionMode: scsi.DECRYPTION_MODE_MIXED,
+		AlgorithmIndex: index,
+		Key:            key,
+		KeyID:          keyID,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Drives may accept the settings without applying them, only the status tells
+	status, err := m.drive.EncryptionStatus()
+	if err != nil {
+		return fmt.Errorf("reading encryption status: %w", err)
+	}
+	if status.EncryptionMode != scsi.ENCRYPTION_MODE_ENCRYPT || status.AlgorithmIndex != index {
+		return fmt.Errorf("drive reports encryption mode %d with algorithm %d after enabling it: %w", status.EncryptionMode, status.AlgorithmIndex, ErrDriveNotEncrypting)
+	}
+	return nil
+}
+
+// encryptionAlgorithm returns the drive's index of AES-256-GCM
+func (m *managedDrive) encryptionAlgorithm() (uint8, error) {
+	algorithms, err := m.drive.EncryptionAlgorithms()
+	if err != nil {
+		return 0, fmt.Errorf("reading encryption capabilities: %w", err)
+	}
+
+	for _, algorithm := range algorithms {
+		if algorithm.Code == scsi.SECURITY_ALGORITHM_AES_256_GCM && algorithm.CanEncrypt && algorithm.CanDecrypt {
+			return algorithm.Index, nil
+		}
+	}
+	return 0, fmt.Errorf("drive does not support AES-256-GCM: %w", ErrDriveNotEncrypting)
+}
+
+// checkEncrypting refuses writes to the current tape unless the drive encrypts them,
+// and records the key on the tape once it is written with it
+func (m *managedDrive) checkEncrypting() error {
+	if m.DriveEncryption == nil {
+		return nil
+	}
+	if m.encryptingKeyID == "" {
+		return fmt.Errorf("refusing to write to tape %s: %w", m.currentTape.GetBarcode(), ErrDriveNotEncrypting)
+	}
+
+	return m.updateTape(func() error {
+		return m.currentTape.RecordEncryptionKeyID(m.encryptingKeyID)
+	})
+}
@@ -1,10 +1,19 @@
 package manager
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"log"
 
-func (m *Manager) FormatTape(barcode string) error {
-	err := m.formatTapeKeepMounted(barcode)
-	_ = m.drive.Unmount()
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+)
+
+// FormatTape formats a tape on request, even if its label identifies it as another tape or it is not
+// in the scratch pool. A tape that already carries an LTFS volume is only formatted with force set.
+func (m *Manager) FormatTape(ctx context.Context, barcode string, force bool) error {
+	drive := m.driveFor(barcode)
+	err := drive.formatTapeKeepMounted(ctx, barcode, false, force)
+	_ = drive.drive.Unmount()
 	if err != nil {
 		return err
 	}
@@ -12,24 +21,89 @@ func (m *Manager) FormatTape(barcode string) error {
 	return nil
 }
 
-func (m *Manager) formatTapeKeepMounted(barcode string) error {
-	tape := m.inventory.GetOrCreateTape(barcode)
+func (m *managedDrive) formatTapeKeepMounted(ctx context.Context, barcode string, verifyLabel bool, force bool) error {
+	if element.IsCleaningVolumeTag(barcode) {
+		return fmt.Errorf("refusing to format cleaning cartridge %s", barcode)
+	}
+
+	// Refused and failed tapes must not end up in the inventory as formatted
+	tape := m.inventory.GetOrNewTape(barcode)
+
+	err := m.checkMediaCompatible(tape, true)
+	if err != nil {
+		return err
+	}
 
-	err := m.loadTape(tape)
+	err = m.loadTapeUnverified(ctx, tape)
 	if err != nil {
 		return err
 	}
 
 	if DryRun {
+		// Pretend the format succeeded, so the dry run moves on to other tapes like a real one would
+		m.inventory.AddTape(tape)
 		return nil
 	}
 
+	if tape.GetWriteProtected() {
+		return fmt.Errorf("cannot format tape %s: %w", barcode, ErrTapeWriteProtected)
+	}
+	// Checked again, as a barcode without a cartridge type only reveals it once loaded
+	err = m.checkMediaCompatible(tape, true)
+	if err != nil {
+		return err
+	}
+	if !force {
+		err = m.checkNoVolume(ctx, barcode)
+		if err != nil {
+			return err
+		}
+	}
+	// Checked after the volume, so a backup skips tapes of other pools as already formatted
+	if verifyLabel {
+		err = m.verifyTapeLabel(ctx, barcode)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Formatting starts the tape over, with the current drive encryption key if any
+	keyID := ""
+	if m.DriveEncryption != nil {
+		keyID = m.DriveEncryption.KeyID
+	}
+	if tape.GetEncryptionKeyId() != keyID {
+		err = m.updateTape(func() error {
+			return tape.RecordEncryptionKeyID("")
+		})
+		if err != nil {
+			return err
+		}
+		m.setupEncryption(ctx, tape)
+	}
+
+	err = m.prepareWrite()
+	if err != nil {
+		return err
+	}
+
 	err = m.drive.Format(barcode)
 	if err != nil {
 		return fmt.Errorf("failed to format tape %s: %v", barcode, err)
 	}
+	m.inventory.AddTape(tape)
+
+	err = m.writeTapeLabel(ctx, barcode)
+	if err != nil {
+		return fmt.Errorf("failed to label tape %s: %v", barcode, err)
+	}
+
+	err = m.inventory.RemoveScratch(barcode)
+	if err != nil {
+		log.Printf("Failed to remove tape %s from the scratch pool: %v", barcode, err)
+	}
 
-	err = m.drive.Mount()
+	err = m.drive.Mount(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to mount tape %s: %v", barcode, err)
 	}
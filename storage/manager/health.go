@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/storage/inventory"
+)
+
+type TapeAlert struct {
+	Flag     uint8  `json:"flag"`
+	Name     string `json:"name"`
+	Severity string `json:"severity"`
+}
+
+type DriveHealth struct {
+	ElementAddress   uint16                 `json:"element-address"`
+	TapeAlerts       []TapeAlert            `json:"tape-alerts"`
+	WriteErrors      *scsi.ErrorCounters    `json:"write-errors,omitempty"`
+	ReadErrors       *scsi.ErrorCounters    `json:"read-errors,omitempty"`
+	VolumeStatistics *scsi.VolumeStatistics `json:"volume-statistics,omitempty"`
+}
+
+// DriveHealth returns the health of every drive
+func (m *Manager) DriveHealth() ([]*DriveHealth, error) {
+	healths := make([]*DriveHealth, 0, len(m.drives))
+	for _, drive := range m.drives {
+		health, err := drive.health()
+		if err != nil {
+			return nil, fmt.Errorf("drive %d: %w", drive.loaderDriveAddress, err)
+		}
+		healths = append(healths, health)
+	}
+	return healths, nil
+}
+
+func (m *managedDrive) health() (*DriveHealth, error) {
+	health, err := m.drive.Health()
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := make([]TapeAlert, 0, len(health.TapeAlerts))
+	for _, flag := range health.TapeAlerts {
+		alerts = append(alerts, TapeAlert{
+			Flag:     uint8(flag),
+			Name:     flag.Name(),
+			Severity: flag.Severity().String(),
+		})
+	}
+
+	return &DriveHealth{
+		ElementAddress:   m.loaderDriveAddress,
+		TapeAlerts:       alerts,
+		WriteErrors:      health.WriteErrors,
+		ReadErrors:       health.ReadErrors,
+		VolumeStatistics: health.VolumeStatistics,
+	}, nil
+}
+
+// checkTapeAlerts logs the drive's TapeAlert flags and records them against tape, if known
+// It returns whether the drive asks for cleaning
+// Failures are only logged, as they must not keep the tape from being unloaded
+func (m *managedDrive) checkTapeAlerts(tape inventory.Tape) bool {
+	flags, err := m.drive.TapeAlerts()
+	if err != nil {
+		log.Printf("Failed to read TapeAlert flags: %v", err)
+		return false
+	}
+	if len(flags) == 0 {
+		return false
+	}
+	needsCleaning := slices.Contains(flags, scsi.TAPE_ALERT_CLEAN_NOW) || slices.Contains(flags, scsi.TAPE_ALERT_CLEAN_PERIODIC)
+
+	barcode := "unknown tape"
+	if tape != nil {
+		barcode = tape.GetBarcode()
+	}
+	for _, flag := range flags {
+		log.Printf("[ALRT] %s: %s", barcode, flag)
+	}
+
+	if tape == nil {
+		return needsCleaning
+	}
+	err = m.updateTape(func() error {
+		return tape.RecordAlerts(flags...)
+	})
+	if err != nil {
+		log.Printf("Failed to record TapeAlert flags for tape %s: %v", barcode, err)
+	}
+	return needsCleaning
+}
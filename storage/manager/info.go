@@ -0,0 +1,90 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+)
+
+type DriveInfo struct {
+	ElementAddress uint16 `json:"element-address"`
+	*scsi.DeviceInfo
+}
+
+type DeviceInfo struct {
+	// Changer is nil for a standalone drive
+	Changer *scsi.DeviceInfo `json:"changer,omitempty"`
+	Drives  []*DriveInfo     `json:"drives"`
+}
+
+// DeviceInfo reads the INQUIRY data and VPD pages of the changer and every drive
+func (m *Manager) DeviceInfo() (*DeviceInfo, error) {
+	info := &DeviceInfo{}
+
+	changerInfo, err := m.loader.DeviceInfo()
+	if err != nil && !errors.Is(err, ErrNoChanger) {
+		return nil, fmt.Errorf("changer: %w", err)
+	}
+	info.Changer = changerInfo
+
+	for _, drive := range m.drives {
+		driveInfo, err := drive.drive.DeviceInfo()
+		if err != nil {
+			return nil, fmt.Errorf("drive %d: %w", drive.loaderDriveAddress, err)
+		}
+		info.Drives = append(info.Drives, &DriveInfo{
+			ElementAddress: drive.loaderDriveAddress,
+			DeviceInfo:     driveInfo,
+		})
+	}
+	return info, nil
+}
+
+// driveInfo asks the drive once, drives that cannot report their INQUIRY data are recorded by serial alone
+func (m *managedDrive) driveInfo() *scsi.DeviceInfo {
+	if m.info != nil {
+		return m.info
+	}
+
+	info, err := m.drive.DeviceInfo()
+	if err != nil {
+		log.Printf("Failed to read INQUIRY data of drive %d: %v", m.loaderDriveAddress, err)
+		serial, err := m.drive.SerialNumber()
+		if err != nil {
+			log.Printf("Failed to read serial number of drive %d: %v", m.loaderDriveAddress, err)
+		}
+		// Not cached, the next tape gets another try
+		return &scsi.DeviceInfo{Serial: serial}
+	}
+	m.info = info
+	return info
+}
+
+// prepareWrite is called before writing to the current tape
+func (m *managedDrive) prepareWrite() error {
+	err := m.checkEncrypting()
+	if err != nil {
+		return err
+	}
+	return m.recordWrite()
+}
+
+// recordWrite notes the drive's serial and firmware on the current tape, once per load,
+// so tapes written by a drive found to be faulty can be traced
+func (m *managedDrive) recordWrite() error {
+	if m.writeRecorded {
+		return nil
+	}
+
+	info := m.driveInfo()
+	err := m.updateTape(func() error {
+		return m.currentTape.RecordDriveWrite(info)
+	})
+	if err != nil {
+		return err
+	}
+	m.writeRecorded = true
+	return nil
+}
@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const LABEL_PREFIX = "tapemgr"
+
+var ErrTapeLabelMismatch = errors.New("tape label does not match")
+
+// tapeLabel is stored in a vendor specific host attribute of the cartridge memory, so a
+// cartridge can be identified without its barcode label and the LTFS volume name stays intact
+type tapeLabel struct {
+	Barcode   string
+	Pool      string
+	Formatted time.Time
+}
+
+func (l *tapeLabel) String() string {
+	fields := []string{LABEL_PREFIX, "barcode=" + l.Barcode}
+	if l.Pool != "" {
+		fields = append(fields, "pool="+l.Pool)
+	}
+	fields = append(fields, "formatted="+l.Formatted.UTC().Format(time.RFC3339))
+	return strings.Join(fields, " ")
+}
+
+// parseTapeLabel returns nil for labels not written by tapemgr
+func parseTapeLabel(text string) *tapeLabel {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || fields[0] != LABEL_PREFIX {
+		return nil
+	}
+
+	label := &tapeLabel{}
+	for _, field := range fields[1:] {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "barcode":
+			label.Barcode = value
+		case "pool":
+			label.Pool = value
+		case "formatted":
+			label.Formatted, _ = time.Parse(time.RFC3339, value)
+		}
+	}
+	return label
+}
+
+// ValidatePoolID checks that a pool ID can be stored in a tape label
+func ValidatePoolID(pool string) error {
+	if strings.ContainsFunc(pool, func(r rune) bool { return r <= ' ' || r == '=' || r > '~' }) {
+		return fmt.Errorf("pool ID %q may only contain printable ASCII characters other than spaces and '='", pool)
+	}
+	return nil
+}
+
+func (m *managedDrive) writeTapeLabel(ctx context.Context, barcode string) error {
+	label := &tapeLabel{
+		Barcode:   barcode,
+		Pool:      m.PoolID,
+		Formatted: time.Now(),
+	}
+	return m.drive.SetHostLabel(ctx, label.String())
+}
+
+// verifyTapeLabel checks the cartridge in the drive is the tape we expect
+// Tapes formatted before labels were written are accepted as they cannot be verified
+func (m *managedDrive) verifyTapeLabel(ctx context.Context, barcode string) error {
+	text, err := m.drive.HostLabel(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot verify tape %s, failed to read its label: %w", barcode, err)
+	}
+
+	label := parseTapeLabel(text)
+	if label == nil {
+		log.Printf("Tape %s has no tapemgr label, not verifying it", barcode)
+		return nil
+	}
+
+	if label.Barcode != barcode {
+		return fmt.Errorf("%w: expected tape %s, but the cartridge identifies itself as %s (barcode labels swapped?)", ErrTapeLabelMismatch, barcode, label.Barcode)
+	}
+	if m.PoolID != "" && label.Pool != "" && label.Pool != m.PoolID {
+		return fmt.Errorf("%w: tape %s belongs to pool %s, not %s", ErrTapeLabelMismatch, barcode, label.Pool, m.PoolID)
+	}
+	return nil
+}
+
+// warnTapeLabel is verifyTapeLabel for reading tapes, it only logs what it finds
+func (m *managedDrive) warnTapeLabel(ctx context.Context, barcode string) {
+	err := m.verifyTapeLabel(ctx, barcode)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
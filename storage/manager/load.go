@@ -1,9 +1,13 @@
 package manager
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/FoxDenHome/tapemgr/scsi"
 	"github.com/FoxDenHome/tapemgr/storage/inventory"
 )
 
@@ -12,9 +16,16 @@ const (
 	TAPE_SIZE_NEW_SPARE = 2 * TAPE_SIZE_SPARE
 
 	TOMBSTONE_SIZE_SPARE = 4 * 1024 * 1024 // 4 MB
+
+	LOAD_NOT_READY_RETRIES = 10
+	LOAD_NOT_READY_DELAY   = 30 * time.Second
 )
 
-func (m *Manager) loadForSize(size int64) error {
+var ErrTapeWriteProtected = errors.New("tape is write-protected")
+var ErrTapeInUse = errors.New("tape is in use by another drive")
+var ErrNoTapeAvailable = errors.New("no tape with enough free space and no usable scratch tape")
+
+func (m *managedDrive) loadForSize(ctx context.Context, size int64) error {
 	if m.currentTape != nil && m.currentTape.GetFree() >= size+TAPE_SIZE_SPARE {
 		return nil
 	}
@@ -27,10 +38,25 @@ func (m *Manager) loadForSize(size int64) error {
 		}
 	}
 
-	for _, tape := range m.inventory.GetTapesSortByFreeDesc() {
-		if tape.GetFree() >= size+TAPE_SIZE_NEW_SPARE {
-			return m.loadAndMount(tape)
+	for _, tape := range m.candidateTapes(size + TAPE_SIZE_NEW_SPARE) {
+		if !m.isWritable(tape) {
+			continue
+		}
+
+		err = m.loadTape(ctx, tape)
+		if errors.Is(err, ErrTapeInUse) {
+			// Another drive picked the tape in the meantime
+			continue
 		}
+		if err != nil {
+			return err
+		}
+		// The write-protect tab might have been set since the tape was last loaded,
+		// and barcodes without a cartridge type only reveal it once loaded
+		if !m.isWritable(tape) {
+			continue
+		}
+		return m.mountCurrentTape(ctx)
 	}
 
 	volumeTags, err := m.loader.GetVolumeTags()
@@ -39,59 +65,222 @@ func (m *Manager) loadForSize(size int64) error {
 	}
 
 	for _, barcode := range volumeTags {
-		if !m.inventory.HasTape(barcode) {
-			// Found unused new tape!
-			return m.formatTapeKeepMounted(barcode)
+		if m.inventory.HasTape(barcode) {
+			continue
+		}
+		// Unknown tapes might belong to another system, only approved blank tapes are used
+		if !m.isScratch(barcode) {
+			log.Printf("Not formatting unknown tape %s, it is not in the scratch pool", barcode)
+			continue
+		}
+
+		// Found unused new tape!
+		err = m.formatTapeKeepMounted(ctx, barcode, true, false)
+		if errors.Is(err, ErrTapeInUse) {
+			continue
+		}
+		if errors.Is(err, ErrTapeWriteProtected) || errors.Is(err, ErrIncompatibleMedia) || errors.Is(err, ErrTapeHasVolume) || errors.Is(err, ErrTapeLabelMismatch) {
+			log.Printf("Skipping new tape %s: %v", barcode, err)
+			continue
+		}
+		return err
+	}
+
+	return ErrNoTapeAvailable
+}
+
+// candidateTapes returns the tapes with at least size free that no other drive holds, most free space first
+func (m *managedDrive) candidateTapes(size int64) []inventory.Tape {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var tapes []inventory.Tape
+	for _, tape := range m.inventory.GetTapesSortByFreeDesc() {
+		if tape.GetFree() < size || m.inOtherDrive(tape) {
+			continue
+		}
+		tapes = append(tapes, tape)
+	}
+	return tapes
+}
+
+// inOtherDrive must be called with the lock held
+func (m *managedDrive) inOtherDrive(tape inventory.Tape) bool {
+	for _, other := range m.drives {
+		if other != m && tape.Equals(other.currentTape) {
+			return true
 		}
 	}
+	return false
+}
+
+// loadTape loads a tape to write to and refuses to use it if its label identifies it as a different tape
+func (m *managedDrive) loadTape(ctx context.Context, tape inventory.Tape) error {
+	if tape.Equals(m.currentTape) {
+		return nil
+	}
 
+	err := m.loadTapeUnverified(ctx, tape)
+	if err != nil || DryRun {
+		return err
+	}
+
+	err = m.verifyTapeLabel(ctx, tape.GetBarcode())
+	if err != nil {
+		_ = m.setCurrentTape(nil)
+		return err
+	}
 	return nil
 }
 
-func (m *Manager) loadTape(tape inventory.Tape) error {
+func (m *managedDrive) loadTapeUnverified(ctx context.Context, tape inventory.Tape) error {
 	if tape.Equals(m.currentTape) {
 		return nil
 	}
 
+	err := m.checkMediaCompatible(tape, false)
+	if err != nil {
+		return err
+	}
+
+	previous := m.currentTape
+	if previous != nil {
+		// Unloaded here rather than by the library, so the previous tape's TapeAlert flags are read and the drive gets cleaned if it asks for it
+		err = m.unmountAndUnload()
+		if err != nil {
+			return fmt.Errorf("unloading tape %s: %w", previous.GetBarcode(), err)
+		}
+	}
+
+	// Claimed before moving, so no other drive tries to load the same tape
+	err = m.setCurrentTape(tape)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("Loading tape %s to drive %d", tape.GetBarcode(), m.loaderDriveAddress)
 
 	if DryRun {
-		m.currentTape = tape
 		return nil
 	}
 
-	err := m.drive.Unmount()
-	if err != nil {
-		return fmt.Errorf("failed to unmount drive: %v", err)
+	err = m.drive.Unmount()
+	if err == nil {
+		if previous == nil {
+			// The drive might hold a tape loaded before tapemgr started, which the library unloads implicitly
+			m.checkTapeAlerts(nil)
+		}
+		err = m.moveTapeToDrive(ctx, tape.GetBarcode())
+	} else {
+		err = fmt.Errorf("failed to unmount drive: %v", err)
 	}
-
-	err = m.loader.MoveTapeToDrive(m.loaderDriveAddress, tape.GetBarcode())
 	if err != nil {
-		return fmt.Errorf("failed to move tape %s: %v", tape.GetBarcode(), err)
+		_ = m.setCurrentTape(nil)
+		return err
 	}
+	m.preventDriveRemoval()
+	m.setupEncryption(ctx, tape)
+	m.checkWriteProtected(ctx, tape)
+	m.recordMediaType(ctx, tape)
 
-	m.currentTape = tape
 	return nil
 }
 
-func (m *Manager) loadAndMount(tape inventory.Tape) error {
+func (m *managedDrive) loadAndMount(ctx context.Context, tape inventory.Tape) error {
 	if tape.Equals(m.currentTape) {
 		return nil
 	}
 
-	err := m.loadTape(tape)
+	// Reading a tape with the wrong label does no harm, so restore and scan only warn about it
+	err := m.loadTapeUnverified(ctx, tape)
 	if err != nil {
 		return err
 	}
+	if !DryRun {
+		m.warnTapeLabel(ctx, tape.GetBarcode())
+	}
 
+	return m.mountCurrentTape(ctx)
+}
+
+func (m *managedDrive) mountCurrentTape(ctx context.Context) error {
 	if DryRun {
 		return nil
 	}
 
-	err = m.drive.Mount()
+	err := m.drive.Mount(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to mount tape %s in drive: %v", tape.GetBarcode(), err)
+		return fmt.Errorf("failed to mount tape %s in drive: %v", m.currentTape.GetBarcode(), err)
 	}
 
 	return nil
 }
+
+// isWritable reports whether the tape can be used as a backup target, logging why not
+func (m *managedDrive) isWritable(tape inventory.Tape) bool {
+	if tape.GetWriteProtected() {
+		log.Printf("Skipping write-protected tape %s", tape.GetBarcode())
+		return false
+	}
+
+	err := m.checkMediaCompatible(tape, true)
+	if err != nil {
+		log.Printf("Skipping tape %s: %v", tape.GetBarcode(), err)
+		return false
+	}
+	return true
+}
+
+// checkWriteProtected records the write-protect tab of the loaded tape, backups skip protected tapes
+func (m *managedDrive) checkWriteProtected(ctx context.Context, tape inventory.Tape) {
+	writeProtected, err := m.drive.WriteProtected(ctx)
+	if err != nil {
+		log.Printf("Failed to read write protection of tape %s: %v", tape.GetBarcode(), err)
+		return
+	}
+
+	if writeProtected != tape.GetWriteProtected() {
+		log.Printf("Tape %s write protection changed to %v", tape.GetBarcode(), writeProtected)
+	}
+	err = m.updateTape(func() error {
+		return tape.RecordWriteProtected(writeProtected)
+	})
+	if err != nil {
+		log.Printf("Failed to record write protection of tape %s: %v", tape.GetBarcode(), err)
+	}
+}
+
+func (m *managedDrive) moveTapeToDrive(ctx context.Context, barcode string) error {
+	// The library unloads the previous tape first
+	m.allowDriveRemoval()
+
+	if m.manual != nil {
+		return m.manual.insertTape(ctx, barcode)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := m.loader.MoveTapeToDrive(m.loaderDriveAddress, barcode)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, scsi.ErrDoorOpen), errors.Is(err, scsi.ErrNotReady):
+			if attempt >= LOAD_NOT_READY_RETRIES {
+				return fmt.Errorf("library still not ready after %d attempts, giving up on tape %s: %w", attempt, barcode, err)
+			}
+			log.Printf("Library not ready (%v), retrying in %v (attempt %d/%d)", err, LOAD_NOT_READY_DELAY, attempt, LOAD_NOT_READY_RETRIES)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(LOAD_NOT_READY_DELAY):
+			}
+		case errors.Is(err, scsi.ErrSourceElementEmpty):
+			return fmt.Errorf("tape %s is not where the library reported it, re-run inventory: %w", barcode, err)
+		case errors.Is(err, scsi.ErrMediumRemovalPrevented):
+			return fmt.Errorf("library refused to move tape %s, run the unlock mode if an earlier run left it locked: %w", barcode, err)
+		case errors.Is(err, scsi.ErrMediumError), errors.Is(err, scsi.ErrIncompatibleMedium):
+			return fmt.Errorf("tape %s appears to be a bad cartridge, refusing to use it: %w", barcode, err)
+		default:
+			return fmt.Errorf("failed to move tape %s: %w", barcode, err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Lock prevents tapes from being removed through the library's magazines and mail slots
+// or the drive's eject button while a job moves or writes tapes, until Unlock is called
+func (m *Manager) Lock() error {
+	if DryRun {
+		return nil
+	}
+
+	err := m.loader.PreventMediumRemoval(true)
+	if err != nil {
+		return fmt.Errorf("preventing medium removal from library: %w", err)
+	}
+	m.locked = true
+	return nil
+}
+
+// Unlock allows medium removal on the library and the drive again.
+// It does not depend on Lock having been called, so it also recovers from a crashed run.
+func (m *Manager) Unlock() error {
+	m.locked = false
+
+	var errs []error
+	for _, drive := range m.drives {
+		err := drive.drive.PreventMediumRemoval(false)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("allowing medium removal from drive %d: %w", drive.loaderDriveAddress, err))
+		}
+	}
+	err := m.loader.PreventMediumRemoval(false)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("allowing medium removal from library: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// preventDriveRemoval locks the tape that was just loaded in the drive, if the job holds the lock
+func (m *managedDrive) preventDriveRemoval() {
+	if !m.locked {
+		return
+	}
+	err := m.drive.PreventMediumRemoval(true)
+	if err != nil {
+		log.Printf("Failed to prevent medium removal from drive %d: %v", m.loaderDriveAddress, err)
+	}
+}
+
+// allowDriveRemoval must be called before the library takes a tape out of the drive,
+// as drives refuse to unload while removal is prevented
+func (m *managedDrive) allowDriveRemoval() {
+	err := m.drive.PreventMediumRemoval(false)
+	if err != nil {
+		log.Printf("Failed to allow medium removal from drive %d: %v", m.loaderDriveAddress, err)
+	}
+}
@@ -0,0 +1,72 @@
+package manager
+
+import (
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+	"github.com/FoxDenHome/tapemgr/scsi/loader"
+)
+
+// ExportTapes moves the given tapes into the mailslots, tapes in a drive are unmounted and put back into storage first
+func (m *Manager) ExportTapes(barcodes ...string) error {
+	elements, err := m.loader.GetElements()
+	if err != nil {
+		return err
+	}
+
+	for _, elem := range elements {
+		if elem.ElementType != element.ELEMENT_TYPE_DATA_TRANSFER || !elem.HasFlag(element.FLAG_FULL) || !slices.Contains(barcodes, elem.VolumeTag) {
+			continue
+		}
+		drive, err := m.driveAt(elem.Address)
+		if err != nil {
+			return fmt.Errorf("cannot export tape %s: %w", elem.VolumeTag, err)
+		}
+		if DryRun {
+			log.Printf("Would unload tape %s from drive %d", elem.VolumeTag, drive.loaderDriveAddress)
+			continue
+		}
+		err = drive.unmountAndUnload()
+		if err != nil {
+			return fmt.Errorf("unloading tape %s for export: %w", elem.VolumeTag, err)
+		}
+	}
+
+	if DryRun {
+		for _, barcode := range barcodes {
+			log.Printf("Would export tape %s", barcode)
+		}
+		return nil
+	}
+
+	moves, err := m.loader.ExportTapes(barcodes...)
+	logMoves("Exported", moves)
+	return err
+}
+
+func (m *Manager) ImportTapes() error {
+	if DryRun {
+		log.Printf("Would import all tapes from the mailslots")
+		return nil
+	}
+
+	moves, err := m.loader.ImportTapes()
+	logMoves("Imported", moves)
+	for _, move := range moves {
+		if !m.inventory.HasTape(move.VolumeTag) {
+			log.Printf("Tape %s is not in the inventory, it will be treated as a new tape", move.VolumeTag)
+		}
+	}
+	return err
+}
+
+func logMoves(verb string, moves []loader.Move) {
+	for _, move := range moves {
+		log.Printf("%s tape %s: element %d -> element %d", verb, move.VolumeTag, move.Source, move.Destination)
+	}
+	if len(moves) == 0 {
+		log.Printf("%s no tapes", verb)
+	}
+}
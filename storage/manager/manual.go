@@ -0,0 +1,233 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/drive"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+	"github.com/FoxDenHome/tapemgr/scsi/loader"
+	"github.com/FoxDenHome/tapemgr/scsi/lto"
+	"github.com/FoxDenHome/tapemgr/util"
+)
+
+const (
+	// MANUAL_DRIVE_ADDRESS is the element address a manual changer reports for its drive
+	MANUAL_DRIVE_ADDRESS = 0
+	// Shelf tapes are listed as storage elements from this address on
+	MANUAL_SHELF_ADDRESS = 1
+)
+
+var ErrNoChanger = errors.New("standalone drive has no media changer, move tapes by hand")
+
+// ManualChanger stands in for the media changer of a standalone drive.
+// It ejects tapes and waits for an operator to insert the requested one,
+// which is only used once its cartridge memory or label confirm its barcode.
+type ManualChanger struct {
+	drive drive.Drive
+	// volumeTags are the tapes on the shelf next to the drive, new ones among them are formatted for backups
+	volumeTags []string
+
+	lock sync.Mutex
+	// loaded is the tape verified to be in the drive
+	loaded string
+}
+
+var _ loader.Changer = &ManualChanger{}
+
+func NewManualChanger(tapeDrive drive.Drive, volumeTags []string) *ManualChanger {
+	return &ManualChanger{
+		drive:      tapeDrive,
+		volumeTags: volumeTags,
+	}
+}
+
+func (c *ManualChanger) DeviceInfo() (*scsi.DeviceInfo, error) {
+	return nil, ErrNoChanger
+}
+
+func (c *ManualChanger) DriveAddress(ident *scsi.DeviceIdentification) (uint16, error) {
+	return MANUAL_DRIVE_ADDRESS, nil
+}
+
+// MoveTapeToDrive waits for the operator without a deadline, the manager goes through insertTape to be cancellable
+func (c *ManualChanger) MoveTapeToDrive(driveAddress uint16, volumeTag string) error {
+	return c.insertTape(context.Background(), volumeTag)
+}
+
+// insertTape ejects the tape in the drive and waits until the operator inserted the requested one
+func (c *ManualChanger) insertTape(ctx context.Context, volumeTag string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	present, err := c.drive.MediumPresent()
+	if err != nil {
+		return fmt.Errorf("checking for a tape in the drive: %w", err)
+	}
+	if present && c.loaded == volumeTag {
+		return nil
+	}
+
+	for {
+		err = c.eject()
+		if err != nil {
+			return err
+		}
+
+		log.Printf("[OPER] Insert tape %s into the drive", volumeTag)
+		err = util.Poll(ctx, 0, c.drive.MediumPresent)
+		if err != nil {
+			return fmt.Errorf("waiting for tape %s to be inserted: %w", volumeTag, err)
+		}
+
+		err = c.verify(ctx, volumeTag)
+		if err == nil {
+			c.loaded = volumeTag
+			return nil
+		}
+		if !errors.Is(err, ErrTapeLabelMismatch) {
+			return err
+		}
+		log.Printf("[OPER] Wrong tape inserted, ejecting it: %v", err)
+	}
+}
+
+// verify checks the barcode in the cartridge memory and the tapemgr or LTFS volume label.
+// Blank cartridges carry neither and are accepted, as the operator inserted them on request.
+func (c *ManualChanger) verify(ctx context.Context, volumeTag string) error {
+	var identities []string
+	identify := func(identity string) {
+		identity = strings.TrimSpace(identity)
+		if identity != "" && !slices.Contains(identities, identity) {
+			identities = append(identities, identity)
+		}
+	}
+
+	mam, err := c.drive.MediumAuxiliaryMemory(ctx)
+	if err != nil {
+		log.Printf("Failed to read medium auxiliary memory of the inserted tape: %v", err)
+	} else {
+		identify(mam.Barcode)
+		// The LTFS volume name, which is the barcode for tapes formatted by tapemgr
+		identify(mam.VolumeLabel)
+	}
+
+	text, err := c.drive.HostLabel(ctx)
+	if err != nil {
+		log.Printf("Failed to read label of the inserted tape: %v", err)
+	} else if label := parseTapeLabel(text); label != nil {
+		identify(label.Barcode)
+	}
+
+	if len(identities) == 0 {
+		log.Printf("Inserted tape carries no barcode or label, trusting the operator that it is %s", volumeTag)
+		return nil
+	}
+
+	// The cartridge memory may only hold the six character volume serial
+	serial, _, _ := lto.ParseVolumeTag(volumeTag)
+	for _, identity := range identities {
+		if identity == volumeTag || (serial != "" && identity == serial) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: expected tape %s, but the inserted cartridge identifies itself as %s", ErrTapeLabelMismatch, volumeTag, strings.Join(identities, ", "))
+}
+
+// eject must be called with the lock held
+func (c *ManualChanger) eject() error {
+	present, err := c.drive.MediumPresent()
+	if err != nil {
+		return fmt.Errorf("checking for a tape in the drive: %w", err)
+	}
+	if !present {
+		c.loaded = ""
+		return nil
+	}
+
+	err = c.drive.Eject()
+	if err != nil {
+		return fmt.Errorf("ejecting tape: %w", err)
+	}
+	if c.loaded != "" {
+		log.Printf("[OPER] Ejected tape %s, take it out of the drive", c.loaded)
+	} else {
+		log.Printf("[OPER] Ejected tape, take it out of the drive")
+	}
+	c.loaded = ""
+	return nil
+}
+
+// MoveDriveTapeToStorage ejects the tape, the operator puts it back on the shelf
+func (c *ManualChanger) MoveDriveTapeToStorage(driveAddress uint16) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.eject()
+}
+
+func (c *ManualChanger) MoveMedium(sourceAddress uint16, destAddress uint16) error {
+	return ErrNoChanger
+}
+
+func (c *ManualChanger) ExchangeMedium(firstAddress uint16, secondAddress uint16) error {
+	return ErrNoChanger
+}
+
+func (c *ManualChanger) GetVolumeTags() ([]string, error) {
+	return slices.Clone(c.volumeTags), nil
+}
+
+// GetElements reports the drive and the configured shelf tapes as storage elements
+func (c *ManualChanger) GetElements() ([]*element.Descriptor, error) {
+	present, err := c.drive.MediumPresent()
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	driveElem := &element.Descriptor{
+		Address:     MANUAL_DRIVE_ADDRESS,
+		ElementType: element.ELEMENT_TYPE_DATA_TRANSFER,
+		Flags:       uint16(element.FLAG_ACCESS),
+	}
+	if present {
+		driveElem.Flags |= uint16(element.FLAG_FULL)
+		driveElem.VolumeTag = c.loaded
+	}
+
+	elements := []*element.Descriptor{driveElem}
+	for i, volumeTag := range c.volumeTags {
+		elem := &element.Descriptor{
+			Address:     MANUAL_SHELF_ADDRESS + uint16(i),
+			ElementType: element.ELEMENT_TYPE_STORAGE,
+			Flags:       uint16(element.FLAG_ACCESS),
+		}
+		if !present || volumeTag != c.loaded {
+			elem.Flags |= uint16(element.FLAG_FULL)
+			elem.VolumeTag = volumeTag
+		}
+		elements = append(elements, elem)
+	}
+	return elements, nil
+}
+
+func (c *ManualChanger) ExportTapes(volumeTags ...string) ([]loader.Move, error) {
+	return nil, ErrNoChanger
+}
+
+func (c *ManualChanger) ImportTapes() ([]loader.Move, error) {
+	return nil, ErrNoChanger
+}
+
+// PreventMediumRemoval has nothing to lock, the drive's eject button is locked through the drive
+func (c *ManualChanger) PreventMediumRemoval(prevent bool) error {
+	return nil
+}
@@ -0,0 +1,216 @@
+package manager_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"regexp"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FoxDenHome/tapemgr/scsi/sim"
+	"github.com/FoxDenHome/tapemgr/storage/inventory"
+	"github.com/FoxDenHome/tapemgr/storage/manager"
+)
+
+var insertPrompt = regexp.MustCompile(`\[OPER\] Insert tape (\S+) into the drive`)
+
+// operator follows the prompts of the manual changer, taking tapes from the simulated library's slots
+type operator struct {
+	t       *testing.T
+	library *sim.Library
+	out     io.Writer
+
+	inserting sync.WaitGroup
+
+	lock sync.Mutex
+	// wrong tapes are inserted instead of the requested ones, one per prompt
+	wrong []string
+	// ignore leaves the drive empty, like an operator away from the drive
+	ignore  bool
+	prompts []string
+	ejected int
+}
+
+// newOperator reads the log of the manager for prompts until the test ends
+func newOperator(t *testing.T, library *sim.Library, ignore bool) *operator {
+	op := &operator{
+		t:       t,
+		library: library,
+		out:     log.Writer(),
+		ignore:  ignore,
+	}
+	log.SetOutput(op)
+	t.Cleanup(func() {
+		op.inserting.Wait()
+		log.SetOutput(op.out)
+	})
+	return op
+}
+
+func (o *operator) Write(p []byte) (int, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if bytes.Contains(p, []byte("[OPER] Ejected tape")) {
+		o.ejected++
+	}
+	if match := insertPrompt.FindSubmatch(p); match != nil {
+		requested := string(match[1])
+		o.prompts = append(o.prompts, requested)
+
+		volumeTag := requested
+		if len(o.wrong) > 0 {
+			volumeTag = o.wrong[0]
+			o.wrong = o.wrong[1:]
+		}
+		// The simulator logs the move, so it cannot happen within the write
+		if !o.ignore {
+			o.inserting.Add(1)
+			go func() {
+				defer o.inserting.Done()
+				err := o.library.MoveTapeToDrive(sim.ADDRESS_DRIVE, volumeTag)
+				if err != nil {
+					o.t.Errorf("operator inserting tape %s: %v", volumeTag, err)
+				}
+			}()
+		}
+	}
+	return o.out.Write(p)
+}
+
+// insertWrong has the operator insert the given tapes on the next prompts
+func (o *operator) insertWrong(volumeTags ...string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.wrong = append(o.wrong, volumeTags...)
+}
+
+func (o *operator) ejectedTapes() int {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.ejected
+}
+
+func (o *operator) takePrompts() []string {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	prompts := o.prompts
+	o.prompts = nil
+	return prompts
+}
+
+// newManualSetup sets up a standalone drive, the slots of the simulated library serve as the shelf
+func newManualSetup(t *testing.T, ignore bool, shelf ...string) (*simSetup, *operator) {
+	t.Helper()
+	s := newSimSetup(t, "3")
+	op := newOperator(t, s.library, ignore)
+
+	var err error
+	s.inventory, err = inventory.New(s.tapesPath)
+	if err != nil {
+		t.Fatalf("creating inventory: %v", err)
+	}
+	s.manager, err = manager.New(s.fileCryptor, s.pathCryptor, s.inventory, manager.NewManualChanger(s.drive, shelf), manager.DriveConfig{
+		Drive:          s.drive,
+		ElementAddress: manager.DRIVE_ADDRESS_AUTO,
+	})
+	if err != nil {
+		t.Fatalf("creating manager: %v", err)
+	}
+	s.manager.ScratchBarcodes = []*regexp.Regexp{regexp.MustCompile("^SIM")}
+	return s, op
+}
+
+func TestManualBackupRestore(t *testing.T) {
+	s, op := newManualSetup(t, false, "SIM000L8", "SIM001L8", "SIM002L8")
+
+	files := map[string][]byte{}
+	for _, name := range []string{"a", "b"} {
+		files[name] = s.writeFile(t, name)
+	}
+	err := s.manager.Backup(context.Background(), s.source)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+
+	// Every file fills a tape, the operator is asked for a new one each time
+	prompts := op.takePrompts()
+	if len(prompts) != 2 || prompts[0] == prompts[1] {
+		t.Fatalf("backup asked for tapes %v, want two different ones", prompts)
+	}
+	for _, barcode := range prompts {
+		if !s.inventory.HasTape(barcode) || len(s.inventory.GetOrCreateTape(barcode).GetFiles()) != 1 {
+			t.Errorf("tape %s is not in the inventory with one file", barcode)
+		}
+	}
+
+	s.checkRestore(t, s.manager, files)
+	restorePrompts := op.takePrompts()
+	slices.Sort(prompts)
+	slices.Sort(restorePrompts)
+	if !slices.Equal(restorePrompts, prompts) {
+		t.Errorf("restore asked for tapes %v, want %v", restorePrompts, prompts)
+	}
+}
+
+func TestManualWrongTapeEjected(t *testing.T) {
+	s, op := newManualSetup(t, false, "SIM000L8", "SIM001L8")
+	ctx := context.Background()
+
+	err := s.manager.FormatTape(ctx, "SIM000L8", false)
+	if err != nil {
+		t.Fatalf("FormatTape: %v", err)
+	}
+	err = s.manager.UnmountAndUnload()
+	if err != nil {
+		t.Fatalf("UnmountAndUnload: %v", err)
+	}
+	op.takePrompts()
+
+	// The cartridge memory gives the blank tape away, it is ejected and asked for again
+	op.insertWrong("SIM001L8")
+	ejected := op.ejectedTapes()
+	err = s.manager.ScanTape(ctx, "SIM000L8")
+	if err != nil {
+		t.Fatalf("ScanTape: %v", err)
+	}
+
+	prompts := op.takePrompts()
+	if !slices.Equal(prompts, []string{"SIM000L8", "SIM000L8"}) {
+		t.Errorf("got prompts %v, want the tape to be asked for twice", prompts)
+	}
+	if count := op.ejectedTapes() - ejected; count != 1 {
+		t.Errorf("ejected %d tapes, want the wrong tape ejected", count)
+	}
+	elements, err := s.library.GetElements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, elem := range elements {
+		if elem.Address == sim.ADDRESS_DRIVE && elem.VolumeTag != "SIM000L8" {
+			t.Errorf("drive holds %q, want SIM000L8", elem.VolumeTag)
+		}
+	}
+}
+
+func TestManualWaitCancelled(t *testing.T) {
+	s, op := newManualSetup(t, true, "SIM000L8")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	err := s.manager.FormatTape(ctx, "SIM000L8", false)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("FormatTape without the operator: got %v, want %v", err, context.DeadlineExceeded)
+	}
+	if prompts := op.takePrompts(); !slices.Equal(prompts, []string{"SIM000L8"}) {
+		t.Errorf("got prompts %v, want SIM000L8", prompts)
+	}
+	if s.inventory.HasTape("SIM000L8") {
+		t.Errorf("tape that was never inserted is in the inventory")
+	}
+}
@@ -0,0 +1,84 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/lto"
+	"github.com/FoxDenHome/tapemgr/storage/inventory"
+)
+
+var ErrIncompatibleMedia = errors.New("media not supported by drive")
+
+// supportedDensities asks the drive once, drives that cannot report their densities are not restricted
+func (m *managedDrive) supportedDensities() []scsi.Density {
+	if m.densitiesRead {
+		return m.densities
+	}
+	m.densitiesRead = true
+
+	densities, err := m.drive.SupportedDensities()
+	if err != nil {
+		log.Printf("Failed to read supported densities of drive %d, not checking media compatibility: %v", m.loaderDriveAddress, err)
+		return nil
+	}
+	m.densities = densities
+	return densities
+}
+
+// checkMediaCompatible refuses tapes the drive cannot read, or with write set, cannot write
+func (m *managedDrive) checkMediaCompatible(tape inventory.Tape, write bool) error {
+	media := tape.Media()
+	if !media.Known() {
+		return nil
+	}
+
+	densities := m.supportedDensities()
+	if densities == nil {
+		return nil
+	}
+
+	for _, density := range densities {
+		if density.PrimaryCode == media.DensityCode() && (density.Writable || !write) {
+			return nil
+		}
+	}
+
+	access := "read"
+	if write {
+		access = "write"
+	}
+	return fmt.Errorf("drive %d cannot %s %s tape %s: %w", m.loaderDriveAddress, access, media, tape.GetBarcode(), ErrIncompatibleMedia)
+}
+
+// recordMediaType records the cartridge type of the loaded tape, asking the drive if the barcode has none
+func (m *managedDrive) recordMediaType(ctx context.Context, tape inventory.Tape) {
+	media := tape.Media()
+	if !media.Known() {
+		densities, err := m.drive.MediumDensities(ctx)
+		if err != nil {
+			log.Printf("Failed to read density of tape %s: %v", tape.GetBarcode(), err)
+			return
+		}
+		for _, density := range densities {
+			var ok bool
+			media, ok = lto.MediaFromDensity(density.PrimaryCode)
+			if ok {
+				break
+			}
+		}
+	}
+	if !media.Known() {
+		return
+	}
+
+	err := m.updateTape(func() error {
+		return tape.RecordMediaType(media)
+	})
+	if err != nil {
+		log.Printf("Failed to record media type of tape %s: %v", tape.GetBarcode(), err)
+	}
+}
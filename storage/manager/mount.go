@@ -1,50 +1,101 @@
 package manager
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 )
 
-func (m *Manager) MountTapeWait(barcode string) error {
+func (m *Manager) MountTapeWait(ctx context.Context, barcode string) error {
 	tape := m.inventory.GetOrCreateTape(barcode)
+	drive := m.driveFor(barcode)
 
 	if DryRun {
-		m.currentTape = tape
-		return nil
+		return drive.setCurrentTape(tape)
 	}
 
-	err := m.loadAndMount(tape)
+	err := drive.loadAndMount(ctx, tape)
 	if err != nil {
 		return err
 	}
 
-	log.Printf("Mounted tape %s", barcode)
-	m.currentTape = tape
+	log.Printf("Mounted tape %s in drive %d", barcode, drive.loaderDriveAddress)
 
-	err = m.scanCurrentTape()
+	err = drive.scanCurrentTape()
 	if err != nil {
 		return err
 	}
 
-	m.drive.WaitForUnmount()
-	return nil
+	unmounted := make(chan struct{})
+	go func() {
+		drive.drive.WaitForUnmount()
+		close(unmounted)
+	}()
+
+	select {
+	case <-unmounted:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
+// UnmountAndUnload puts the tapes of all drives back into storage
 func (m *Manager) UnmountAndUnload() error {
-	m.currentTape = nil
+	var errs []error
+	for _, drive := range m.drives {
+		errs = append(errs, drive.unmountAndUnload())
+	}
+	return errors.Join(errs...)
+}
+
+func (m *managedDrive) unmountAndUnload() error {
 	if DryRun {
+		_ = m.setCurrentTape(nil)
 		return nil
 	}
 
+	return m.unload(func() error {
+		err := m.loader.MoveDriveTapeToStorage(m.loaderDriveAddress)
+		if err != nil {
+			return fmt.Errorf("moving tape from drive %d to storage: %w", m.loaderDriveAddress, err)
+		}
+		return nil
+	})
+}
+
+// unload unmounts the drive, reads the TapeAlert flags, runs move to take the tape out
+// and cleans the drive afterwards if it asked for it
+func (m *managedDrive) unload(move func() error) error {
+	tape := m.currentTape
+	_ = m.setCurrentTape(nil)
+
 	err := m.drive.Unmount()
 	if err != nil {
 		return fmt.Errorf("unmounting drive: %w", err)
 	}
 
-	err = m.loader.MoveDriveTapeToStorage(m.loaderDriveAddress)
+	// Check before moving the tape out, as drives clear media related flags once the cartridge is removed
+	needsCleaning := m.checkTapeAlerts(tape)
+
+	m.allowDriveRemoval()
+	err = move()
 	if err != nil {
-		return fmt.Errorf("moving tape from drive %d to storage: %w", m.loaderDriveAddress, err)
+		return err
 	}
 
+	if !needsCleaning {
+		return nil
+	}
+	if !m.AutoClean {
+		log.Printf("Drive %d needs cleaning, run the clean mode or enable auto-clean", m.loaderDriveAddress)
+		return nil
+	}
+	// Unloading also happens during shutdown after the command's context is gone, a second signal still force quits
+	err = m.clean(context.Background())
+	if err != nil {
+		return fmt.Errorf("cleaning drive %d: %w", m.loaderDriveAddress, err)
+	}
 	return nil
 }
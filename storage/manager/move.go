@@ -0,0 +1,205 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+	"github.com/FoxDenHome/tapemgr/scsi/loader"
+)
+
+var ErrInvalidDestination = errors.New("invalid destination element")
+
+// MoveTape moves a tape into an empty storage slot or mailslot, taking it out of its drive if needed
+func (m *Manager) MoveTape(barcode string, destAddress uint16) error {
+	elements, err := m.loader.GetElements()
+	if err != nil {
+		return err
+	}
+
+	source, err := findTapeElement(elements, barcode)
+	if err != nil {
+		return err
+	}
+	return m.moveElement(elements, source, destAddress)
+}
+
+// UnloadDrives moves the tapes in all drives back to their home slots
+func (m *Manager) UnloadDrives() error {
+	var errs []error
+	for _, drive := range m.drives {
+		errs = append(errs, m.UnloadDrive(drive.loaderDriveAddress, nil))
+	}
+	return errors.Join(errs...)
+}
+
+// UnloadDrive moves the tape in the drive back to its home slot, or with destAddress set, into that slot
+func (m *Manager) UnloadDrive(driveAddress uint16, destAddress *uint16) error {
+	drive, err := m.driveAt(driveAddress)
+	if err != nil {
+		return err
+	}
+
+	if destAddress == nil {
+		if DryRun {
+			log.Printf("Would move the tape in drive %d back to its home slot", driveAddress)
+			return nil
+		}
+		return drive.unmountAndUnload()
+	}
+
+	elements, err := m.loader.GetElements()
+	if err != nil {
+		return err
+	}
+
+	source, err := findElement(elements, driveAddress)
+	if err != nil {
+		return err
+	}
+	if !source.HasFlag(element.FLAG_FULL) {
+		return fmt.Errorf("drive %d is empty", driveAddress)
+	}
+	return m.moveElement(elements, source, *destAddress)
+}
+
+// ExchangeTapes swaps two tapes in storage slots or mailslots
+func (m *Manager) ExchangeTapes(firstBarcode string, secondBarcode string) error {
+	elements, err := m.loader.GetElements()
+	if err != nil {
+		return err
+	}
+
+	first, err := findTapeElement(elements, firstBarcode)
+	if err != nil {
+		return err
+	}
+	second, err := findTapeElement(elements, secondBarcode)
+	if err != nil {
+		return err
+	}
+	if first.Address == second.Address {
+		return fmt.Errorf("cannot exchange tape %s with itself", firstBarcode)
+	}
+
+	for _, elem := range []*element.Descriptor{first, second} {
+		err = checkSlot(elem)
+		if err != nil {
+			return err
+		}
+		if !isAccessible(elem) {
+			return fmt.Errorf("tape %s in %s element %d is not accessible", elem.VolumeTag, elem.ElementType, elem.Address)
+		}
+	}
+
+	if DryRun {
+		log.Printf("Would EXCHANGE MEDIUM %s element %d (%s) <-> %s element %d (%s)", first.ElementType, first.Address, first.VolumeTag, second.ElementType, second.Address, second.VolumeTag)
+		return nil
+	}
+
+	err = m.loader.ExchangeMedium(first.Address, second.Address)
+	if err != nil {
+		return err
+	}
+	logMoves("Moved", []loader.Move{
+		{VolumeTag: first.VolumeTag, Source: first.Address, Destination: second.Address},
+		{VolumeTag: second.VolumeTag, Source: second.Address, Destination: first.Address},
+	})
+	return nil
+}
+
+// moveElement validates and runs a single MOVE MEDIUM, or prints it on dry runs
+func (m *Manager) moveElement(elements []*element.Descriptor, source *element.Descriptor, destAddress uint16) error {
+	dest, err := findElement(elements, destAddress)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidDestination, err)
+	}
+
+	err = checkSlot(dest)
+	if err != nil {
+		return err
+	}
+	if dest.Address == source.Address {
+		log.Printf("Tape %s is already in %s element %d", source.VolumeTag, source.ElementType, source.Address)
+		return nil
+	}
+	if dest.HasFlag(element.FLAG_FULL) {
+		return fmt.Errorf("%s element %d already holds tape %s: %w", dest.ElementType, dest.Address, dest.VolumeTag, ErrInvalidDestination)
+	}
+	if !isAccessible(dest) {
+		return fmt.Errorf("%s element %d is not accessible: %w", dest.ElementType, dest.Address, ErrInvalidDestination)
+	}
+	if dest.ElementType == element.ELEMENT_TYPE_IMPORT_EXPORT && !dest.HasFlag(element.FLAG_EXPORT_ENABLED) {
+		return fmt.Errorf("mailslot %d is not enabled for export: %w", dest.Address, ErrInvalidDestination)
+	}
+	if !isAccessible(source) {
+		return fmt.Errorf("tape %s in %s element %d is not accessible", source.VolumeTag, source.ElementType, source.Address)
+	}
+
+	// Drives not configured here may be in use by another host, their tapes are left alone
+	var drive *managedDrive
+	if source.ElementType == element.ELEMENT_TYPE_DATA_TRANSFER {
+		drive, err = m.driveAt(source.Address)
+		if err != nil {
+			return fmt.Errorf("cannot move tape %s out of drive %d: %w", source.VolumeTag, source.Address, err)
+		}
+	}
+
+	if DryRun {
+		log.Printf("Would MOVE MEDIUM %s element %d -> %s element %d (%s)", source.ElementType, source.Address, dest.ElementType, dest.Address, source.VolumeTag)
+		return nil
+	}
+
+	move := func() error {
+		err := m.loader.MoveMedium(source.Address, dest.Address)
+		if err != nil {
+			return err
+		}
+		logMoves("Moved", []loader.Move{{VolumeTag: source.VolumeTag, Source: source.Address, Destination: dest.Address}})
+		return nil
+	}
+	if drive != nil {
+		return drive.unload(move)
+	}
+	return move()
+}
+
+func (m *Manager) driveAt(address uint16) (*managedDrive, error) {
+	for _, drive := range m.drives {
+		if drive.loaderDriveAddress == address {
+			return drive, nil
+		}
+	}
+	return nil, fmt.Errorf("no configured drive at element address %d", address)
+}
+
+// checkSlot only lets tapes be moved into and out of storage slots and mailslots, drives are loaded by the manager
+func checkSlot(elem *element.Descriptor) error {
+	if elem.ElementType != element.ELEMENT_TYPE_STORAGE && elem.ElementType != element.ELEMENT_TYPE_IMPORT_EXPORT {
+		return fmt.Errorf("%s element %d is not a storage slot or mailslot: %w", elem.ElementType, elem.Address, ErrInvalidDestination)
+	}
+	return nil
+}
+
+func isAccessible(elem *element.Descriptor) bool {
+	return elem.HasFlag(element.FLAG_ACCESS) && !elem.HasFlag(element.FLAG_EXCEPTION)
+}
+
+func findElement(elements []*element.Descriptor, address uint16) (*element.Descriptor, error) {
+	for _, elem := range elements {
+		if elem.Address == address {
+			return elem, nil
+		}
+	}
+	return nil, fmt.Errorf("library has no element %d", address)
+}
+
+func findTapeElement(elements []*element.Descriptor, barcode string) (*element.Descriptor, error) {
+	for _, elem := range elements {
+		if elem.HasFlag(element.FLAG_FULL) && elem.VolumeTag == barcode {
+			return elem, nil
+		}
+	}
+	return nil, fmt.Errorf("no tape found with volume tag %s", barcode)
+}
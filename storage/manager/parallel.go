@@ -0,0 +1,125 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// parallel hands the jobs produce sends to whichever drive is free next. With holder set, a job
+// goes to the drive holding its tape instead, and a job failing with ErrTapeInUse is handed to
+// the drive that took the tape in the meantime. The first other error stops all drives and is
+// returned, once every drive is idle again.
+func parallel[T any](
+	ctx context.Context,
+	drives []*managedDrive,
+	holder func(job T) *managedDrive,
+	produce func(ctx context.Context, send func(job T) error) error,
+	work func(ctx context.Context, drive *managedDrive, job T) error,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var errOnce sync.Once
+	var firstErr error
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	jobs := make(chan T)
+	driveJobs := make(map[*managedDrive]chan T)
+	for _, drive := range drives {
+		driveJobs[drive] = make(chan T)
+	}
+	route := func(job T) chan T {
+		if holder != nil {
+			if drive := holder(job); drive != nil {
+				return driveJobs[drive]
+			}
+		}
+		return jobs
+	}
+
+	// Handed over jobs keep the drives busy, so they only stop once every sent job is done
+	var pendingLock sync.Mutex
+	pending := 0
+	produced := false
+	idle := make(chan struct{})
+	finish := func(jobDone bool) {
+		pendingLock.Lock()
+		defer pendingLock.Unlock()
+		if jobDone {
+			pending--
+		} else {
+			produced = true
+		}
+		if produced && pending == 0 {
+			close(idle)
+		}
+	}
+
+	var wg sync.WaitGroup
+	handOver := func(job T) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case route(job) <- job:
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	for _, drive := range drives {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var job T
+				select {
+				case job = <-jobs:
+				case job = <-driveJobs[drive]:
+				case <-idle:
+					return
+				case <-ctx.Done():
+					return
+				}
+
+				err := work(ctx, drive, job)
+				if holder != nil && errors.Is(err, ErrTapeInUse) {
+					handOver(job)
+					continue
+				}
+				if err != nil {
+					fail(err)
+					return
+				}
+				finish(true)
+			}
+		}()
+	}
+
+	err := produce(ctx, func(job T) error {
+		pendingLock.Lock()
+		pending++
+		pendingLock.Unlock()
+
+		select {
+		case route(job) <- job:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if err != nil {
+		fail(err)
+	} else {
+		finish(false)
+	}
+
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,348 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+	"github.com/FoxDenHome/tapemgr/scsi/loader"
+)
+
+var ErrOutsidePartition = errors.New("outside of the library partition tapemgr may use")
+
+// SlotRange is an inclusive range of storage element addresses
+type SlotRange struct {
+	First uint16
+	Last  uint16
+}
+
+// Partition is the part of a shared library tapemgr may use.
+// Storage slots must be in one of the slot ranges and tapes must match one of the barcode patterns,
+// an empty list allows everything. Drives and mailslots are shared, only their tapes are checked.
+type Partition struct {
+	Slots    []SlotRange
+	Barcodes []*regexp.Regexp
+}
+
+// ParsePartition parses slot ranges of the form "4096-4119" or "4096" and barcode regular expressions
+func ParsePartition(slots []string, barcodes []string) (*Partition, error) {
+	partition := &Partition{}
+
+	for _, str := range slots {
+		firstStr, lastStr, isRange := strings.Cut(str, "-")
+		if !isRange {
+			lastStr = firstStr
+		}
+
+		first, err := strconv.ParseUint(strings.TrimSpace(firstStr), 0, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slot range %q: %w", str, err)
+		}
+		last, err := strconv.ParseUint(strings.TrimSpace(lastStr), 0, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slot range %q: %w", str, err)
+		}
+		if last < first {
+			return nil, fmt.Errorf("invalid slot range %q: end is before start", str)
+		}
+
+		partition.Slots = append(partition.Slots, SlotRange{First: uint16(first), Last: uint16(last)})
+	}
+
+	for _, str := range barcodes {
+		pattern, err := regexp.Compile(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid barcode pattern %q: %w", str, err)
+		}
+		partition.Barcodes = append(partition.Barcodes, pattern)
+	}
+
+	return partition, nil
+}
+
+func (p *Partition) String() string {
+	var parts []string
+	for _, slots := range p.Slots {
+		parts = append(parts, fmt.Sprintf("slots %d-%d", slots.First, slots.Last))
+	}
+	for _, pattern := range p.Barcodes {
+		parts = append(parts, fmt.Sprintf("barcodes %q", pattern))
+	}
+	if len(parts) == 0 {
+		return "whole library"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (p *Partition) containsSlot(address uint16) bool {
+	if len(p.Slots) == 0 {
+		return true
+	}
+	for _, slots := range p.Slots {
+		if address >= slots.First && address <= slots.Last {
+			return true
+		}
+	}
+	return false
+}
+
+// containsTape checks the barcode of data tapes, cleaning cartridges are only restricted by their slot
+func (p *Partition) containsTape(elem *element.Descriptor) bool {
+	if !elem.HasFlag(element.FLAG_FULL) || elem.IsCleaning() || len(p.Barcodes) == 0 {
+		return true
+	}
+	for _, pattern := range p.Barcodes {
+		if pattern.MatchString(elem.VolumeTag) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether tapemgr may touch the element
+func (p *Partition) contains(elem *element.Descriptor) bool {
+	switch elem.ElementType {
+	case element.ELEMENT_TYPE_MEDIUM_TRANSPORT:
+		return true
+	case element.ELEMENT_TYPE_STORAGE:
+		return p.containsSlot(elem.Address) && p.containsTape(elem)
+	default:
+		return p.containsTape(elem)
+	}
+}
+
+// partitionChanger keeps every loader operation inside the partition, other elements
+// except for the drives are hidden and moves touching them fail
+type partitionChanger struct {
+	loader.Changer
+
+	partition *Partition
+}
+
+func NewPartitionChanger(changer loader.Changer, partition *Partition) loader.Changer {
+	return &partitionChanger{
+		Changer:   changer,
+		partition: partition,
+	}
+}
+
+func (c *partitionChanger) GetElements() ([]*element.Descriptor, error) {
+	elements, err := c.Changer.GetElements()
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []*element.Descriptor
+	for _, elem := range elements {
+		// Drives stay visible to be resolved and reported, even while they hold a tape of the other partition
+		if elem.ElementType == element.ELEMENT_TYPE_DATA_TRANSFER || c.partition.contains(elem) {
+			filtered = append(filtered, elem)
+		}
+	}
+	return filtered, nil
+}
+
+func (c *partitionChanger) GetVolumeTags() ([]string, error) {
+	elements, err := c.GetElements()
+	if err != nil {
+		return nil, err
+	}
+
+	var barcodes []string
+	for _, elem := range elements {
+		if elem.HasFlag(element.FLAG_FULL) && elem.VolumeTag != "" && !elem.IsCleaning() && c.partition.contains(elem) {
+			barcodes = append(barcodes, elem.VolumeTag)
+		}
+	}
+	return barcodes, nil
+}
+
+// element looks up an element of the whole library, failing if it is outside the partition
+func (c *partitionChanger) element(elements []*element.Descriptor, address uint16) (*element.Descriptor, error) {
+	elem, err := findElement(elements, address)
+	if err != nil {
+		return nil, err
+	}
+	if !c.partition.contains(elem) {
+		if elem.HasFlag(element.FLAG_FULL) {
+			return nil, fmt.Errorf("tape %s in %s element %d is %w", elem.VolumeTag, elem.ElementType, elem.Address, ErrOutsidePartition)
+		}
+		return nil, fmt.Errorf("%s element %d is %w", elem.ElementType, elem.Address, ErrOutsidePartition)
+	}
+	return elem, nil
+}
+
+func (c *partitionChanger) MoveTapeToDrive(driveAddress uint16, volumeTag string) error {
+	elements, err := c.Changer.GetElements()
+	if err != nil {
+		return err
+	}
+
+	source, err := findTapeElement(elements, volumeTag)
+	if err != nil {
+		return err
+	}
+	if source.Address == driveAddress {
+		log.Printf("tape %s already in drive %d", volumeTag, driveAddress)
+		return nil
+	}
+	_, err = c.element(elements, source.Address)
+	if err != nil {
+		return err
+	}
+
+	// Empty the drive first, so the library does not pick a slot for its tape on its own
+	err = c.MoveDriveTapeToStorage(driveAddress)
+	if err != nil {
+		return fmt.Errorf("failed to move previous tape from drive %d to storage: %v", driveAddress, err)
+	}
+	return c.Changer.MoveTapeToDrive(driveAddress, volumeTag)
+}
+
+// MoveDriveTapeToStorage puts the tape back into its home slot, or a free slot of the partition
+func (c *partitionChanger) MoveDriveTapeToStorage(driveAddress uint16) error {
+	elements, err := c.Changer.GetElements()
+	if err != nil {
+		return err
+	}
+
+	drive, err := findElement(elements, driveAddress)
+	if err != nil {
+		return err
+	}
+	if !drive.HasFlag(element.FLAG_FULL) {
+		return nil
+	}
+	// The other partition's tape is left for its owner to unload
+	_, err = c.element(elements, driveAddress)
+	if err != nil {
+		return err
+	}
+
+	isFree := func(elem *element.Descriptor) bool {
+		return elem.ElementType == element.ELEMENT_TYPE_STORAGE && !elem.HasFlag(element.FLAG_FULL) && isAccessible(elem) && c.partition.contains(elem)
+	}
+
+	if drive.HasFlag(element.FLAG_SOURCE_INVERT_VALID) {
+		home, err := findElement(elements, drive.SourceElementAddress)
+		if err == nil && isFree(home) {
+			log.Printf("Moving tape from drive %d back to source %d", driveAddress, home.Address)
+			return c.Changer.MoveMedium(driveAddress, home.Address)
+		}
+	}
+
+	for _, elem := range elements {
+		if isFree(elem) {
+			log.Printf("Moving tape from drive %d to free slot %d", driveAddress, elem.Address)
+			return c.Changer.MoveMedium(driveAddress, elem.Address)
+		}
+	}
+	return fmt.Errorf("no free slot in the partition (%s) for the tape in drive %d", c.partition, driveAddress)
+}
+
+func (c *partitionChanger) MoveMedium(sourceAddress uint16, destAddress uint16) error {
+	elements, err := c.Changer.GetElements()
+	if err != nil {
+		return err
+	}
+
+	for _, address := range []uint16{sourceAddress, destAddress} {
+		_, err = c.element(elements, address)
+		if err != nil {
+			return err
+		}
+	}
+	return c.Changer.MoveMedium(sourceAddress, destAddress)
+}
+
+func (c *partitionChanger) ExchangeMedium(firstAddress uint16, secondAddress uint16) error {
+	elements, err := c.Changer.GetElements()
+	if err != nil {
+		return err
+	}
+
+	for _, address := range []uint16{firstAddress, secondAddress} {
+		_, err = c.element(elements, address)
+		if err != nil {
+			return err
+		}
+	}
+	return c.Changer.ExchangeMedium(firstAddress, secondAddress)
+}
+
+func (c *partitionChanger) ExportTapes(volumeTags ...string) ([]loader.Move, error) {
+	elements, err := c.Changer.GetElements()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, volumeTag := range volumeTags {
+		source, err := findTapeElement(elements, volumeTag)
+		if err != nil {
+			return nil, err
+		}
+		_, err = c.element(elements, source.Address)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.Changer.ExportTapes(volumeTags...)
+}
+
+// ImportTapes moves the tapes of the partition from the mailslots into free slots of the partition
+func (c *partitionChanger) ImportTapes() ([]loader.Move, error) {
+	elements, err := c.Changer.GetElements()
+	if err != nil {
+		return nil, err
+	}
+
+	var mailslots []*element.Descriptor
+	var freeSlots []*element.Descriptor
+	for _, elem := range elements {
+		switch elem.ElementType {
+		case element.ELEMENT_TYPE_IMPORT_EXPORT:
+			if !elem.HasFlag(element.FLAG_FULL) {
+				continue
+			}
+			if !c.partition.contains(elem) {
+				log.Printf("Leaving tape %s in mailslot %d, it is %v", elem.VolumeTag, elem.Address, ErrOutsidePartition)
+				continue
+			}
+			if !elem.HasFlag(element.FLAG_IMPORT_ENABLED) || !isAccessible(elem) {
+				log.Printf("Skipping tape %s in mailslot %d, it is not accessible for import", elem.VolumeTag, elem.Address)
+				continue
+			}
+			mailslots = append(mailslots, elem)
+		case element.ELEMENT_TYPE_STORAGE:
+			if !elem.HasFlag(element.FLAG_FULL) && isAccessible(elem) && c.partition.contains(elem) {
+				freeSlots = append(freeSlots, elem)
+			}
+		}
+	}
+
+	var moves []loader.Move
+	for _, source := range mailslots {
+		if len(freeSlots) == 0 {
+			return moves, fmt.Errorf("no free storage slot left in the partition for tape %s in mailslot %d", source.VolumeTag, source.Address)
+		}
+
+		dest := freeSlots[0]
+		log.Printf("Importing tape %s from mailslot %d to slot %d", source.VolumeTag, source.Address, dest.Address)
+		err = c.Changer.MoveMedium(source.Address, dest.Address)
+		if err != nil {
+			return moves, err
+		}
+
+		freeSlots = freeSlots[1:]
+		moves = append(moves, loader.Move{
+			VolumeTag:   source.VolumeTag,
+			Source:      source.Address,
+			Destination: dest.Address,
+		})
+	}
+	return moves, nil
+}
@@ -0,0 +1,197 @@
+package manager_test
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/FoxDenHome/tapemgr/scsi/loader"
+	"github.com/FoxDenHome/tapemgr/scsi/sim"
+	"github.com/FoxDenHome/tapemgr/storage/manager"
+)
+
+const (
+	SLOT_0   = sim.ADDRESS_STORAGE
+	MAILSLOT = sim.ADDRESS_IMPORT_EXPORT
+	DRIVE    = sim.ADDRESS_DRIVE
+)
+
+// newPartitionedLibrary creates a library with SIM000L8..SIM005L8 in slots SLOT_0..SLOT_0+5, two free slots
+// and two mailslots. The partition has the first four slots and the tapes SIM000L8..SIM004L8.
+func newPartitionedLibrary(t *testing.T) (*sim.Library, loader.Changer) {
+	t.Helper()
+	library, err := sim.Open("sim://" + filepath.Join(t.TempDir(), "lib") + "?tapes=6&slots=8&mailslots=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	partition := &manager.Partition{
+		Slots:    []manager.SlotRange{{First: SLOT_0, Last: SLOT_0 + 3}},
+		Barcodes: []*regexp.Regexp{regexp.MustCompile("^SIM00[0-4]")},
+	}
+	return library, manager.NewPartitionChanger(library, partition)
+}
+
+// move rearranges the library behind the partition's back, like the other partition's owner would
+func move(t *testing.T, library *sim.Library, source uint16, dest uint16) {
+	t.Helper()
+	err := library.MoveMedium(source, dest)
+	if err != nil {
+		t.Fatalf("moving %d to %d: %v", source, dest, err)
+	}
+}
+
+// tapeAt returns the tape in the element, as the whole library sees it
+func tapeAt(t *testing.T, library *sim.Library, address uint16) string {
+	t.Helper()
+	elements, err := library.GetElements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, elem := range elements {
+		if elem.Address == address {
+			return elem.VolumeTag
+		}
+	}
+	t.Fatalf("library has no element %d", address)
+	return ""
+}
+
+func TestPartitionFiltersElements(t *testing.T) {
+	library, changer := newPartitionedLibrary(t)
+	// SIM003L8 waits in a mailslot, a foreign tape took its slot
+	move(t, library, SLOT_0+3, MAILSLOT)
+	move(t, library, SLOT_0+5, SLOT_0+3)
+
+	elements, err := changer.GetElements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var addresses []uint16
+	for _, elem := range elements {
+		addresses = append(addresses, elem.Address)
+	}
+	// Slot 3 holds the foreign SIM005L8, slots 4 and up are outside the slot range
+	want := []uint16{sim.ADDRESS_TRANSPORT, SLOT_0, SLOT_0 + 1, SLOT_0 + 2, MAILSLOT, MAILSLOT + 1, DRIVE}
+	if !slices.Equal(addresses, want) {
+		t.Errorf("visible elements %v, want %v", addresses, want)
+	}
+
+	tags, err := changer.GetVolumeTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(tags)
+	wantTags := []string{"SIM000L8", "SIM001L8", "SIM002L8", "SIM003L8"}
+	if !slices.Equal(tags, wantTags) {
+		t.Errorf("volume tags %v, want %v", tags, wantTags)
+	}
+}
+
+func TestPartitionRefusesOutsideMoves(t *testing.T) {
+	library, changer := newPartitionedLibrary(t)
+	// A tape of the partition's pattern in a slot outside the range, and a foreign tape inside it
+	move(t, library, SLOT_0+3, SLOT_0+6)
+	move(t, library, SLOT_0+5, SLOT_0+3)
+
+	tests := []struct {
+		name string
+		do   func() error
+	}{
+		{"move into a slot outside the range", func() error { return changer.MoveMedium(SLOT_0, SLOT_0+5) }},
+		{"move a foreign tape", func() error { return changer.MoveMedium(SLOT_0+3, MAILSLOT) }},
+		{"move from a slot outside the range", func() error { return changer.MoveMedium(SLOT_0+6, MAILSLOT) }},
+		{"exchange with a foreign tape", func() error { return changer.ExchangeMedium(SLOT_0, SLOT_0+3) }},
+		{"exchange with a slot outside the range", func() error { return changer.ExchangeMedium(SLOT_0, SLOT_0+4) }},
+		{"export a foreign tape", func() error { _, err := changer.ExportTapes("SIM005L8"); return err }},
+		{"export a tape outside the range", func() error { _, err := changer.ExportTapes("SIM000L8", "SIM003L8"); return err }},
+		{"load a foreign tape", func() error { return changer.MoveTapeToDrive(DRIVE, "SIM005L8") }},
+		{"load a tape outside the range", func() error { return changer.MoveTapeToDrive(DRIVE, "SIM003L8") }},
+	}
+
+	for _, tt := range tests {
+		err := tt.do()
+		if !errors.Is(err, manager.ErrOutsidePartition) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, manager.ErrOutsidePartition)
+		}
+	}
+
+	want := map[uint16]string{
+		SLOT_0: "SIM000L8", SLOT_0 + 3: "SIM005L8", SLOT_0 + 4: "SIM004L8", SLOT_0 + 5: "", SLOT_0 + 6: "SIM003L8",
+		MAILSLOT: "", DRIVE: "",
+	}
+	for address, volumeTag := range want {
+		if got := tapeAt(t, library, address); got != volumeTag {
+			t.Errorf("element %d holds %q after refused moves, want %q", address, got, volumeTag)
+		}
+	}
+
+	// Tapes and slots of the partition still move
+	err := changer.MoveTapeToDrive(DRIVE, "SIM000L8")
+	if err != nil {
+		t.Errorf("loading a tape of the partition: %v", err)
+	}
+}
+
+func TestPartitionUnloadsIntoOwnSlots(t *testing.T) {
+	library, changer := newPartitionedLibrary(t)
+	err := changer.MoveTapeToDrive(DRIVE, "SIM000L8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The home slot is taken and the rest of the partition is full, only slots outside it are free
+	move(t, library, SLOT_0+4, SLOT_0)
+
+	err = changer.MoveDriveTapeToStorage(DRIVE)
+	if err == nil {
+		t.Fatalf("tape was unloaded into a slot outside the partition")
+	}
+	if got := tapeAt(t, library, DRIVE); got != "SIM000L8" {
+		t.Errorf("drive holds %q after a failed unload, want SIM000L8", got)
+	}
+
+	move(t, library, SLOT_0+2, SLOT_0+7)
+	err = changer.MoveDriveTapeToStorage(DRIVE)
+	if err != nil {
+		t.Fatalf("MoveDriveTapeToStorage: %v", err)
+	}
+	if got := tapeAt(t, library, SLOT_0+2); got != "SIM000L8" {
+		t.Errorf("tape went elsewhere than the free slot of the partition, slot %d holds %q", SLOT_0+2, got)
+	}
+}
+
+func TestPartitionImportsIntoOwnSlots(t *testing.T) {
+	library, changer := newPartitionedLibrary(t)
+	move(t, library, SLOT_0, MAILSLOT)
+	move(t, library, SLOT_0+5, MAILSLOT+1)
+
+	moves, err := changer.ImportTapes()
+	if err != nil {
+		t.Fatalf("ImportTapes: %v", err)
+	}
+	want := []loader.Move{{VolumeTag: "SIM000L8", Source: MAILSLOT, Destination: SLOT_0}}
+	if !slices.Equal(moves, want) {
+		t.Errorf("moves %+v, want %+v", moves, want)
+	}
+	if got := tapeAt(t, library, MAILSLOT+1); got != "SIM005L8" {
+		t.Errorf("foreign tape was imported, mailslot holds %q", got)
+	}
+
+	// No free slot is left in the partition, though slots outside it are
+	move(t, library, SLOT_0+1, MAILSLOT)
+	move(t, library, SLOT_0+4, SLOT_0+1)
+	move(t, library, MAILSLOT+1, SLOT_0+4)
+	moves, err = changer.ImportTapes()
+	if err == nil || len(moves) != 0 {
+		t.Errorf("import into a full partition: got moves %+v and error %v", moves, err)
+	}
+	if got := tapeAt(t, library, MAILSLOT); got != "SIM001L8" {
+		t.Errorf("mailslot holds %q after a failed import, want SIM001L8", got)
+	}
+	for _, address := range []uint16{SLOT_0 + 5, SLOT_0 + 6, SLOT_0 + 7} {
+		if got := tapeAt(t, library, address); got != "" {
+			t.Errorf("slot %d outside the partition got tape %s", address, got)
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package manager
 
 import (
+	"context"
 	"crypto/rand"
 	"fmt"
 	"log"
+	"maps"
 	"math/big"
 	"path/filepath"
 	"slices"
@@ -20,7 +22,7 @@ type restoreFile struct {
 	decryptedPath string
 }
 
-func (m *Manager) Restore(filter FilterFunc, target string) error {
+func (m *Manager) Restore(ctx context.Context, filter FilterFunc, target string) error {
 	if !filepath.IsAbs(target) {
 		return fmt.Errorf("target path %s is not absolute", target)
 	}
@@ -40,60 +42,76 @@ func (m *Manager) Restore(filter FilterFunc, target string) error {
 		allFileMap[barcode][decryptedPath] = file
 	}
 
-	for barcode, filesMap := range allFileMap {
-		log.Printf("Copying from tape %s", barcode)
-		tape := m.inventory.GetOrCreateTape(barcode)
-		err := m.loadAndMount(tape)
-		if err != nil {
-			return err
-		}
+	barcodes := slices.Sorted(maps.Keys(allFileMap))
 
-		fileInfos := make([]*restoreFile, 0, len(filesMap))
-		for decryptedPath, file := range filesMap {
-			var fileInfo *inventory.FileLTFSInfo
-			if DryRun {
-				sb, _ := rand.Int(rand.Reader, big.NewInt(1<<32-1))
-				sb64 := sb.Int64()
-				part := "a"
-				if sb64%2 == 1 {
-					part = "b"
-				}
-				fileInfo = &inventory.FileLTFSInfo{
-					Partition:  part,
-					StartBlock: int(sb64),
-				}
-			} else {
-				fileInfo, err = file.GetLTFSInfo(m.drive)
-				if err != nil {
-					return err
-				}
+	// Each drive restores the files of one tape at a time, the loader serializes the moves.
+	// A tape still in a drive from an earlier operation is restored by that drive.
+	return parallel(ctx, m.drives, m.driveWith, func(ctx context.Context, send func(barcode string) error) error {
+		for _, barcode := range barcodes {
+			err := send(barcode)
+			if err != nil {
+				return err
 			}
-			fileInfos = append(fileInfos, &restoreFile{
-				info:          fileInfo,
-				file:          file,
-				decryptedPath: decryptedPath,
-			})
 		}
+		return nil
+	}, func(ctx context.Context, drive *managedDrive, barcode string) error {
+		return drive.restoreTape(ctx, barcode, allFileMap[barcode], target)
+	})
+}
 
-		slices.SortFunc(fileInfos, func(a, b *restoreFile) int {
-			partitionCmp := strings.Compare(a.info.Partition, b.info.Partition)
-			if partitionCmp != 0 {
-				return partitionCmp
-			}
-			return a.info.StartBlock - b.info.StartBlock
-		})
+func (m *managedDrive) restoreTape(ctx context.Context, barcode string, filesMap map[string]inventory.File, target string) error {
+	log.Printf("Copying from tape %s in drive %d", barcode, m.loaderDriveAddress)
+	tape := m.inventory.GetOrCreateTape(barcode)
+	err := m.loadAndMount(ctx, tape)
+	if err != nil {
+		return err
+	}
 
-		for _, fileInfo := range fileInfos {
-			filePath := filepath.Join(m.drive.MountPoint(), fileInfo.file.GetPath())
-			log.Printf("[COPY] %s", filePath)
-			if DryRun {
-				continue
+	fileInfos := make([]*restoreFile, 0, len(filesMap))
+	for decryptedPath, file := range filesMap {
+		var fileInfo *inventory.FileLTFSInfo
+		if DryRun {
+			sb, _ := rand.Int(rand.Reader, big.NewInt(1<<32-1))
+			sb64 := sb.Int64()
+			part := "a"
+			if sb64%2 == 1 {
+				part = "b"
+			}
+			fileInfo = &inventory.FileLTFSInfo{
+				Partition:  part,
+				StartBlock: int(sb64),
 			}
-			err := m.file.DecryptMkdirAll(filePath, filepath.Join(target, fileInfo.decryptedPath))
+		} else {
+			fileInfo, err = file.GetLTFSInfo(m.drive)
 			if err != nil {
 				return err
 			}
 		}
+		fileInfos = append(fileInfos, &restoreFile{
+			info:          fileInfo,
+			file:          file,
+			decryptedPath: decryptedPath,
+		})
+	}
+
+	slices.SortFunc(fileInfos, func(a, b *restoreFile) int {
+		partitionCmp := strings.Compare(a.info.Partition, b.info.Partition)
+		if partitionCmp != 0 {
+			return partitionCmp
+		}
+		return a.info.StartBlock - b.info.StartBlock
+	})
+
+	for _, fileInfo := range fileInfos {
+		filePath := filepath.Join(m.drive.MountPoint(), fileInfo.file.GetPath())
+		log.Printf("[COPY] %s", filePath)
+		if DryRun {
+			continue
+		}
+		err := m.file.DecryptMkdirAll(ctx, filePath, filepath.Join(target, fileInfo.decryptedPath))
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -1,24 +1,26 @@
 package manager
 
 import (
+	"context"
 	"log"
 )
 
-func (m *Manager) ScanTape(barcode string) error {
+func (m *Manager) ScanTape(ctx context.Context, barcode string) error {
 	tape := m.inventory.GetOrCreateTape(barcode)
+	drive := m.driveFor(barcode)
 
-	err := m.loadAndMount(tape)
+	err := drive.loadAndMount(ctx, tape)
 	if err != nil {
 		return err
 	}
 
 	defer func() {
-		_ = m.drive.Unmount()
+		_ = drive.drive.Unmount()
 	}()
-	return m.scanCurrentTape()
+	return drive.scanCurrentTape()
 }
 
-func (m *Manager) scanCurrentTape() error {
+func (m *managedDrive) scanCurrentTape() error {
 	barcode := m.currentTape.GetBarcode()
 	log.Printf("Re-inventorying tape %s", barcode)
 	defer log.Printf("Finished re-inventorying tape %s", barcode)
@@ -26,5 +28,7 @@ func (m *Manager) scanCurrentTape() error {
 		return nil
 	}
 
-	return m.currentTape.LoadFrom(m.drive)
+	return m.updateTape(func() error {
+		return m.currentTape.LoadFrom(m.drive)
+	})
 }
@@ -0,0 +1,84 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+)
+
+var ErrTapeHasVolume = errors.New("tape already carries an LTFS volume")
+
+// ParseScratchBarcodes compiles the barcode patterns of tapes that may be formatted without registering them
+func ParseScratchBarcodes(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, str := range patterns {
+		pattern, err := regexp.Compile(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scratch barcode pattern %q: %w", str, err)
+		}
+		compiled = append(compiled, pattern)
+	}
+	return compiled, nil
+}
+
+// isScratch reports whether an unknown tape may be formatted when a new tape is needed
+func (m *Manager) isScratch(barcode string) bool {
+	if m.inventory.IsScratch(barcode) {
+		return true
+	}
+	for _, pattern := range m.ScratchBarcodes {
+		if pattern.MatchString(barcode) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddScratchTapes registers blank tapes to be formatted once a new tape is needed
+func (m *Manager) AddScratchTapes(barcodes ...string) error {
+	for _, barcode := range barcodes {
+		if element.IsCleaningVolumeTag(barcode) {
+			return fmt.Errorf("%s is a cleaning cartridge", barcode)
+		}
+		if m.inventory.HasTape(barcode) {
+			return fmt.Errorf("tape %s is already formatted and in the inventory", barcode)
+		}
+	}
+
+	if DryRun {
+		log.Printf("Would add tapes to the scratch pool: %s", strings.Join(barcodes, ", "))
+		return nil
+	}
+	return m.inventory.AddScratch(barcodes...)
+}
+
+// RemoveScratchTapes unregisters scratch tapes, tapes matching a scratch barcode pattern stay in the pool
+func (m *Manager) RemoveScratchTapes(barcodes ...string) error {
+	if DryRun {
+		log.Printf("Would remove tapes from the scratch pool: %s", strings.Join(barcodes, ", "))
+		return nil
+	}
+	return m.inventory.RemoveScratch(barcodes...)
+}
+
+// ScratchTapes returns the registered scratch tapes, not the ones only matching a scratch barcode pattern
+func (m *Manager) ScratchTapes() []string {
+	return m.inventory.ScratchTapes()
+}
+
+// checkNoVolume refuses to format a cartridge LTFS was used on, it may hold another system's data
+func (m *managedDrive) checkNoVolume(ctx context.Context, barcode string) error {
+	mam, err := m.drive.MediumAuxiliaryMemory(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot check tape %s for an LTFS volume: %w", barcode, err)
+	}
+	if mam.HasLTFSVolume() {
+		return fmt.Errorf("%w (formatted by %s)", ErrTapeHasVolume, mam.Application())
+	}
+	return nil
+}
@@ -0,0 +1,619 @@
+package manager_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"syscall"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+	"github.com/FoxDenHome/tapemgr/scsi/sim"
+	"github.com/FoxDenHome/tapemgr/storage/encryption"
+	"github.com/FoxDenHome/tapemgr/storage/inventory"
+	"github.com/FoxDenHome/tapemgr/storage/manager"
+)
+
+// A tape switch happens once less than TAPE_SIZE_SPARE is left, the simulated
+// tapes have just a few KiB more than that, so every test file needs a tape of its own
+const (
+	SIM_CAPACITY  = "1048580K"
+	TEST_FILESIZE = 3000
+)
+
+type simSetup struct {
+	manager   *manager.Manager
+	inventory *inventory.Inventory
+	tapesPath string
+	source    string
+	// libraryPath is where the simulator keeps its state and tape contents
+	libraryPath string
+
+	library *sim.Library
+	// drive is the first of drives, the one single drive tests use
+	drive       *sim.Drive
+	drives      []*sim.Drive
+	fileCryptor *encryption.FileCryptor
+	pathCryptor *encryption.PathCryptor
+}
+
+// newSimSetup creates a library with one drive and the given number of tapes, of which the
+// writeProtected ones have their write-protect tab set
+func newSimSetup(t *testing.T, tapes string, writeProtected ...string) *simSetup {
+	t.Helper()
+	return newSimSetupQuery(t, "drives=1&tapes="+tapes+"&capacity="+SIM_CAPACITY, writeProtected...)
+}
+
+// newSimSetupQuery creates a library from the given sim:// query, the manager uses all of its drives
+func newSimSetupQuery(t *testing.T, query string, writeProtected ...string) *simSetup {
+	t.Helper()
+	dir := t.TempDir()
+	manager.DryRun = false
+
+	libraryURL := "sim://" + filepath.Join(dir, "lib") + "?" + query
+	library, err := sim.Open(libraryURL)
+	if err != nil {
+		t.Fatalf("opening simulated library: %v", err)
+	}
+	if len(writeProtected) > 0 {
+		setWriteProtected(t, filepath.Join(dir, "lib", sim.STATE_FILE), writeProtected)
+		library, err = sim.Open(libraryURL)
+		if err != nil {
+			t.Fatalf("reopening simulated library: %v", err)
+		}
+	}
+	drives := library.Drives()
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileCryptor, err := encryption.NewFileCryptor(identity.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pathCryptor, err := encryption.NewPathCryptor(bytes.Repeat([]byte{0x42}, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := filepath.Join(dir, "src")
+	err = os.Mkdir(source, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tapesPath := filepath.Join(dir, "tapes")
+	err = os.Mkdir(tapesPath, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &simSetup{
+		tapesPath:   tapesPath,
+		source:      source,
+		libraryPath: filepath.Join(dir, "lib"),
+		library:     library,
+		drive:       drives[0],
+		drives:      drives,
+		fileCryptor: fileCryptor,
+		pathCryptor: pathCryptor,
+	}
+	s.manager, s.inventory = s.newManager(t, s.tapesPath)
+	return s
+}
+
+// newManager creates another manager on the library of the setup, with the inventory in tapesPath,
+// like a second run of tapemgr or a host that lost its inventory would
+func (s *simSetup) newManager(t *testing.T, tapesPath string) (*manager.Manager, *inventory.Inventory) {
+	t.Helper()
+	inv, err := inventory.New(tapesPath)
+	if err != nil {
+		t.Fatalf("creating inventory: %v", err)
+	}
+
+	var drives []manager.DriveConfig
+	for _, drive := range s.drives {
+		drives = append(drives, manager.DriveConfig{
+			Drive:          drive,
+			ElementAddress: manager.DRIVE_ADDRESS_AUTO,
+		})
+	}
+	m, err := manager.New(s.fileCryptor, s.pathCryptor, inv, s.library, drives...)
+	if err != nil {
+		t.Fatalf("creating manager: %v", err)
+	}
+	return m, inv
+}
+
+// setWriteProtected edits the library state like an operator would, the simulator has no API for it
+func setWriteProtected(t *testing.T, statePath string, volumeTags []string) {
+	t.Helper()
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state map[string]any
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	media, _ := state["media"].(map[string]any)
+	if media == nil {
+		media = map[string]any{}
+	}
+	for _, volumeTag := range volumeTags {
+		media[volumeTag] = map[string]any{"load-count": 0, "write-protected": true}
+	}
+	state["media"] = media
+
+	data, err = json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(statePath, data, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (s *simSetup) writeFile(t *testing.T, name string) []byte {
+	t.Helper()
+	data := make([]byte, TEST_FILESIZE)
+	_, _ = rand.Read(data)
+	err := os.WriteFile(filepath.Join(s.source, name), data, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestSimBackupRestore(t *testing.T) {
+	s := newSimSetup(t, "4")
+	s.manager.ScratchBarcodes = []*regexp.Regexp{regexp.MustCompile("^SIM00[0-2]")}
+	ctx := context.Background()
+
+	files := map[string][]byte{}
+	for _, name := range []string{"a", "b", "c"} {
+		files[name] = s.writeFile(t, name)
+	}
+
+	err := s.manager.Backup(ctx, s.source)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	// Every file filled a tape, so each backup switched to a newly formatted one
+	if count := s.inventory.TapeCount(); count != 3 {
+		t.Fatalf("backup used %d tapes, want 3", count)
+	}
+
+	// SIM003L8 is not in the scratch pool, so there is no tape left for another file
+	files["d"] = s.writeFile(t, "d")
+	err = s.manager.Backup(ctx, s.source)
+	if !errors.Is(err, manager.ErrNoTapeAvailable) {
+		t.Fatalf("Backup without a scratch tape: got %v, want %v", err, manager.ErrNoTapeAvailable)
+	}
+
+	err = s.manager.AddScratchTapes("SIM003L8")
+	if err != nil {
+		t.Fatalf("AddScratchTapes: %v", err)
+	}
+	err = s.manager.Backup(ctx, s.source)
+	if err != nil {
+		t.Fatalf("Backup with a scratch tape: %v", err)
+	}
+	if count := s.inventory.TapeCount(); count != 4 {
+		t.Fatalf("backup used %d tapes, want 4", count)
+	}
+	if s.inventory.IsScratch("SIM003L8") {
+		t.Errorf("formatted tape SIM003L8 is still in the scratch pool")
+	}
+
+	s.checkRestore(t, s.manager, files)
+}
+
+// checkRestore restores everything m knows about and compares it against files
+func (s *simSetup) checkRestore(t *testing.T, m *manager.Manager, files map[string][]byte) {
+	t.Helper()
+	target := t.TempDir()
+	err := m.Restore(context.Background(), func(path string, info inventory.File) bool { return true }, target)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	for name, data := range files {
+		restored, err := os.ReadFile(filepath.Join(target, s.source, name))
+		if err != nil {
+			t.Errorf("reading restored file %s: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(restored, data) {
+			t.Errorf("restored file %s differs", name)
+		}
+	}
+}
+
+func TestSimBackupScanRestore(t *testing.T) {
+	s := newSimSetup(t, "3")
+	s.manager.ScratchBarcodes = []*regexp.Regexp{regexp.MustCompile("^SIM")}
+	ctx := context.Background()
+
+	files := map[string][]byte{}
+	for _, name := range []string{"a", "b"} {
+		files[name] = s.writeFile(t, name)
+	}
+	err := s.manager.Backup(ctx, s.source)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	barcodes := map[string]int{}
+	for _, tp := range s.inventory.GetTapesSortByFreeDesc() {
+		barcodes[tp.GetBarcode()] = len(tp.GetFiles())
+	}
+	if len(barcodes) != 2 {
+		t.Fatalf("backup used tapes %v, want 2", barcodes)
+	}
+
+	// A second host starts without an inventory and rebuilds it from the tapes
+	m, inv := s.newManager(t, t.TempDir())
+	for barcode := range barcodes {
+		err = m.ScanTape(ctx, barcode)
+		if err != nil {
+			t.Fatalf("ScanTape(%s): %v", barcode, err)
+		}
+	}
+	if count := inv.TapeCount(); count != 2 {
+		t.Fatalf("scan found %d tapes, want 2", count)
+	}
+	for _, tp := range inv.GetTapesSortByFreeDesc() {
+		if len(tp.GetFiles()) != barcodes[tp.GetBarcode()] {
+			t.Errorf("scan found %d files on %s, backup wrote %d", len(tp.GetFiles()), tp.GetBarcode(), barcodes[tp.GetBarcode()])
+		}
+		if tp.GetSize() == 0 {
+			t.Errorf("scan did not record the size of %s", tp.GetBarcode())
+		}
+	}
+
+	s.checkRestore(t, m, files)
+}
+
+// driveTapes returns the tapes in the drives of the simulated library
+func (s *simSetup) driveTapes(t *testing.T) []string {
+	t.Helper()
+	elements, err := s.library.GetElements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tapes []string
+	for _, elem := range elements {
+		if elem.ElementType == element.ELEMENT_TYPE_DATA_TRANSFER {
+			tapes = append(tapes, elem.VolumeTag)
+		}
+	}
+	return tapes
+}
+
+func TestSimTwoDrivesBackupRestore(t *testing.T) {
+	s := newSimSetupQuery(t, "drives=2&tapes=4&capacity=4G")
+	s.manager.ScratchBarcodes = []*regexp.Regexp{regexp.MustCompile("^SIM")}
+	ctx := context.Background()
+
+	files := map[string][]byte{}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		files[name] = s.writeFile(t, name)
+	}
+	err := s.manager.Backup(ctx, s.source)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+
+	// Each drive formats at most one tape, which takes every file it stores
+	count := s.inventory.TapeCount()
+	if count < 1 || count > 2 {
+		t.Fatalf("backup used %d tapes, want one per drive at most", count)
+	}
+	stored, sharing := 0, false
+	for _, tp := range s.inventory.GetTapesSortByFreeDesc() {
+		stored += len(tp.GetFiles())
+		sharing = sharing || len(tp.GetFiles()) >= 2
+	}
+	if stored != len(files) || !sharing {
+		t.Fatalf("backup stored %d files on %d tapes, want %d with several on one tape", stored, count, len(files))
+	}
+
+	// The tapes are still in the drives, each is restored by the drive holding it
+	s.checkRestore(t, s.manager, files)
+
+	// The tombstone goes onto a tape in one of the drives rather than loading another, even with
+	// the first drive empty. Drives take files as they become free, so either may hold a tape.
+	loaded := s.driveTapes(t)
+	if loaded[1] != "" {
+		err = s.manager.UnloadDrive(sim.ADDRESS_DRIVE, nil)
+		if err != nil {
+			t.Fatalf("UnloadDrive: %v", err)
+		}
+		loaded[0] = ""
+	}
+	err = os.Remove(filepath.Join(s.source, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	delete(files, "a")
+	err = s.manager.Backup(ctx, s.source)
+	if err != nil {
+		t.Fatalf("Backup after removing a file: %v", err)
+	}
+	if tapes := s.driveTapes(t); !slices.Equal(tapes, loaded) {
+		t.Errorf("drives hold %v after writing the tombstone, want %v", tapes, loaded)
+	}
+	if bestFiles := s.inventory.GetBestFiles(s.pathCryptor); len(bestFiles) != len(files) {
+		t.Errorf("inventory has %d files after the tombstone, want %d", len(bestFiles), len(files))
+	}
+
+	// A second restore finds the tapes in the drives the first one left them in
+	s.checkRestore(t, s.manager, files)
+}
+
+func TestSimLabelMismatchOnlyBlocksWrites(t *testing.T) {
+	// Room for more than one file per tape
+	s := newSimSetupQuery(t, "tapes=2&capacity=4G")
+	s.manager.ScratchBarcodes = []*regexp.Regexp{regexp.MustCompile("^SIM000")}
+	ctx := context.Background()
+
+	files := map[string][]byte{"a": s.writeFile(t, "a")}
+	err := s.manager.Backup(ctx, s.source)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+
+	// The cartridge now claims to be another tape, as if its barcode label had been swapped
+	err = os.WriteFile(filepath.Join(s.libraryPath, sim.TAPES_DIR, "SIM000L8", sim.LABEL_FILE), []byte("tapemgr barcode=SIM001L8 formatted=2026-01-01T00:00:00Z"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every run starts without a tape it trusts in the drive
+	m, _ := s.newManager(t, s.tapesPath)
+	s.checkRestore(t, m, files)
+	err = m.ScanTape(ctx, "SIM000L8")
+	if err != nil {
+		t.Errorf("ScanTape: %v", err)
+	}
+
+	m, _ = s.newManager(t, s.tapesPath)
+	err = os.Remove(filepath.Join(s.source, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = s.writeFile(t, "b")
+	err = m.Backup(ctx, s.source)
+	if !errors.Is(err, manager.ErrTapeLabelMismatch) {
+		t.Errorf("Backup onto the mislabelled tape: got %v, want %v", err, manager.ErrTapeLabelMismatch)
+	}
+}
+
+func TestSimFormat(t *testing.T) {
+	s := newSimSetup(t, "2")
+	ctx := context.Background()
+
+	err := s.manager.FormatTape(ctx, "SIM000L8", false)
+	if err != nil {
+		t.Fatalf("FormatTape: %v", err)
+	}
+	if !s.inventory.HasTape("SIM000L8") {
+		t.Errorf("formatted tape is not in the inventory")
+	}
+
+	err = s.manager.FormatTape(ctx, "SIM000L8", false)
+	if !errors.Is(err, manager.ErrTapeHasVolume) {
+		t.Fatalf("formatting an LTFS tape again: got %v, want %v", err, manager.ErrTapeHasVolume)
+	}
+	err = s.manager.FormatTape(ctx, "SIM000L8", true)
+	if err != nil {
+		t.Fatalf("FormatTape with force: %v", err)
+	}
+
+	err = s.manager.UnmountAndUnload()
+	if err != nil {
+		t.Fatalf("UnmountAndUnload: %v", err)
+	}
+}
+
+func TestSimExportLoadedTape(t *testing.T) {
+	s := newSimSetup(t, "2")
+	ctx := context.Background()
+
+	err := s.manager.FormatTape(ctx, "SIM000L8", false)
+	if err != nil {
+		t.Fatalf("FormatTape: %v", err)
+	}
+
+	// The formatted tape is still mounted, exporting it has to unload it first
+	err = s.manager.ExportTapes("SIM000L8")
+	if err != nil {
+		t.Fatalf("ExportTapes: %v", err)
+	}
+	err = s.manager.ImportTapes()
+	if err != nil {
+		t.Fatalf("ImportTapes: %v", err)
+	}
+}
+
+func TestSimRefusedFormatStaysOutOfInventory(t *testing.T) {
+	s := newSimSetup(t, "2", "SIM000L8")
+	s.manager.ScratchBarcodes = []*regexp.Regexp{regexp.MustCompile("^SIM")}
+	ctx := context.Background()
+
+	err := s.manager.FormatTape(ctx, "SIM000L8", false)
+	if !errors.Is(err, manager.ErrTapeWriteProtected) {
+		t.Fatalf("formatting a write-protected tape: got %v, want %v", err, manager.ErrTapeWriteProtected)
+	}
+	if s.inventory.HasTape("SIM000L8") {
+		t.Errorf("refused tape was added to the inventory")
+	}
+	_, err = os.Stat(filepath.Join(s.tapesPath, "SIM000L8.proto"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("refused tape has an inventory file: %v", err)
+	}
+
+	// The backup skips the write-protected scratch tape and formats the other one
+	s.writeFile(t, "a")
+	err = s.manager.Backup(ctx, s.source)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if s.inventory.HasTape("SIM000L8") || !s.inventory.HasTape("SIM001L8") {
+		t.Errorf("backup used the wrong tape")
+	}
+	for _, tape := range s.inventory.GetTapesSortByFreeDesc() {
+		if tape.GetSize() == 0 {
+			t.Errorf("tape %s is in the inventory without a filesystem size", tape.GetBarcode())
+		}
+	}
+
+	// What was learned about the refused tape survives, without making it part of the inventory
+	inv, err := inventory.New(s.tapesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.HasTape("SIM000L8") || inv.TapeCount() != 1 {
+		t.Errorf("reloaded inventory has %d tapes, want only SIM001L8", inv.TapeCount())
+	}
+	refused := inv.GetOrNewTape("SIM000L8")
+	if !refused.GetWriteProtected() {
+		t.Errorf("write protection of the refused tape was not kept")
+	}
+	_, err = os.Stat(filepath.Join(s.tapesPath, inventory.BLANK_DIR, "SIM001L8.proto"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("formatted tape still has a blank tape file: %v", err)
+	}
+}
+
+func TestSimMoveTapeOutOfDrive(t *testing.T) {
+	s := newSimSetup(t, "2")
+	ctx := context.Background()
+
+	err := s.manager.FormatTape(ctx, "SIM000L8", false)
+	if err != nil {
+		t.Fatalf("FormatTape: %v", err)
+	}
+
+	const mailslot = 16
+	err = s.manager.MoveTape("SIM000L8", mailslot)
+	if err != nil {
+		t.Fatalf("MoveTape: %v", err)
+	}
+	statuses, err := s.manager.LibraryStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.Barcode == "SIM000L8" {
+			if status.Address != mailslot {
+				t.Errorf("tape SIM000L8 is in element %d, want mailslot %d", status.Address, mailslot)
+			}
+			return
+		}
+	}
+	t.Errorf("tape SIM000L8 is not in the library")
+}
+
+func TestSimSkipsScratchTapeOfOtherPool(t *testing.T) {
+	s := newSimSetup(t, "2")
+	s.manager.ScratchBarcodes = []*regexp.Regexp{regexp.MustCompile("^SIM")}
+	s.manager.PoolID = "main"
+	ctx := context.Background()
+
+	// A label without a volume, as left by another pool's format that failed in mkltfs
+	labelDir := filepath.Join(s.libraryPath, sim.TAPES_DIR, "SIM000L8")
+	err := os.MkdirAll(labelDir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(labelDir, sim.LABEL_FILE), []byte("tapemgr barcode=SIM000L8 pool=other formatted=2026-01-01T00:00:00Z"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.writeFile(t, "a")
+	err = s.manager.Backup(ctx, s.source)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if s.inventory.HasTape("SIM000L8") || !s.inventory.HasTape("SIM001L8") {
+		t.Errorf("backup used the tape of the other pool")
+	}
+}
+
+func TestSimCancelledBackupRemovesPartialFile(t *testing.T) {
+	s := newSimSetup(t, "2")
+	s.manager.ScratchBarcodes = []*regexp.Regexp{regexp.MustCompile("^SIM")}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Reading from a pipe blocks, so the backup can be cancelled while it is copying the file
+	fifo := filepath.Join(s.source, "pipe")
+	err := syscall.Mkfifo(fifo, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writerDone := make(chan error, 1)
+	go func() {
+		writer, err := os.OpenFile(fifo, os.O_WRONLY, 0)
+		if err != nil {
+			writerDone <- err
+			return
+		}
+		defer func() {
+			_ = writer.Close()
+		}()
+		// More than the pipe buffer, so the backup has written part of the file once this returns
+		_, err = writer.Write(make([]byte, 1024*1024))
+		cancel()
+		if err == nil {
+			// Wakes up the blocked read, which then notices the cancellation
+			_, err = writer.Write(make([]byte, 1024))
+		}
+		if errors.Is(err, syscall.EPIPE) {
+			err = nil
+		}
+		writerDone <- err
+	}()
+
+	err = s.manager.Backup(ctx, s.source)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled Backup: got %v, want %v", err, context.Canceled)
+	}
+	err = <-writerDone
+	if err != nil {
+		t.Fatalf("writing to the pipe: %v", err)
+	}
+
+	err = filepath.WalkDir(filepath.Join(s.libraryPath, sim.TAPES_DIR), func(path string, entry os.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() && entry.Name() != sim.LABEL_FILE {
+			t.Errorf("partial file %s left on the tape", path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The inventory on disk must match the tape, which holds no files
+	err = s.inventory.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tape := range s.inventory.GetTapesSortByFreeDesc() {
+		if len(tape.GetFiles()) > 0 {
+			t.Errorf("tape %s lists files after the cancelled backup: %v", tape.GetBarcode(), tape.GetFiles())
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package manager
+
+import (
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/scsi/element"
+)
+
+type ElementStatus struct {
+	Type       string  `json:"type"`
+	Address    uint16  `json:"address"`
+	Full       bool    `json:"full"`
+	Barcode    string  `json:"barcode,omitempty"`
+	Source     *uint16 `json:"source,omitempty"`
+	Accessible bool    `json:"accessible"`
+	Exception  string  `json:"exception,omitempty"`
+	Known      bool    `json:"known"`
+	Cleaning   bool    `json:"cleaning,omitempty"`
+	UsesLeft   int     `json:"uses-left,omitempty"`
+	Size       int64   `json:"size,omitempty"`
+	Free       int64   `json:"free,omitempty"`
+}
+
+func (m *Manager) LibraryStatus() ([]*ElementStatus, error) {
+	elements, err := m.loader.GetElements()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*ElementStatus, 0, len(elements))
+	for _, elem := range elements {
+		status := &ElementStatus{
+			Type:       elem.ElementType.String(),
+			Address:    elem.Address,
+			Full:       elem.HasFlag(element.FLAG_FULL),
+			Accessible: elem.HasFlag(element.FLAG_ACCESS),
+		}
+
+		if elem.HasFlag(element.FLAG_EXCEPTION) {
+			status.Exception = scsi.DescribeASC(elem.ExceptionSenseCode, elem.ExceptionSenseCodeQualifier)
+		}
+
+		if status.Full {
+			status.Barcode = elem.VolumeTag
+			if elem.HasFlag(element.FLAG_SOURCE_INVERT_VALID) {
+				source := elem.SourceElementAddress
+				status.Source = &source
+			}
+
+			if elem.IsCleaning() {
+				status.Cleaning = true
+				status.UsesLeft = m.CleaningUsesLeft(elem.VolumeTag)
+			} else if m.inventory.HasTape(elem.VolumeTag) {
+				tape := m.inventory.GetOrCreateTape(elem.VolumeTag)
+				status.Known = true
+				status.Size = tape.GetSize()
+				status.Free = tape.GetFree()
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
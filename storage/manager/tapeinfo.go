@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/FoxDenHome/tapemgr/scsi"
+	"github.com/FoxDenHome/tapemgr/storage/inventory"
+)
+
+var ErrTapeInfoDryRun = errors.New("reading tape info requires loading the tape, which dry-run mode does not do")
+
+type TapeInfo struct {
+	Barcode string                      `json:"barcode"`
+	Known   bool                        `json:"known"`
+	Size    int64                       `json:"size,omitempty"`
+	Free    int64                       `json:"free,omitempty"`
+	Label   string                      `json:"label"`
+	Medium  *scsi.MediumAuxiliaryMemory `json:"medium"`
+}
+
+// TapeInfo loads a tape and reads its cartridge memory, recording it in the inventory if the tape is known
+func (m *Manager) TapeInfo(ctx context.Context, barcode string) (*TapeInfo, error) {
+	if DryRun {
+		return nil, ErrTapeInfoDryRun
+	}
+	return m.driveFor(barcode).tapeInfo(ctx, barcode)
+}
+
+func (m *managedDrive) tapeInfo(ctx context.Context, barcode string) (*TapeInfo, error) {
+	info := &TapeInfo{
+		Barcode: barcode,
+		Known:   m.inventory.HasTape(barcode),
+	}
+
+	var tape inventory.Tape
+	var err error
+	if info.Known {
+		tape = m.inventory.GetOrCreateTape(barcode)
+		// Skip label verification, tape-info is how a mislabelled tape gets diagnosed
+		err = m.loadTapeUnverified(ctx, tape)
+	} else {
+		// Going through loadTape would add the tape to the inventory and so mark it as formatted
+		err = m.drive.Unmount()
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmount drive: %v", err)
+		}
+		_ = m.setCurrentTape(nil)
+		err = m.moveTapeToDrive(ctx, barcode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info.Medium, err = m.drive.MediumAuxiliaryMemory(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read medium auxiliary memory of tape %s: %w", barcode, err)
+	}
+
+	info.Label, err = m.drive.HostLabel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read label of tape %s: %w", barcode, err)
+	}
+
+	if tape != nil {
+		err = tape.RecordMedium(info.Medium)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record medium of tape %s: %w", barcode, err)
+		}
+		info.Size = tape.GetSize()
+		info.Free = tape.GetFree()
+	}
+
+	return info, nil
+}
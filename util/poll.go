@@ -0,0 +1,45 @@
+package util
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	POLL_INITIAL_INTERVAL = 100 * time.Millisecond
+	POLL_MAX_INTERVAL     = 5 * time.Second
+)
+
+// Poll calls check with exponential backoff until it reports done, returns an error,
+// or ctx is done. A timeout > 0 bounds the total time spent polling.
+func Poll(ctx context.Context, timeout time.Duration, check func() (bool, error)) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	interval := POLL_INITIAL_INTERVAL
+	for {
+		done, err := check()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		interval *= 2
+		if interval > POLL_MAX_INTERVAL {
+			interval = POLL_MAX_INTERVAL
+		}
+	}
+}